@@ -0,0 +1,311 @@
+package main
+
+import (
+	"OrderManager/common"
+	"OrderManager/pb"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	SCOPE_READ          = "read"
+	SCOPE_TASKS_WRITE   = "tasks:write"
+	SCOPE_PATCHES_WRITE = "patches:write"
+)
+
+// API Key 格式：om_<前缀>_<密钥>
+const apiKeyPrefix = "om_"
+
+// 最近使用时间的更新间隔，避免每次请求都写库
+const apiKeyTouchInterval = time.Minute
+
+// 各 RPC 需要的权限范围，未列出的 RPC 不允许通过 API Key 调用
+var methodScopes = map[string]string{
+	pb.Service_GetTaskListAll_FullMethodName:       SCOPE_READ,
+	pb.Service_GetTaskListOne_FullMethodName:       SCOPE_READ,
+	pb.Service_QueryTaskWithField_FullMethodName:   SCOPE_READ,
+	pb.Service_GetPatchsAll_FullMethodName:         SCOPE_READ,
+	pb.Service_GetOnePatchs_FullMethodName:         SCOPE_READ,
+	pb.Service_GetTaskHistory_FullMethodName:       SCOPE_READ,
+	pb.Service_GetPatchHistory_FullMethodName:      SCOPE_READ,
+	pb.Service_ListTrash_FullMethodName:            SCOPE_READ,
+	pb.Service_ListComments_FullMethodName:         SCOPE_READ,
+	pb.Service_ListWorkLogs_FullMethodName:         SCOPE_READ,
+	pb.Service_GetWorkReport_FullMethodName:        SCOPE_READ,
+	pb.Service_ListTaskDependencies_FullMethodName: SCOPE_READ,
+	pb.Service_ListTaskTypes_FullMethodName:        SCOPE_READ,
+	pb.Service_ListLabels_FullMethodName:           SCOPE_READ,
+	pb.Service_ListImportBatches_FullMethodName:    SCOPE_READ,
+	pb.Service_GetImportBatch_FullMethodName:       SCOPE_READ,
+	pb.Service_ExportTasks_FullMethodName:          SCOPE_READ,
+	pb.Service_ExportPatchs_FullMethodName:         SCOPE_READ,
+	pb.Service_ExportWorkReport_FullMethodName:     SCOPE_READ,
+	pb.Service_ListTaskCommits_FullMethodName:      SCOPE_READ,
+	pb.Service_ListLeaves_FullMethodName:           SCOPE_READ,
+	pb.Service_ListBusinessDays_FullMethodName:     SCOPE_READ,
+	pb.Service_ListEscalationRules_FullMethodName:  SCOPE_READ,
+	pb.Service_ListEscalations_FullMethodName:      SCOPE_READ,
+	pb.Service_GetCapacity_FullMethodName:          SCOPE_READ,
+
+	pb.Service_ImportXLSToTaskTable_FullMethodName: SCOPE_TASKS_WRITE,
+	pb.Service_AddTask_FullMethodName:              SCOPE_TASKS_WRITE,
+	pb.Service_ModTask_FullMethodName:              SCOPE_TASKS_WRITE,
+	pb.Service_DelTask_FullMethodName:              SCOPE_TASKS_WRITE,
+	pb.Service_RestoreTask_FullMethodName:          SCOPE_TASKS_WRITE,
+	pb.Service_LogWork_FullMethodName:              SCOPE_TASKS_WRITE,
+	pb.Service_AddTaskDependency_FullMethodName:    SCOPE_TASKS_WRITE,
+	pb.Service_RemoveTaskDependency_FullMethodName: SCOPE_TASKS_WRITE,
+	pb.Service_MoveTask_FullMethodName:             SCOPE_TASKS_WRITE,
+	pb.Service_ImportExternalIssues_FullMethodName: SCOPE_TASKS_WRITE,
+	pb.Service_LinkCommits_FullMethodName:          SCOPE_TASKS_WRITE,
+
+	pb.Service_ImportXLSToPatchTable_FullMethodName: SCOPE_PATCHES_WRITE,
+	pb.Service_ModPatch_FullMethodName:              SCOPE_PATCHES_WRITE,
+	pb.Service_DelPatch_FullMethodName:              SCOPE_PATCHES_WRITE,
+	pb.Service_RestorePatch_FullMethodName:          SCOPE_PATCHES_WRITE,
+}
+
+// 同时处理修改单和补丁的 RPC，API Key 有任一写权限即可调用，
+// 由处理函数按对象类型调用 requireEntityScope 再做检查
+var entityScopedMethods = map[string]bool{
+	pb.Service_ImportXLSX_FullMethodName:          true,
+	pb.Service_RollbackImportBatch_FullMethodName: true,
+	pb.Service_AddLabels_FullMethodName:           true,
+	pb.Service_RemoveLabels_FullMethodName:        true,
+	pb.Service_AddComment_FullMethodName:          true,
+	pb.Service_EditComment_FullMethodName:         true,
+	pb.Service_DeleteComment_FullMethodName:       true,
+}
+
+var validScopes = map[string]bool{SCOPE_READ: true, SCOPE_TASKS_WRITE: true, SCOPE_PATCHES_WRITE: true}
+
+func authenticateAPIKey(ctx context.Context, key string) (context.Context, error) {
+	now := time.Now()
+	var apiKey APIKeyInfo
+	if err := db.Where("key_hash = ?", hashToken(key)).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errUnauthenticated
+		}
+		return nil, err
+	}
+	if apiKey.RevokedAt != nil || (apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(now)) {
+		return nil, errUnauthenticated
+	}
+	var user UserInfo
+	if err := db.First(&user, apiKey.UserID).Error; err != nil || !user.Active {
+		return nil, errUnauthenticated
+	}
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > apiKeyTouchInterval {
+		if err := db.Model(&apiKey).Update("last_used_at", now).Error; err != nil {
+			log.Println("更新 API Key 使用时间失败：", err)
+		}
+	}
+	ctx = context.WithValue(ctx, ctxKeyUser, &user)
+	return context.WithValue(ctx, ctxKeyScopes, strings.Split(apiKey.Scopes, ",")), nil
+}
+
+// 会话登录的用户不受限制，API Key 只能调用其权限范围内的 RPC
+func authorizeAPIKey(ctx context.Context, fullMethod string) error {
+	scopes, ok := ctx.Value(ctxKeyScopes).([]string)
+	if !ok {
+		return nil
+	}
+	required, ok := methodScopes[fullMethod]
+	for _, scope := range scopes {
+		if ok && scope == required {
+			return nil
+		}
+		if entityScopedMethods[fullMethod] && (scope == SCOPE_TASKS_WRITE || scope == SCOPE_PATCHES_WRITE) {
+			return nil
+		}
+	}
+	return status.Errorf(codes.PermissionDenied, "api key is not allowed to call %s", fullMethod)
+}
+
+// 通过 API Key 调用时检查对该类对象的写权限，会话登录的用户不受限制
+func requireEntityScope(ctx context.Context, entityType string) error {
+	scopes, ok := ctx.Value(ctxKeyScopes).([]string)
+	if !ok {
+		return nil
+	}
+	required := SCOPE_TASKS_WRITE
+	if entityType == AUDIT_ENTITY_PATCH {
+		required = SCOPE_PATCHES_WRITE
+	}
+	for _, scope := range scopes {
+		if scope == required {
+			return nil
+		}
+	}
+	return status.Errorf(codes.PermissionDenied, "api key requires %s scope", required)
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+func apiKeyInfoToPbAPIKey(k APIKeyInfo, account string) *pb.ApiKey {
+	return &pb.ApiKey{
+		Id:          uint64(k.ID),
+		Account:     account,
+		Prefix:      k.Prefix,
+		Scopes:      strings.Split(k.Scopes, ","),
+		Description: k.Description,
+		CreatedAt:   k.CreatedAt.Format("2006-01-02 15:04:05"),
+		ExpiresAt:   formatOptionalTime(k.ExpiresAt),
+		LastUsedAt:  formatOptionalTime(k.LastUsedAt),
+		RevokedAt:   formatOptionalTime(k.RevokedAt),
+		CreatedBy:   k.CreatedBy,
+	}
+}
+
+func findServiceAccount(tx *gorm.DB, name string) (*UserInfo, error) {
+	account, err := findUserByName(tx, name)
+	if err != nil {
+		return nil, err
+	}
+	if account.Source != AUTH_SOURCE_SERVICE {
+		return nil, fmt.Errorf("%s is not a service account", name)
+	}
+	return account, nil
+}
+
+// 服务账号使用负数工号（-1、-2 ...），不占用员工工号
+func (s *server) CreateServiceAccount(ctx context.Context, in *pb.CreateServiceAccountRequest) (*pb.CreateServiceAccountReply, error) {
+	if in.Name == "" || in.Email == "" {
+		return nil, errors.New("name and email are required")
+	}
+	account := UserInfo{Name: in.Name, Email: &in.Email, Active: true, Source: AUTH_SOURCE_SERVICE}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := requireAdmin(tx, operatorName(ctx, in.User)); err != nil {
+			return err
+		}
+		var minJobNo int
+		if err := tx.Model(&UserInfo{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("COALESCE(MIN(job_no), 0)").Where("job_no < 0").Scan(&minJobNo).Error; err != nil {
+			return err
+		}
+		jobNo := minJobNo - 1
+		account.JobNo = &jobNo
+		if err := checkUserUnique(tx, 0, account.Name, account.JobNo, account.Email); err != nil {
+			return err
+		}
+		return tx.Create(&account).Error
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil, errors.New("user already exists")
+	} else if err != nil {
+		return nil, err
+	}
+	return &pb.CreateServiceAccountReply{Account: common.UserInfoToPbUser(account)}, nil
+}
+
+func (s *server) CreateAPIKey(ctx context.Context, in *pb.CreateAPIKeyRequest) (*pb.CreateAPIKeyReply, error) {
+	operator := operatorName(ctx, in.User)
+	if err := requireAdmin(db, operator); err != nil {
+		return nil, err
+	}
+	if len(in.Scopes) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+	for _, scope := range in.Scopes {
+		if !validScopes[scope] {
+			return nil, fmt.Errorf("unknown scope: %s", scope)
+		}
+	}
+	account, err := findServiceAccount(db, in.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey := APIKeyInfo{
+		UserID:      account.ID,
+		Scopes:      strings.Join(in.Scopes, ","),
+		Description: in.Description,
+		CreatedBy:   operator,
+	}
+	if in.ExpiresAt != "" {
+		expiresAt, err := time.ParseInLocation("2006-01-02", in.ExpiresAt, time.Local)
+		if err != nil {
+			return nil, err
+		}
+		if !expiresAt.After(time.Now()) {
+			return nil, errors.New("expiresAt must be in the future")
+		}
+		apiKey.ExpiresAt = &expiresAt
+	}
+
+	prefix := make([]byte, 4)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, err
+	}
+	secret, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	apiKey.Prefix = apiKeyPrefix + hex.EncodeToString(prefix)
+	key := apiKey.Prefix + "_" + secret
+	apiKey.KeyHash = hashToken(key)
+
+	if err := db.Create(&apiKey).Error; err != nil {
+		return nil, err
+	}
+	recordAuthEvent(ctx, db, AUTH_EVENT_API_KEY_CREATED, account.Name, fmt.Sprintf("%s by %s", apiKey.Prefix, operator))
+	return &pb.CreateAPIKeyReply{Key: key, Info: apiKeyInfoToPbAPIKey(apiKey, account.Name)}, nil
+}
+
+func (s *server) ListAPIKeys(ctx context.Context, in *pb.ListAPIKeysRequest) (*pb.ListAPIKeysReply, error) {
+	if err := requireAdmin(db, operatorName(ctx, in.User)); err != nil {
+		return nil, err
+	}
+	account, err := findServiceAccount(db, in.Account)
+	if err != nil {
+		return nil, err
+	}
+	var keys []APIKeyInfo
+	if err := db.Where("user_id = ?", account.ID).Order("id").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	reply := &pb.ListAPIKeysReply{Keys: make([]*pb.ApiKey, len(keys))}
+	for i, k := range keys {
+		reply.Keys[i] = apiKeyInfoToPbAPIKey(k, account.Name)
+	}
+	return reply, nil
+}
+
+func (s *server) RevokeAPIKey(ctx context.Context, in *pb.RevokeAPIKeyRequest) (*pb.RevokeAPIKeyReply, error) {
+	operator := operatorName(ctx, in.User)
+	if err := requireAdmin(db, operator); err != nil {
+		return nil, err
+	}
+	var apiKey APIKeyInfo
+	if err := db.First(&apiKey, in.Id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("api key does not exist")
+		}
+		return nil, err
+	}
+	if apiKey.RevokedAt != nil {
+		return &pb.RevokeAPIKeyReply{}, nil
+	}
+	if err := db.Model(&apiKey).Update("revoked_at", time.Now()).Error; err != nil {
+		return nil, err
+	}
+	var account UserInfo
+	db.Select("name").First(&account, apiKey.UserID)
+	recordAuthEvent(ctx, db, AUTH_EVENT_API_KEY_REVOKED, account.Name, fmt.Sprintf("%s by %s", apiKey.Prefix, operator))
+	return &pb.RevokeAPIKeyReply{}, nil
+}
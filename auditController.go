@@ -0,0 +1,273 @@
+package main
+
+import (
+	"OrderManager/pb"
+	"context"
+	"encoding/json"
+	"fmt"
+	"google.golang.org/grpc"
+	"gorm.io/gorm"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	AUDIT_ENTITY_TASK  = "task"
+	AUDIT_ENTITY_PATCH = "patch"
+
+	AUDIT_ACTION_CREATE   = "create"
+	AUDIT_ACTION_UPDATE   = "update"
+	AUDIT_ACTION_DELETE   = "delete"
+	AUDIT_ACTION_RESTORE  = "restore"
+	AUDIT_ACTION_PURGE    = "purge"
+	AUDIT_ACTION_ESCALATE = "escalate"
+)
+
+const auditMaxLimit = 500
+
+type fieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// 按 gorm column 名取出结构体各字段的字符串值，带 audit:"-" 标签的字段不记录
+func auditValues(v interface{}) ([]string, map[string]string) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	rt := rv.Type()
+	var columns []string
+	values := make(map[string]string)
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if f.Tag.Get("audit") == "-" {
+			continue
+		}
+		gormTag := f.Tag.Get("gorm")
+		column := ""
+		for _, part := range strings.Split(gormTag, ";") {
+			if strings.HasPrefix(part, "column:") {
+				column = strings.TrimPrefix(part, "column:")
+			}
+		}
+		if column == "" {
+			continue
+		}
+		columns = append(columns, column)
+		values[column] = formatAuditValue(rv.Field(i).Interface(), strings.Contains(gormTag, "type:date;"))
+	}
+	return columns, values
+}
+
+func formatAuditValue(v interface{}, dateOnly bool) string {
+	switch t := v.(type) {
+	case time.Time:
+		if t.IsZero() {
+			return ""
+		}
+		if dateOnly {
+			return t.Format("2006-01-02")
+		}
+		return t.Format("2006-01-02 15:04:05")
+	case *time.Time:
+		if t == nil {
+			return ""
+		}
+		return formatAuditValue(*t, dateOnly)
+	case gorm.DeletedAt:
+		if !t.Valid {
+			return ""
+		}
+		return formatAuditValue(t.Time, dateOnly)
+	}
+	return fmt.Sprint(v)
+}
+
+// before 为 nil 表示新建，after 为 nil 表示删除
+func diffFields(before, after interface{}) []fieldChange {
+	var columns []string
+	var oldValues, newValues map[string]string
+	if before != nil {
+		columns, oldValues = auditValues(before)
+	}
+	if after != nil {
+		columns, newValues = auditValues(after)
+	}
+	var changes []fieldChange
+	for _, column := range columns {
+		if oldValues[column] != newValues[column] {
+			changes = append(changes, fieldChange{Field: column, Old: oldValues[column], New: newValues[column]})
+		}
+	}
+	return changes
+}
+
+func auditEntityID(entityType string, v interface{}) string {
+	switch e := v.(type) {
+	case *TaskInfo:
+		return e.TaskID
+	case *PatchsInfo:
+		return e.PatchNo
+	}
+	return ""
+}
+
+func isNilEntity(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// 在调用方的事务中写入审计记录，写入失败时整个事务回滚；没有变化时不写
+func recordAudit(ctx context.Context, tx *gorm.DB, user, entityType string, before, after interface{}) error {
+	if isNilEntity(before) {
+		before = nil
+	}
+	if isNilEntity(after) {
+		after = nil
+	}
+	action := AUDIT_ACTION_UPDATE
+	if before == nil {
+		action = AUDIT_ACTION_CREATE
+	} else if after == nil {
+		action = AUDIT_ACTION_DELETE
+	}
+	return recordAuditAction(ctx, tx, user, entityType, action, before, after)
+}
+
+// 与 recordAudit 相同，但由调用方指定操作类型（软删除、恢复等）
+func recordAuditAction(ctx context.Context, tx *gorm.DB, user, entityType, action string, before, after interface{}) error {
+	if isNilEntity(before) {
+		before = nil
+	}
+	if isNilEntity(after) {
+		after = nil
+	}
+	entity := after
+	if after == nil {
+		entity = before
+	}
+	changes := diffFields(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+	return writeAudit(ctx, tx, user, entityType, auditEntityID(entityType, entity), action, changes)
+}
+
+func writeAudit(ctx context.Context, tx *gorm.DB, user, entityType, entityID, action string, changes []fieldChange) error {
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	method, _ := grpc.Method(ctx)
+	entry := AuditLogInfo{
+		Actor:      operatorName(ctx, user),
+		Method:     method,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		Changes:    string(data),
+		PeerAddr:   peerAddr(ctx),
+	}
+	return tx.Create(&entry).Error
+}
+
+func auditLogInfoToPbAuditEntry(e AuditLogInfo) *pb.AuditEntry {
+	var changes []fieldChange
+	_ = json.Unmarshal([]byte(e.Changes), &changes)
+	entry := &pb.AuditEntry{
+		Id:         uint64(e.ID),
+		Time:       e.CreatedAt.Format("2006-01-02 15:04:05"),
+		Actor:      e.Actor,
+		Method:     e.Method,
+		EntityType: e.EntityType,
+		EntityId:   e.EntityID,
+		Action:     e.Action,
+		PeerAddr:   e.PeerAddr,
+		Changes:    make([]*pb.FieldChange, len(changes)),
+	}
+	for i, c := range changes {
+		entry.Changes[i] = &pb.FieldChange{Field: c.Field, OldValue: c.Old, NewValue: c.New}
+	}
+	return entry
+}
+
+func queryAuditEntries(query *gorm.DB, limit, offset int32) ([]*pb.AuditEntry, int64, error) {
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if limit <= 0 || limit > auditMaxLimit {
+		limit = auditMaxLimit
+	}
+	var entries []AuditLogInfo
+	if err := query.Order("id desc").Limit(int(limit)).Offset(int(offset)).Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+	result := make([]*pb.AuditEntry, len(entries))
+	for i, e := range entries {
+		result[i] = auditLogInfoToPbAuditEntry(e)
+	}
+	return result, total, nil
+}
+
+func (s *server) GetTaskHistory(ctx context.Context, in *pb.GetTaskHistoryRequest) (*pb.GetTaskHistoryReply, error) {
+	query := db.Model(&AuditLogInfo{}).Where("entity_type = ? AND entity_id = ?", AUDIT_ENTITY_TASK, in.TaskId)
+	entries, _, err := queryAuditEntries(query, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetTaskHistoryReply{Entries: entries}, nil
+}
+
+func (s *server) GetPatchHistory(ctx context.Context, in *pb.GetPatchHistoryRequest) (*pb.GetPatchHistoryReply, error) {
+	query := db.Model(&AuditLogInfo{}).Where("entity_type = ? AND entity_id = ?", AUDIT_ENTITY_PATCH, in.PatchNo)
+	entries, _, err := queryAuditEntries(query, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetPatchHistoryReply{Entries: entries}, nil
+}
+
+func (s *server) SearchAuditLog(ctx context.Context, in *pb.SearchAuditLogRequest) (*pb.SearchAuditLogReply, error) {
+	if err := requireAdmin(db, operatorName(ctx, in.User)); err != nil {
+		return nil, err
+	}
+	query := db.Model(&AuditLogInfo{})
+	if in.Actor != "" {
+		query = query.Where("actor = ?", in.Actor)
+	}
+	if in.EntityType != "" {
+		query = query.Where("entity_type = ?", in.EntityType)
+	}
+	if in.EntityId != "" {
+		query = query.Where("entity_id = ?", in.EntityId)
+	}
+	if in.Method != "" {
+		query = query.Where("method LIKE ?", "%"+in.Method)
+	}
+	if in.Field != "" {
+		query = query.Where("changes LIKE ?", `%"field":"`+in.Field+`"%`)
+	}
+	if in.Since != "" {
+		since, err := parseEventTime(in.Since)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("created_at >= ?", since)
+	}
+	if in.Until != "" {
+		until, err := parseEventTime(in.Until)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("created_at < ?", until)
+	}
+	entries, total, err := queryAuditEntries(query, in.Limit, in.Offset)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.SearchAuditLogReply{Entries: entries, Total: total}, nil
+}
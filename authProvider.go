@@ -0,0 +1,77 @@
+package main
+
+import (
+	"OrderManager/config"
+	"context"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AUTH_SOURCE_LOCAL = "local"
+	AUTH_SOURCE_LDAP  = "ldap"
+	// 服务账号，只能通过 API Key 调用
+	AUTH_SOURCE_SERVICE = "service"
+)
+
+// 认证失败（用户名或密码不对等），reason 只写入认证日志，不返回给客户端
+type authFailure struct {
+	reason string
+}
+
+func (e *authFailure) Error() string {
+	return e.reason
+}
+
+// 认证提供方，existing 为本地已有的用户（可能为 nil），成功时返回本地用户
+type authProvider interface {
+	authenticate(ctx context.Context, name, password string, existing *UserInfo) (*UserInfo, error)
+}
+
+var authProviders = map[string]authProvider{
+	AUTH_SOURCE_LOCAL:   localProvider{},
+	AUTH_SOURCE_LDAP:    ldapProvider{},
+	AUTH_SOURCE_SERVICE: serviceProvider{},
+}
+
+// 已有用户按其来源认证；新用户在启用目录认证时交给 LDAP 并自动创建
+func providerFor(existing *UserInfo) authProvider {
+	if existing != nil {
+		if p, ok := authProviders[existing.Source]; ok {
+			return p
+		}
+		return authProviders[AUTH_SOURCE_LOCAL]
+	}
+	if config.LDAP_URL != "" {
+		return authProviders[AUTH_SOURCE_LDAP]
+	}
+	return authProviders[AUTH_SOURCE_LOCAL]
+}
+
+// 用户不存在时也做一次 bcrypt 比较，使响应时间一致
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+type localProvider struct{}
+
+func (localProvider) authenticate(ctx context.Context, name, password string, existing *UserInfo) (*UserInfo, error) {
+	//算法标识符 + 成本因子 + 盐值
+	hash := dummyPasswordHash
+	if existing != nil {
+		hash = []byte(existing.Password)
+	}
+	err := bcrypt.CompareHashAndPassword(hash, []byte(password))
+	switch {
+	case existing == nil:
+		return nil, &authFailure{reason: "unknown user"}
+	case err != nil:
+		return nil, &authFailure{reason: "wrong password"}
+	case !existing.Active:
+		return nil, &authFailure{reason: "deactivated user"}
+	}
+	return existing, nil
+}
+
+type serviceProvider struct{}
+
+func (serviceProvider) authenticate(ctx context.Context, name, password string, existing *UserInfo) (*UserInfo, error) {
+	return nil, &authFailure{reason: "service account password login"}
+}
@@ -0,0 +1,496 @@
+package main
+
+import (
+	"OrderManager/pb"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"time"
+)
+
+// 备份文件格式，格式不兼容时加一
+const (
+	backupFormat        = "ordermanager-backup"
+	backupFormatVersion = 1
+)
+
+// 数据库结构版本，models 增加或修改列时加一；只能恢复不高于当前版本的备份
+const backupSchemaVersion = 1
+
+const backupManifestName = "manifest.json"
+
+// 恢复时上传的备份文件大小上限
+const maxRestoreSize = 512 << 20
+
+// 备份中所有文件解压后的总大小上限，防止压缩炸弹
+const maxBackupDataSize = 2 << 30
+
+const backupBatchSize = 500
+
+// 参与备份的表，按恢复顺序排列；登录会话和重置密码的验证码不备份
+var backupModels = []interface{}{
+	&UserInfo{}, &APIKeyInfo{}, &TaskTypeInfo{}, &TaskInfo{}, &PatchsInfo{}, &TaskDependencyInfo{},
+	&LabelInfo{}, &EntityLabelInfo{}, &CommentInfo{}, &WorkLogInfo{}, &AuditLogInfo{}, &AuthEventInfo{},
+	&ImportBatchInfo{}, &ImportBatchRowInfo{}, &CalendarTokenInfo{}, &ExternalIssueInfo{}, &TaskCommitInfo{},
+	&WorkScheduleInfo{}, &LeaveInfo{}, &BusinessDayInfo{}, &EscalationRuleInfo{}, &EscalationInfo{},
+}
+
+type backupTable struct {
+	Name   string `json:"name"`
+	File   string `json:"file"`
+	Rows   int64  `json:"rows"`
+	SHA256 string `json:"sha256"`
+}
+
+type backupManifest struct {
+	Format        string        `json:"format"`
+	FormatVersion int           `json:"formatVersion"`
+	SchemaVersion int           `json:"schemaVersion"`
+	CreatedAt     time.Time     `json:"createdAt"`
+	CreatedBy     string        `json:"createdBy"`
+	Tables        []backupTable `json:"tables"`
+}
+
+func modelSchema(model interface{}) (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+	return stmt.Schema, nil
+}
+
+// 以列名为键把一张表逐行写成 JSON lines，回收站中的记录一并备份
+func dumpTable(ctx context.Context, tx *gorm.DB, model interface{}, w io.Writer) (int64, error) {
+	sch, err := modelSchema(model)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := tx.Unscoped().Model(model).Order(primaryKeyOrder(sch)).Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	enc := json.NewEncoder(w)
+	var n int64
+	for rows.Next() {
+		v := reflect.New(sch.ModelType)
+		if err := tx.ScanRows(rows, v.Interface()); err != nil {
+			return n, err
+		}
+		record := make(map[string]interface{}, len(sch.Fields))
+		for _, f := range sch.Fields {
+			if f.DBName != "" {
+				record[f.DBName], _ = f.ValueOf(ctx, v.Elem())
+			}
+		}
+		if err := enc.Encode(record); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, rows.Err()
+}
+
+func primaryKeyOrder(sch *schema.Schema) clause.OrderBy {
+	var order clause.OrderBy
+	for _, f := range sch.PrimaryFields {
+		order.Columns = append(order.Columns, clause.OrderByColumn{Column: clause.Column{Name: f.DBName}})
+	}
+	return order
+}
+
+// 写出备份：每张表一个 .jsonl 文件，最后写入带有行数和 sha256 的 manifest.json
+func writeBackup(ctx context.Context, w io.Writer, createdBy string) (*backupManifest, error) {
+	manifest := &backupManifest{
+		Format:        backupFormat,
+		FormatVersion: backupFormatVersion,
+		SchemaVersion: backupSchemaVersion,
+		CreatedAt:     time.Now(),
+		CreatedBy:     createdBy,
+	}
+	zw := zip.NewWriter(w)
+	// 在同一个只读快照中导出所有表，保证各表之间一致
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range backupModels {
+			sch, err := modelSchema(model)
+			if err != nil {
+				return err
+			}
+			table := backupTable{Name: sch.Table, File: sch.Table + ".jsonl"}
+			f, err := zw.Create(table.File)
+			if err != nil {
+				return err
+			}
+			h := sha256.New()
+			bw := bufio.NewWriter(io.MultiWriter(f, h))
+			if table.Rows, err = dumpTable(ctx, tx, model, bw); err != nil {
+				return fmt.Errorf("backup %s: %v", sch.Table, err)
+			}
+			if err := bw.Flush(); err != nil {
+				return err
+			}
+			table.SHA256 = hex.EncodeToString(h.Sum(nil))
+			manifest.Tables = append(manifest.Tables, table)
+		}
+		return nil
+	}, &sql.TxOptions{ReadOnly: true, Isolation: sql.LevelRepeatableRead})
+	if err != nil {
+		return nil, err
+	}
+	f, err := zw.Create(backupManifestName)
+	if err != nil {
+		return nil, err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return nil, err
+	}
+	return manifest, zw.Close()
+}
+
+// 读取压缩包中的一个文件，解压后超过 limit 字节时报错
+func readZipFile(f *zip.File, limit int64) ([]byte, error) {
+	if f.UncompressedSize64 > uint64(limit) {
+		return nil, fmt.Errorf("%s is too large", f.Name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	// 头部记录的大小不可信，按实际解压的字节数再检查一次
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s is too large", f.Name)
+	}
+	return data, nil
+}
+
+// 校验备份文件：格式和结构版本、每张表的 sha256 以及行数，全部通过才返回
+func verifyBackup(data []byte) (*backupManifest, map[string][]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, errors.New("backup is not a valid archive")
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	mf, ok := files[backupManifestName]
+	if !ok {
+		return nil, nil, errors.New("backup has no manifest")
+	}
+	remaining := int64(maxBackupDataSize)
+	raw, err := readZipFile(mf, remaining)
+	if err != nil {
+		return nil, nil, err
+	}
+	remaining -= int64(len(raw))
+	var manifest backupManifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("invalid manifest: %v", err)
+	}
+	if manifest.Format != backupFormat || manifest.FormatVersion != backupFormatVersion {
+		return nil, nil, fmt.Errorf("unsupported backup format %s v%d", manifest.Format, manifest.FormatVersion)
+	}
+	if manifest.SchemaVersion < 1 {
+		return nil, nil, fmt.Errorf("invalid schema version %d", manifest.SchemaVersion)
+	}
+	if manifest.SchemaVersion > backupSchemaVersion {
+		return nil, nil, fmt.Errorf("backup schema version %d is newer than %d, upgrade the server first", manifest.SchemaVersion, backupSchemaVersion)
+	}
+
+	known := make(map[string]bool, len(backupModels))
+	var expected []string
+	for _, model := range backupModels {
+		sch, err := modelSchema(model)
+		if err != nil {
+			return nil, nil, err
+		}
+		known[sch.Table] = true
+		expected = append(expected, sch.Table)
+	}
+	contents := make(map[string][]byte, len(manifest.Tables))
+	for _, t := range manifest.Tables {
+		if !known[t.Name] {
+			return nil, nil, fmt.Errorf("unknown table %s in backup", t.Name)
+		}
+		if _, dup := contents[t.Name]; dup {
+			return nil, nil, fmt.Errorf("duplicate table %s in backup", t.Name)
+		}
+		f, ok := files[t.File]
+		if !ok {
+			return nil, nil, fmt.Errorf("backup is missing %s", t.File)
+		}
+		content, err := readZipFile(f, remaining)
+		if err != nil {
+			return nil, nil, err
+		}
+		remaining -= int64(len(content))
+		sum := sha256.Sum256(content)
+		if hex.EncodeToString(sum[:]) != t.SHA256 {
+			return nil, nil, fmt.Errorf("checksum mismatch for %s", t.File)
+		}
+		if rows := int64(bytes.Count(content, []byte("\n"))); rows != t.Rows {
+			return nil, nil, fmt.Errorf("%s has %d rows, manifest says %d", t.File, rows, t.Rows)
+		}
+		contents[t.Name] = content
+	}
+	// 恢复会先清空用户表，缺表的备份不能通过校验
+	for _, table := range expected {
+		if _, ok := contents[table]; !ok {
+			return nil, nil, fmt.Errorf("backup is missing table %s", table)
+		}
+	}
+	return &manifest, contents, nil
+}
+
+// 按模型的列类型解码一行，备份中没有的列取数据库默认值，未知的列视为错误
+func decodeBackupRow(sch *schema.Schema, line []byte) (map[string]interface{}, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return nil, err
+	}
+	record := make(map[string]interface{}, len(raw))
+	for column, value := range raw {
+		f := sch.LookUpField(column)
+		if f == nil || f.DBName != column {
+			return nil, fmt.Errorf("unknown column %s", column)
+		}
+		v := reflect.New(f.FieldType)
+		if err := json.Unmarshal(value, v.Interface()); err != nil {
+			return nil, fmt.Errorf("column %s: %v", column, err)
+		}
+		record[column] = v.Elem().Interface()
+	}
+	return record, nil
+}
+
+// 恢复前除用户表以外的业务表必须为空；用户表中已有的账号（如执行恢复的管理员）会被备份中的账号替换
+func checkRestoreTarget(tx *gorm.DB) error {
+	for _, model := range backupModels {
+		if _, isUser := model.(*UserInfo); isUser {
+			continue
+		}
+		var n int64
+		if err := tx.Unscoped().Model(model).Count(&n).Error; err != nil {
+			return err
+		}
+		if n > 0 {
+			sch, err := modelSchema(model)
+			if err != nil {
+				return err
+			}
+			return status.Errorf(codes.FailedPrecondition, "database is not empty: %s has %d rows", sch.Table, n)
+		}
+	}
+	return nil
+}
+
+// 先完整校验备份，再在一个事务中按 backupModels 的顺序写入；verifyOnly 时只校验
+func restoreBackup(data []byte, verifyOnly bool) (*backupManifest, error) {
+	manifest, contents, err := verifyBackup(data)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if verifyOnly {
+		return manifest, nil
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := checkRestoreTarget(tx); err != nil {
+			return err
+		}
+		// 用户 ID 会被备份中的账号重新占用，已有的会话和验证码必须一并清除，否则会以其他账号的身份生效
+		all := tx.Session(&gorm.Session{AllowGlobalUpdate: true})
+		for _, model := range []interface{}{&SessionInfo{}, &PasswordResetInfo{}} {
+			if err := all.Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := all.Unscoped().Delete(&UserInfo{}).Error; err != nil {
+			return err
+		}
+		for _, model := range backupModels {
+			sch, err := modelSchema(model)
+			if err != nil {
+				return err
+			}
+			// 比备份更新的表保持为空
+			content, ok := contents[sch.Table]
+			if !ok {
+				continue
+			}
+			var batch []map[string]interface{}
+			flush := func() error {
+				if len(batch) == 0 {
+					return nil
+				}
+				err := tx.Table(sch.Table).Create(&batch).Error
+				batch = batch[:0]
+				return err
+			}
+			scanner := bufio.NewScanner(bytes.NewReader(content))
+			scanner.Buffer(make([]byte, 0, 64<<10), maxRestoreSize)
+			for line := 1; scanner.Scan(); line++ {
+				record, err := decodeBackupRow(sch, scanner.Bytes())
+				if err != nil {
+					return fmt.Errorf("%s line %d: %v", sch.Table, line, err)
+				}
+				batch = append(batch, record)
+				if len(batch) == backupBatchSize {
+					if err := flush(); err != nil {
+						return fmt.Errorf("restore %s: %v", sch.Table, err)
+					}
+				}
+			}
+			if err := scanner.Err(); err != nil {
+				return err
+			}
+			if err := flush(); err != nil {
+				return fmt.Errorf("restore %s: %v", sch.Table, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func backupManifestToPbReply(m *backupManifest, restored bool) *pb.RestoreReply {
+	reply := &pb.RestoreReply{
+		SchemaVersion: int32(m.SchemaVersion),
+		CreatedAt:     m.CreatedAt.Format("2006-01-02 15:04:05"),
+		CreatedBy:     m.CreatedBy,
+		Restored:      restored,
+	}
+	for _, t := range m.Tables {
+		reply.Tables = append(reply.Tables, &pb.BackupTable{Name: t.Name, Rows: t.Rows, Sha256: t.SHA256})
+	}
+	return reply
+}
+
+func (s *server) Backup(in *pb.BackupRequest, stream pb.Service_BackupServer) error {
+	operator := operatorName(stream.Context(), in.User)
+	if err := requireAdmin(db, operator); err != nil {
+		return err
+	}
+	sender := &chunkSender{
+		send:        stream.Send,
+		fileName:    fmt.Sprintf("ordermanager-%s.zip", time.Now().Format("20060102-150405")),
+		contentType: "application/zip",
+	}
+	manifest, err := writeBackup(stream.Context(), sender, operator)
+	if err != nil {
+		return err
+	}
+	log.Printf("backup by %s: %d tables", operator, len(manifest.Tables))
+	return sender.flush()
+}
+
+func (s *server) Restore(stream pb.Service_RestoreServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	operator := operatorName(stream.Context(), first.User)
+	if err := requireAdmin(db, operator); err != nil {
+		return err
+	}
+	data := first.Data
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if len(data)+len(chunk.Data) > maxRestoreSize {
+			return status.Errorf(codes.InvalidArgument, "backup exceeds %d bytes", maxRestoreSize)
+		}
+		data = append(data, chunk.Data...)
+	}
+	manifest, err := restoreBackup(data, first.VerifyOnly)
+	if err != nil {
+		return err
+	}
+	if !first.VerifyOnly {
+		log.Printf("restore by %s: backup created at %s by %s", operator, manifest.CreatedAt.Format(time.RFC3339), manifest.CreatedBy)
+	}
+	return stream.SendAndClose(backupManifestToPbReply(manifest, !first.VerifyOnly))
+}
+
+// 命令行：OrderManager backup <文件> 或 OrderManager restore [-verify] <文件>
+func runBackupCommand(args []string) error {
+	switch args[0] {
+	case "backup":
+		fs := flag.NewFlagSet("backup", flag.ExitOnError)
+		fs.Parse(args[1:])
+		if fs.NArg() != 1 {
+			return errors.New("usage: backup <file>")
+		}
+		f, err := os.Create(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		manifest, err := writeBackup(context.Background(), f, "cli")
+		if err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		for _, t := range manifest.Tables {
+			fmt.Printf("%-24s %8d rows\n", t.Name, t.Rows)
+		}
+		return nil
+	case "restore":
+		fs := flag.NewFlagSet("restore", flag.ExitOnError)
+		verifyOnly := fs.Bool("verify", false, "only verify the archive")
+		fs.Parse(args[1:])
+		if fs.NArg() != 1 {
+			return errors.New("usage: restore [-verify] <file>")
+		}
+		data, err := os.ReadFile(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		manifest, err := restoreBackup(data, *verifyOnly)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("backup created at %s by %s, schema version %d\n", manifest.CreatedAt.Format(time.RFC3339), manifest.CreatedBy, manifest.SchemaVersion)
+		for _, t := range manifest.Tables {
+			fmt.Printf("%-24s %8d rows\n", t.Name, t.Rows)
+		}
+		if *verifyOnly {
+			fmt.Println("archive verified, nothing restored")
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown command: %s", args[0])
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"OrderManager/pb"
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	BUSINESS_DAY_HOLIDAY = "holiday"
+	BUSINESS_DAY_WORKDAY = "workday"
+)
+
+// 工作日历缓存的有效期，多个服务实例时其他实例的修改最迟在此时间后生效
+const businessCalendarTTL = 10 * time.Minute
+
+// 一次导入或设置的日期数上限
+const maxBusinessDays = 3660
+
+// 周六、周日休息，节假日和调休按 business_day_table 调整
+type businessCalendar struct {
+	overrides map[string]bool // 日期 -> 是否上班
+}
+
+var businessCalendarCache struct {
+	sync.Mutex
+	cal      *businessCalendar
+	loadedAt time.Time
+}
+
+// 当前的工作日历，读取失败时只按周末计算
+func currentBusinessCalendar() *businessCalendar {
+	businessCalendarCache.Lock()
+	defer businessCalendarCache.Unlock()
+	if businessCalendarCache.cal != nil && time.Since(businessCalendarCache.loadedAt) < businessCalendarTTL {
+		return businessCalendarCache.cal
+	}
+	cal := &businessCalendar{overrides: make(map[string]bool)}
+	var days []BusinessDayInfo
+	if err := db.Find(&days).Error; err != nil {
+		log.Println("读取工作日历失败：", err)
+		return cal
+	}
+	for _, d := range days {
+		cal.overrides[d.Date.Format("2006-01-02")] = d.Workday
+	}
+	businessCalendarCache.cal, businessCalendarCache.loadedAt = cal, time.Now()
+	return cal
+}
+
+func invalidateBusinessCalendar() {
+	businessCalendarCache.Lock()
+	businessCalendarCache.cal = nil
+	businessCalendarCache.Unlock()
+}
+
+// 节假日或调休的设置，没有设置时 ok 为 false
+func (c *businessCalendar) override(day time.Time) (workday, ok bool) {
+	workday, ok = c.overrides[day.Format("2006-01-02")]
+	return
+}
+
+func (c *businessCalendar) isWorkday(day time.Time) bool {
+	if workday, ok := c.override(day); ok {
+		return workday
+	}
+	return day.Weekday() != time.Saturday && day.Weekday() != time.Sunday
+}
+
+// from 之后的第 n 个工作日（不含 from 当天）
+func (c *businessCalendar) addWorkdays(from time.Time, n int) time.Time {
+	day := from
+	for n > 0 {
+		day = day.AddDate(0, 0, 1)
+		if c.isWorkday(day) {
+			n--
+		}
+	}
+	return day
+}
+
+// 展示剩余工作日数时的上限，超过时显示为该值
+const maxDisplayWorkdays = 999
+
+// (from, to] 之间的工作日数，to 早于 from 时为负数，表示已超过的工作日数；
+// 数到 limit 个工作日即停止，结果在 [-limit, limit] 之间，避免截止日期很远（如 9999-12-31）时逐日遍历
+func (c *businessCalendar) workdaysBetweenWithin(from, to time.Time, limit int) int {
+	sign := 1
+	if to.Before(from) {
+		from, to, sign = to, from, -1
+	}
+	n := 0
+	for day := from.AddDate(0, 0, 1); n < limit && !day.After(to); day = day.AddDate(0, 0, 1) {
+		if c.isWorkday(day) {
+			n++
+		}
+	}
+	return sign * n
+}
+
+func businessDayInfoToPbBusinessDay(d *BusinessDayInfo) *pb.BusinessDay {
+	t := BUSINESS_DAY_HOLIDAY
+	if d.Workday {
+		t = BUSINESS_DAY_WORKDAY
+	}
+	return &pb.BusinessDay{Date: d.Date.Format("2006-01-02"), Type: t, Name: d.Name}
+}
+
+func parseBusinessDayType(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", BUSINESS_DAY_HOLIDAY, "休", "假":
+		return false, nil
+	case BUSINESS_DAY_WORKDAY, "班":
+		return true, nil
+	}
+	return false, fmt.Errorf("invalid type %q", s)
+}
+
+// 2026-10-01 或 2026-10-01~2026-10-07
+func parseDateRange(s string) (time.Time, time.Time, error) {
+	first, last, isRange := strings.Cut(strings.TrimSpace(s), "~")
+	start, err := time.Parse("2006-01-02", strings.TrimSpace(first))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid date %q", s)
+	}
+	end := start
+	if isRange {
+		if end, err = time.Parse("2006-01-02", strings.TrimSpace(last)); err != nil || end.Before(start) {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid date range %q", s)
+		}
+	}
+	return start, end, nil
+}
+
+func expandBusinessDays(start, end time.Time, workday bool, name, operator string, res []BusinessDayInfo) ([]BusinessDayInfo, error) {
+	if _, err := maxLen("name", name, 50); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if len(res) >= maxBusinessDays {
+			return nil, fmt.Errorf("at most %d days at a time", maxBusinessDays)
+		}
+		res = append(res, BusinessDayInfo{Date: day, Workday: workday, Name: name, UpdatedBy: operator, UpdatedAt: now})
+	}
+	return res, nil
+}
+
+func saveBusinessDays(tx *gorm.DB, days []BusinessDayInfo) error {
+	if len(days) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{UpdateAll: true}).CreateInBatches(days, 500).Error
+}
+
+func (s *server) SetBusinessDays(ctx context.Context, in *pb.SetBusinessDaysRequest) (*pb.SetBusinessDaysReply, error) {
+	operator := operatorName(ctx, in.User)
+	var days []BusinessDayInfo
+	for _, d := range in.Days {
+		date, err := time.Parse("2006-01-02", d.Date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q", d.Date)
+		}
+		workday, err := parseBusinessDayType(d.Type)
+		if err != nil {
+			return nil, err
+		}
+		if days, err = expandBusinessDays(date, date, workday, d.Name, operator, days); err != nil {
+			return nil, err
+		}
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := requireAdmin(tx, operator); err != nil {
+			return err
+		}
+		return saveBusinessDays(tx, days)
+	})
+	if err != nil {
+		return nil, err
+	}
+	invalidateBusinessCalendar()
+	return &pb.SetBusinessDaysReply{}, nil
+}
+
+func (s *server) DeleteBusinessDays(ctx context.Context, in *pb.DeleteBusinessDaysRequest) (*pb.DeleteBusinessDaysReply, error) {
+	for _, d := range in.Dates {
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return nil, fmt.Errorf("invalid date %q", d)
+		}
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := requireAdmin(tx, operatorName(ctx, in.User)); err != nil {
+			return err
+		}
+		if len(in.Dates) == 0 {
+			return nil
+		}
+		return tx.Where("date IN ?", in.Dates).Delete(&BusinessDayInfo{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	invalidateBusinessCalendar()
+	return &pb.DeleteBusinessDaysReply{}, nil
+}
+
+func (s *server) ListBusinessDays(ctx context.Context, in *pb.ListBusinessDaysRequest) (*pb.ListBusinessDaysReply, error) {
+	query := db.Model(&BusinessDayInfo{})
+	if in.Since != "" {
+		if _, err := time.Parse("2006-01-02", in.Since); err != nil {
+			return nil, fmt.Errorf("invalid since %q", in.Since)
+		}
+		query = query.Where("date >= ?", in.Since)
+	}
+	if in.Until != "" {
+		if _, err := time.Parse("2006-01-02", in.Until); err != nil {
+			return nil, fmt.Errorf("invalid until %q", in.Until)
+		}
+		query = query.Where("date <= ?", in.Until)
+	}
+	var days []BusinessDayInfo
+	if err := query.Order("date").Find(&days).Error; err != nil {
+		return nil, err
+	}
+	reply := &pb.ListBusinessDaysReply{Days: make([]*pb.BusinessDay, len(days))}
+	for i := range days {
+		reply.Days[i] = businessDayInfoToPbBusinessDay(&days[i])
+	}
+	return reply, nil
+}
+
+// 解析节假日文件，每行为 日期,类型,名称，也可以用制表符分隔
+func parseBusinessCalendarFile(data []byte, operator string) ([]BusinessDayInfo, error) {
+	var days []BusinessDayInfo
+	scanner := bufio.NewScanner(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		// 按第一个出现的分隔符切分，保留空列，如 2026-10-01,,国庆 的类型为空（默认休息）
+		sep := ","
+		if i := strings.IndexAny(text, ",\t"); i >= 0 && text[i] == '\t' {
+			sep = "\t"
+		}
+		fields := strings.Split(text, sep)
+		for len(fields) < 3 {
+			fields = append(fields, "")
+		}
+		// 表头
+		if d := strings.ToLower(strings.TrimSpace(fields[0])); line == 1 && (d == "date" || d == "日期") {
+			continue
+		}
+		start, end, err := parseDateRange(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		workday, err := parseBusinessDayType(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if days, err = expandBusinessDays(start, end, workday, strings.TrimSpace(fields[2]), operator, days); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return days, nil
+}
+
+func (s *server) ImportBusinessCalendar(ctx context.Context, in *pb.ImportBusinessCalendarRequest) (*pb.ImportBusinessCalendarReply, error) {
+	if len(in.Data) > maxImportFileSize {
+		return nil, fmt.Errorf("file exceeds %d bytes", maxImportFileSize)
+	}
+	operator := operatorName(ctx, in.User)
+	days, err := parseBusinessCalendarFile(in.Data, operator)
+	if err != nil {
+		return nil, err
+	}
+	if len(days) == 0 {
+		return nil, errors.New("no dates in file")
+	}
+	yearSet := make(map[int]bool)
+	for _, d := range days {
+		yearSet[d.Date.Year()] = true
+	}
+	reply := &pb.ImportBusinessCalendarReply{Imported: int32(len(days))}
+	for y := range yearSet {
+		reply.Years = append(reply.Years, int32(y))
+	}
+	sort.Slice(reply.Years, func(i, j int) bool { return reply.Years[i] < reply.Years[j] })
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := requireAdmin(tx, operator); err != nil {
+			return err
+		}
+		if in.Replace {
+			for _, y := range reply.Years {
+				if err := tx.Where("date BETWEEN ? AND ?", fmt.Sprintf("%d-01-01", y), fmt.Sprintf("%d-12-31", y)).
+					Delete(&BusinessDayInfo{}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return saveBusinessDays(tx, days)
+	})
+	if err != nil {
+		return nil, err
+	}
+	invalidateBusinessCalendar()
+	return reply, nil
+}
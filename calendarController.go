@@ -0,0 +1,331 @@
+package main
+
+import (
+	"OrderManager/config"
+	"OrderManager/pb"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	CALENDAR_FEED_USER   = "user"
+	CALENDAR_FEED_GROUP  = "group"
+	CALENDAR_FEED_CLIENT = "client"
+)
+
+// 事件 UID 的域名部分，UID 不变日历应用才会更新而不是新增事件
+const calendarUIDDomain = "ordermanager"
+
+// RFC 5545 要求每行不超过 75 个字节
+const icsLineLimit = 75
+
+func calendarURL(kind, name, token string) string {
+	return fmt.Sprintf("%s/calendar/%s/%s.ics?token=%s", config.CALENDAR_BASE_URL, kind, url.PathEscape(name), token)
+}
+
+func (s *server) ResetCalendarToken(ctx context.Context, in *pb.ResetCalendarTokenRequest) (*pb.ResetCalendarTokenReply, error) {
+	user, err := findUserByName(db, operatorName(ctx, in.User))
+	if err != nil {
+		return nil, err
+	}
+	if !user.Active {
+		return nil, errPermissionDenied
+	}
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	err = db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&CalendarTokenInfo{UserID: user.ID, TokenHash: hashToken(token), CreatedAt: time.Now()}).Error
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ResetCalendarTokenReply{
+		Token:     token,
+		UserUrl:   calendarURL(CALENDAR_FEED_USER, user.Name, token),
+		GroupUrl:  calendarURL(CALENDAR_FEED_GROUP, strconv.Itoa(user.Group), token),
+		ClientUrl: fmt.Sprintf("%s/calendar/%s/{client}.ics?token=%s", config.CALENDAR_BASE_URL, CALENDAR_FEED_CLIENT, token),
+	}, nil
+}
+
+func (s *server) RevokeCalendarToken(ctx context.Context, in *pb.RevokeCalendarTokenRequest) (*pb.RevokeCalendarTokenReply, error) {
+	user, err := findUserByName(db, operatorName(ctx, in.User))
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Where("user_id = ?", user.ID).Delete(&CalendarTokenInfo{}).Error; err != nil {
+		return nil, err
+	}
+	return &pb.RevokeCalendarTokenReply{}, nil
+}
+
+// 密钥对应的启用中的用户，无效时返回 nil
+func calendarUser(token string) (*UserInfo, error) {
+	if token == "" {
+		return nil, nil
+	}
+	var ct CalendarTokenInfo
+	if err := db.Where("token_hash = ?", hashToken(token)).First(&ct).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var user UserInfo
+	if err := db.First(&user, ct.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !user.Active {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+// 与需求号有交集的补丁
+func patchsOfReqNos(tx *gorm.DB, reqNos map[string]bool) ([]PatchsInfo, error) {
+	var patchs []PatchsInfo
+	if err := tx.Order("patch_no").Find(&patchs).Error; err != nil {
+		return nil, err
+	}
+	var res []PatchsInfo
+	for _, p := range patchs {
+		for _, reqNo := range strings.Split(p.ReqNo, ",") {
+			if reqNos[reqNo] {
+				res = append(res, p)
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+// user 只能订阅本人及本组（组长）的日历，group 只能订阅本组的，client 涉及所有人的修改单，仅限组长和管理员
+func calendarAllowed(user *UserInfo, kind, name string) (bool, error) {
+	if user.RoleNo == ROLE_ADMIN {
+		return true, nil
+	}
+	switch kind {
+	case CALENDAR_FEED_USER:
+		if name == user.Name {
+			return true, nil
+		}
+		if user.RoleNo != ROLE_LEADER {
+			return false, nil
+		}
+		var target UserInfo
+		if err := db.Where("name = ?", name).First(&target).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return false, nil
+			}
+			return false, err
+		}
+		return target.Group == user.Group, nil
+	case CALENDAR_FEED_GROUP:
+		return name == strconv.Itoa(user.Group), nil
+	case CALENDAR_FEED_CLIENT:
+		return user.RoleNo == ROLE_LEADER, nil
+	}
+	return false, nil
+}
+
+// 订阅内容：user 为本人的修改单，group 为本组所有人的修改单，均附带相关补丁；client 为客户的补丁及其修改单
+// 返回 nil 表示订阅对象不存在
+func calendarEntries(kind, name string) ([]TaskInfo, []PatchsInfo, error) {
+	var tasks []TaskInfo
+	var patchs []PatchsInfo
+	switch kind {
+	case CALENDAR_FEED_USER, CALENDAR_FEED_GROUP:
+		var principals []string
+		if kind == CALENDAR_FEED_USER {
+			if _, err := findUserByName(db, name); err != nil {
+				return nil, nil, nil
+			}
+			principals = []string{name}
+		} else {
+			group, err := strconv.Atoi(name)
+			if err != nil {
+				return nil, nil, nil
+			}
+			if err := db.Model(&UserInfo{}).Where("`group` = ?", group).Pluck("name", &principals).Error; err != nil {
+				return nil, nil, err
+			}
+			if len(principals) == 0 {
+				return nil, nil, nil
+			}
+		}
+		if err := db.Where("principal IN ?", principals).Order("deadline, task_id").Find(&tasks).Error; err != nil {
+			return nil, nil, err
+		}
+		reqNos := make(map[string]bool)
+		for _, t := range tasks {
+			reqNos[t.ReqNo] = true
+		}
+		var err error
+		if patchs, err = patchsOfReqNos(db, reqNos); err != nil {
+			return nil, nil, err
+		}
+	case CALENDAR_FEED_CLIENT:
+		if err := db.Where("client_name = ?", name).Order("patch_no").Find(&patchs).Error; err != nil {
+			return nil, nil, err
+		}
+		if len(patchs) == 0 {
+			return nil, nil, nil
+		}
+		var reqNos []string
+		for _, p := range patchs {
+			reqNos = append(reqNos, strings.Split(p.ReqNo, ",")...)
+		}
+		if err := db.Where("req_no IN ?", reqNos).Order("deadline, task_id").Find(&tasks).Error; err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, nil
+	}
+	if tasks == nil {
+		tasks = []TaskInfo{}
+	}
+	return tasks, patchs, nil
+}
+
+// 按 RFC 5545 输出内容行：CRLF 换行，超过 75 字节时折行（不拆开 UTF-8 字符）
+type icsWriter struct {
+	b strings.Builder
+}
+
+func (w *icsWriter) line(name, value string) {
+	s := name + ":" + value
+	limit := icsLineLimit
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.b.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+		// 续行开头的空格占一个字节
+		limit = icsLineLimit - 1
+	}
+	w.b.WriteString(s + "\r\n")
+}
+
+var icsTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func icsDate(t time.Time) string {
+	return t.Format("20060102")
+}
+
+func icsTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// 全天事件，SEQUENCE 取版本号，截止日期被修改后日历应用据此更新事件
+func (w *icsWriter) event(uid string, day time.Time, version int64, updatedAt, now time.Time, summary, description string) {
+	w.line("BEGIN", "VEVENT")
+	w.line("UID", uid+"@"+calendarUIDDomain)
+	w.line("DTSTAMP", icsTime(now))
+	if !updatedAt.IsZero() {
+		w.line("LAST-MODIFIED", icsTime(updatedAt))
+	}
+	w.line("SEQUENCE", strconv.FormatInt(version, 10))
+	w.line("DTSTART;VALUE=DATE", icsDate(day))
+	w.line("DTEND;VALUE=DATE", icsDate(day.AddDate(0, 0, 1)))
+	w.line("SUMMARY", icsTextEscaper.Replace(summary))
+	w.line("DESCRIPTION", icsTextEscaper.Replace(description))
+	w.line("TRANSP", "TRANSPARENT")
+	w.line("END", "VEVENT")
+}
+
+func renderCalendar(title string, tasks []TaskInfo, patchs []PatchsInfo) string {
+	now := time.Now()
+	w := &icsWriter{}
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", "-//OrderManager//TodoList//ZH")
+	w.line("CALSCALE", "GREGORIAN")
+	w.line("METHOD", "PUBLISH")
+	w.line("X-WR-CALNAME", icsTextEscaper.Replace(title))
+	for _, t := range tasks {
+		if t.Deadline.IsZero() {
+			continue
+		}
+		w.event("task-"+t.TaskID, t.Deadline, t.Version, t.UpdatedAt, now,
+			fmt.Sprintf("[修改单截止] %s %s", t.TaskID, t.Comment),
+			fmt.Sprintf("负责人：%s\n需求号：%s\n状态：%s", t.Principal, t.ReqNo, t.State))
+	}
+	for _, p := range patchs {
+		w.event("patch-"+p.PatchNo, p.Deadline, p.Version, p.UpdatedAt, now,
+			fmt.Sprintf("[补丁发布] %s %s", p.PatchNo, p.ClientName),
+			fmt.Sprintf("需求号：%s\n发起人：%s\n状态：%s\n补丁原因：%s", p.ReqNo, p.Sponsor, p.State, p.Reason))
+	}
+	w.line("END", "VCALENDAR")
+	return w.b.String()
+}
+
+// GET /calendar/{user|group|client}/{名称}.ics?token=密钥
+func calendarHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user, err := calendarUser(r.URL.Query().Get("token"))
+	if err != nil {
+		log.Println("calendar:", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if user == nil {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+	kind, file, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/calendar/"), "/")
+	name, isICS := strings.CutSuffix(file, ".ics")
+	if !ok || !isICS || name == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if allowed, err := calendarAllowed(user, kind, name); err != nil {
+		log.Println("calendar:", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	} else if !allowed {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	tasks, patchs, err := calendarEntries(kind, name)
+	if err != nil {
+		log.Println("calendar:", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if tasks == nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write([]byte(renderCalendar(fmt.Sprintf("OrderManager %s %s", kind, name), tasks, patchs)))
+}
+
+func calendarServe() {
+	if config.CALENDAR_HTTP_ADDR == "" {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/calendar/", calendarHandler)
+	log.Println("日历订阅服务监听：", config.CALENDAR_HTTP_ADDR)
+	if err := http.ListenAndServe(config.CALENDAR_HTTP_ADDR, mux); err != nil {
+		log.Println("日历订阅服务退出：", err)
+	}
+}
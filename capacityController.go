@@ -0,0 +1,477 @@
+package main
+
+import (
+	"OrderManager/pb"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TASK_RISK_OVERDUE      = "overdue"
+	TASK_RISK_INSUFFICIENT = "insufficient"
+)
+
+// 没有设置工作时间时周一至周五每天 8 小时
+var defaultWeekdayHours = [7]float64{8, 8, 8, 8, 8, 0, 0}
+
+// 未指定结束日期时计算的天数，以及一次最多计算的天数
+const (
+	defaultCapacityDays = 14
+	maxCapacityDays     = 366
+)
+
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+func roundHours(h float64) float64 {
+	return math.Round(h*100) / 100
+}
+
+func parseWeekdayHours(s string) [7]float64 {
+	hours := defaultWeekdayHours
+	for i, v := range strings.SplitN(s, ",", 7) {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			hours[i] = f
+		}
+	}
+	return hours
+}
+
+func formatWeekdayHours(hours [7]float64) string {
+	parts := make([]string, len(hours))
+	for i, h := range hours {
+		parts[i] = strconv.FormatFloat(h, 'f', -1, 64)
+	}
+	return strings.Join(parts, ",")
+}
+
+// 本人、同组的组长或管理员可以维护工作时间和请假
+func requireSelfOrLeader(tx *gorm.DB, operator string, target *UserInfo) error {
+	if operator == target.Name {
+		return nil
+	}
+	op, err := findUserByName(tx, operator)
+	if err != nil || !op.Active {
+		return errPermissionDenied
+	}
+	if op.RoleNo == ROLE_ADMIN || (op.RoleNo == ROLE_LEADER && op.Group == target.Group) {
+		return nil
+	}
+	return errPermissionDenied
+}
+
+func leaveInfoToPbLeave(l *LeaveInfo) *pb.Leave {
+	return &pb.Leave{
+		Id:        uint64(l.ID),
+		Name:      l.Name,
+		StartDate: l.StartDate.Format("2006-01-02"),
+		EndDate:   l.EndDate.Format("2006-01-02"),
+		Hours:     l.Hours,
+		Reason:    l.Reason,
+		CreatedBy: l.CreatedBy,
+		CreatedAt: l.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+func (s *server) SetWorkSchedule(ctx context.Context, in *pb.SetWorkScheduleRequest) (*pb.SetWorkScheduleReply, error) {
+	if len(in.WeekdayHours) != 7 {
+		return nil, errors.New("weekdayHours must have 7 items from Monday to Sunday")
+	}
+	var hours [7]float64
+	for i, h := range in.WeekdayHours {
+		if h < 0 || h > maxWorkHoursPerDay {
+			return nil, fmt.Errorf("hours must be between 0 and %d", maxWorkHoursPerDay)
+		}
+		hours[i] = h
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		target, err := findUserByName(tx, in.Name)
+		if err != nil {
+			return err
+		}
+		if err := requireSelfOrLeader(tx, operatorName(ctx, in.User), target); err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).
+			Create(&WorkScheduleInfo{UserID: target.ID, WeekdayHours: formatWeekdayHours(hours), UpdatedAt: time.Now()}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.SetWorkScheduleReply{WeekdayHours: hours[:]}, nil
+}
+
+func (s *server) AddLeave(ctx context.Context, in *pb.AddLeaveRequest) (*pb.AddLeaveReply, error) {
+	start, err := time.ParseInLocation("2006-01-02", in.StartDate, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("invalid startDate %q", in.StartDate)
+	}
+	end := start
+	if in.EndDate != "" {
+		if end, err = time.ParseInLocation("2006-01-02", in.EndDate, time.Local); err != nil {
+			return nil, fmt.Errorf("invalid endDate %q", in.EndDate)
+		}
+	}
+	if end.Before(start) {
+		return nil, errors.New("endDate must not be before startDate")
+	}
+	if in.Hours < 0 || in.Hours > maxWorkHoursPerDay {
+		return nil, fmt.Errorf("hours must be between 0 and %d", maxWorkHoursPerDay)
+	}
+	if _, err := maxLen("reason", in.Reason, 100); err != nil {
+		return nil, err
+	}
+	operator := operatorName(ctx, in.User)
+	leave := &LeaveInfo{Name: in.Name, StartDate: start, EndDate: end, Hours: in.Hours, Reason: in.Reason, CreatedBy: operator}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		target, err := findUserByName(tx, in.Name)
+		if err != nil {
+			return err
+		}
+		if err := requireSelfOrLeader(tx, operator, target); err != nil {
+			return err
+		}
+		return tx.Create(leave).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	msg := fmt.Sprintf("<%s> -> add leave: %s ~ %s -> <%s>", operator, leave.StartDate.Format("2006-01-02"), leave.EndDate.Format("2006-01-02"), leave.Name)
+	NotificationServer.updateDatabaseAndNotify(msg)
+	return &pb.AddLeaveReply{Leave: leaveInfoToPbLeave(leave)}, nil
+}
+
+func (s *server) DeleteLeave(ctx context.Context, in *pb.DeleteLeaveRequest) (*pb.DeleteLeaveReply, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var leave LeaveInfo
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&leave, in.Id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("leave does not exist")
+			}
+			return err
+		}
+		target, err := findUserByName(tx, leave.Name)
+		if err != nil {
+			return err
+		}
+		if err := requireSelfOrLeader(tx, operatorName(ctx, in.User), target); err != nil {
+			return err
+		}
+		return tx.Delete(&leave).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DeleteLeaveReply{}, nil
+}
+
+// 与 [since, until] 有交集的请假
+func whereLeaveOverlaps(query *gorm.DB, since, until string) (*gorm.DB, error) {
+	if since != "" {
+		if _, err := time.Parse("2006-01-02", since); err != nil {
+			return nil, fmt.Errorf("invalid since %q", since)
+		}
+		query = query.Where("end_date >= ?", since)
+	}
+	if until != "" {
+		if _, err := time.Parse("2006-01-02", until); err != nil {
+			return nil, fmt.Errorf("invalid until %q", until)
+		}
+		query = query.Where("start_date <= ?", until)
+	}
+	return query, nil
+}
+
+func (s *server) ListLeaves(ctx context.Context, in *pb.ListLeavesRequest) (*pb.ListLeavesReply, error) {
+	query := db.Model(&LeaveInfo{})
+	if in.Name != "" {
+		query = query.Where("name = ?", in.Name)
+	} else if in.Group != nil {
+		query = query.Where("name IN (?)", db.Model(&UserInfo{}).Select("name").Where("`group` = ?", in.GetGroup()))
+	}
+	query, err := whereLeaveOverlaps(query, in.Since, in.Until)
+	if err != nil {
+		return nil, err
+	}
+	var leaves []LeaveInfo
+	if err := query.Order("start_date, id").Find(&leaves).Error; err != nil {
+		return nil, err
+	}
+	reply := &pb.ListLeavesReply{Leaves: make([]*pb.Leave, len(leaves))}
+	for i := range leaves {
+		reply.Leaves[i] = leaveInfoToPbLeave(&leaves[i])
+	}
+	return reply, nil
+}
+
+// 某人每天的可用工时
+type workCalendar struct {
+	weekdayHours [7]float64
+	leaves       []LeaveInfo
+	business     *businessCalendar
+}
+
+func (c *workCalendar) capacity(day time.Time) float64 {
+	// time.Weekday 从周日开始
+	hours := c.weekdayHours[(int(day.Weekday())+6)%7]
+	// 节假日不上班，调休上班日按周一至周五中最长的工时计算
+	if workday, ok := c.business.override(day); ok {
+		if !workday {
+			return 0
+		}
+		if hours == 0 {
+			for _, h := range c.weekdayHours[:5] {
+				hours = math.Max(hours, h)
+			}
+		}
+	}
+	for _, l := range c.leaves {
+		if day.Before(dateOf(l.StartDate)) || day.After(dateOf(l.EndDate)) {
+			continue
+		}
+		if l.Hours == 0 {
+			return 0
+		}
+		hours -= l.Hours
+	}
+	return math.Max(hours, 0)
+}
+
+// 各负责人的工作日历，horizon 为需要计算到的最后一天
+func loadWorkCalendars(tx *gorm.DB, users []UserInfo, from, horizon time.Time) (map[string]*workCalendar, error) {
+	ids := make([]uint, len(users))
+	names := make([]string, len(users))
+	for i, u := range users {
+		ids[i], names[i] = u.ID, u.Name
+	}
+	var schedules []WorkScheduleInfo
+	if err := tx.Where("user_id IN ?", ids).Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]string, len(schedules))
+	for _, s := range schedules {
+		byID[s.UserID] = s.WeekdayHours
+	}
+	var leaves []LeaveInfo
+	err := tx.Where("name IN ? AND end_date >= ? AND start_date <= ?", names, from.Format("2006-01-02"), horizon.Format("2006-01-02")).
+		Find(&leaves).Error
+	if err != nil {
+		return nil, err
+	}
+	business := currentBusinessCalendar()
+	calendars := make(map[string]*workCalendar, len(users))
+	for _, u := range users {
+		c := &workCalendar{weekdayHours: defaultWeekdayHours, business: business}
+		if s, ok := byID[u.ID]; ok {
+			c.weekdayHours = parseWeekdayHours(s)
+		}
+		calendars[u.Name] = c
+	}
+	for _, l := range leaves {
+		calendars[l.Name].leaves = append(calendars[l.Name].leaves, l)
+	}
+	return calendars, nil
+}
+
+// 待完成修改单的剩余工时，按截止日期排序
+type openTask struct {
+	task      TaskInfo
+	deadline  time.Time
+	remaining float64
+}
+
+func openTasksOf(tx *gorm.DB, principals []string) (map[string][]openTask, error) {
+	var tasks []TaskInfo
+	err := tx.Where("principal IN ? AND state <> ?", principals, TASK_STATE_TEXT_FINISH).
+		Order("deadline, task_id").Find(&tasks).Error
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(tasks))
+	for i := range tasks {
+		ids[i] = tasks[i].TaskID
+	}
+	logged := make(map[string]float64)
+	if len(ids) > 0 {
+		var sums []struct {
+			TaskID string
+			Hours  float64
+		}
+		if err := tx.Model(&WorkLogInfo{}).Select("task_id, SUM(hours) AS hours").
+			Where("task_id IN ?", ids).Group("task_id").Scan(&sums).Error; err != nil {
+			return nil, err
+		}
+		for _, s := range sums {
+			logged[s.TaskID] = s.Hours
+		}
+	}
+	res := make(map[string][]openTask)
+	for _, t := range tasks {
+		remaining := t.EstimatedWorkHours - logged[t.TaskID]
+		if remaining <= 0 {
+			continue
+		}
+		res[t.Principal] = append(res[t.Principal], openTask{task: t, deadline: dateOf(t.Deadline), remaining: remaining})
+	}
+	return res, nil
+}
+
+// 把每个修改单的剩余工时按可用工时的比例分摊到今天至截止日期之间，已过期或截止前没有可用工时的
+// 放在第一个可用的日期；再按截止日期先后累计，累计剩余工时超过累计可用工时的修改单有延期风险
+func planCapacity(cal *workCalendar, tasks []openTask, today, since, until, horizon time.Time) *pb.PrincipalCapacity {
+	days := int(horizon.Sub(today).Hours()/24+0.5) + 1
+	capacity := make([]float64, days)
+	load := make([]float64, days)
+	for i := range capacity {
+		capacity[i] = cal.capacity(today.AddDate(0, 0, i))
+	}
+	firstWorkday := 0
+	for firstWorkday < days-1 && capacity[firstWorkday] == 0 {
+		firstWorkday++
+	}
+
+	res := &pb.PrincipalCapacity{}
+	var demand, cumCapacity float64
+	next := 0
+	for _, t := range tasks {
+		last := int(t.deadline.Sub(today).Hours()/24 + 0.5)
+		if last >= days {
+			last = days - 1
+		}
+		var available float64
+		for i := 0; i <= last; i++ {
+			available += capacity[i]
+		}
+		for ; next <= last; next++ {
+			cumCapacity += capacity[next]
+		}
+		risk := &pb.TaskRisk{
+			TaskId:         t.task.TaskID,
+			Comment:        t.task.Comment,
+			Deadline:       t.deadline.Format("2006-01-02"),
+			RemainingHours: roundHours(t.remaining),
+			AvailableHours: roundHours(math.Max(cumCapacity-demand, 0)),
+			WorkdaysLeft:   int32(cal.business.workdaysBetweenWithin(today, t.deadline, maxDisplayWorkdays)),
+		}
+		demand += t.remaining
+		switch {
+		case t.deadline.Before(today):
+			risk.Reason = TASK_RISK_OVERDUE
+			risk.AvailableHours = 0
+			load[firstWorkday] += t.remaining
+		case available == 0:
+			risk.Reason = TASK_RISK_INSUFFICIENT
+			load[firstWorkday] += t.remaining
+		default:
+			for i := 0; i <= last; i++ {
+				load[i] += t.remaining * capacity[i] / available
+			}
+			if demand > cumCapacity+1e-9 {
+				risk.Reason = TASK_RISK_INSUFFICIENT
+			}
+		}
+		if risk.Reason != "" {
+			res.AtRisk = append(res.AtRisk, risk)
+		}
+	}
+
+	for day := since; !day.After(until); day = day.AddDate(0, 0, 1) {
+		i := int(day.Sub(today).Hours()/24 + 0.5)
+		d := &pb.CapacityDay{
+			Date:          day.Format("2006-01-02"),
+			Capacity:      roundHours(capacity[i]),
+			Load:          roundHours(load[i]),
+			Overallocated: load[i] > capacity[i]+1e-9,
+		}
+		res.Days = append(res.Days, d)
+		res.CapacityHours += capacity[i]
+		res.LoadHours += load[i]
+		if d.Overallocated {
+			res.OverallocatedDays = append(res.OverallocatedDays, d.Date)
+		}
+	}
+	res.CapacityHours = roundHours(res.CapacityHours)
+	res.LoadHours = roundHours(res.LoadHours)
+	return res
+}
+
+func (s *server) GetCapacity(ctx context.Context, in *pb.GetCapacityRequest) (*pb.GetCapacityReply, error) {
+	today := dateOf(time.Now())
+	since := today
+	if in.Since != "" {
+		t, err := time.ParseInLocation("2006-01-02", in.Since, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid since %q", in.Since)
+		}
+		if t.After(today) {
+			since = t
+		}
+	}
+	until := since.AddDate(0, 0, defaultCapacityDays-1)
+	if in.Until != "" {
+		t, err := time.ParseInLocation("2006-01-02", in.Until, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid until %q", in.Until)
+		}
+		until = t
+	}
+	if until.Before(since) {
+		return nil, errors.New("until must not be before since")
+	}
+	if until.Sub(today).Hours()/24 >= maxCapacityDays {
+		return nil, fmt.Errorf("until must be within %d days from today", maxCapacityDays)
+	}
+
+	query := db.Where("active = ?", true)
+	if len(in.Principals) > 0 {
+		query = db.Where("name IN ?", in.Principals)
+	} else if in.Group != nil {
+		query = query.Where("`group` = ?", in.GetGroup())
+	}
+	var users []UserInfo
+	if err := query.Order("name").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	reply := &pb.GetCapacityReply{Since: since.Format("2006-01-02"), Until: until.Format("2006-01-02")}
+	if len(users) == 0 {
+		return reply, nil
+	}
+	names := make([]string, len(users))
+	for i, u := range users {
+		names[i] = u.Name
+	}
+	tasks, err := openTasksOf(db, names)
+	if err != nil {
+		return nil, err
+	}
+	// 截止日期在 until 之后的修改单也要计算到截止日期，才能按比例分摊
+	horizon := until
+	for _, ts := range tasks {
+		for _, t := range ts {
+			if t.deadline.After(horizon) {
+				horizon = t.deadline
+			}
+		}
+	}
+	if limit := today.AddDate(0, 0, maxCapacityDays-1); horizon.After(limit) {
+		horizon = limit
+	}
+	calendars, err := loadWorkCalendars(db, users, today, horizon)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		p := planCapacity(calendars[u.Name], tasks[u.Name], today, since, until, horizon)
+		p.Principal = u.Name
+		reply.Principals = append(reply.Principals, p)
+	}
+	return reply, nil
+}
@@ -0,0 +1,137 @@
+// githook 供本地 git 钩子调用，把提交上报给服务端，由服务端按提交信息中的任务单号关联修改单。
+//
+// post-commit 钩子：
+//
+//	githook -addr 127.0.0.1:8001
+//
+// pre-push 钩子可以上报一个范围内的提交：
+//
+//	githook origin/master..HEAD
+//
+// API Key 从 -key 或环境变量 ORDERMANAGER_API_KEY 读取，需要 tasks:write 权限。
+// 任何错误都只打印日志，退出码始终为 0，不会阻止提交或推送
+package main
+
+import (
+	"OrderManager/pb"
+	"context"
+	"flag"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"time"
+)
+
+// 单次上报的提交数上限，避免首次推送长历史时请求过大
+const maxCommits = 200
+
+func git(args ...string) (string, error) {
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v", strings.Join(args, " "), err)
+	}
+	return string(out), nil
+}
+
+// 仓库路径取 origin 地址中的 group/project，没有 origin 时取目录名
+func repoName() (string, error) {
+	if origin, err := git("config", "--get", "remote.origin.url"); err == nil {
+		origin = strings.TrimSuffix(strings.TrimSpace(origin), ".git")
+		// git@host:group/project 形式的地址
+		if i := strings.Index(origin, ":"); i > 0 && !strings.Contains(origin, "://") {
+			return origin[i+1:], nil
+		}
+		if u, err := url.Parse(origin); err == nil && u.Path != "" {
+			return strings.Trim(u.Path, "/"), nil
+		}
+	}
+	top, err := git("rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return path.Base(strings.TrimSpace(top)), nil
+}
+
+func readCommits(revs []string) ([]*pb.GitCommit, error) {
+	args := []string{"log", fmt.Sprintf("--max-count=%d", maxCommits), "--format=%H%x1f%an%x1f%cI%x1f%B%x1e"}
+	if len(revs) == 0 {
+		args = append(args, "-1", "HEAD")
+	} else {
+		args = append(args, revs...)
+	}
+	out, err := git(args...)
+	if err != nil {
+		return nil, err
+	}
+	var commits []*pb.GitCommit
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x1f", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		commits = append(commits, &pb.GitCommit{
+			Sha:         fields[0],
+			Author:      fields[1],
+			CommittedAt: fields[2],
+			Message:     strings.TrimSpace(fields[3]),
+		})
+	}
+	return commits, nil
+}
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8001", "server address")
+	key := flag.String("key", os.Getenv("ORDERMANAGER_API_KEY"), "API key with tasks:write scope")
+	repo := flag.String("repo", "", "repository path, defaults to the origin remote")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: githook [flags] [revision range]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	// 钩子失败不应阻止提交或推送，出错时只打印日志并正常退出
+	if *key == "" {
+		log.Println("githook: api key is required, use -key or ORDERMANAGER_API_KEY")
+		return
+	}
+	if *repo == "" {
+		name, err := repoName()
+		if err != nil {
+			log.Println("githook:", err)
+			return
+		}
+		*repo = name
+	}
+	commits, err := readCommits(flag.Args())
+	if err != nil {
+		log.Println("githook:", err)
+		return
+	}
+	if len(commits) == 0 {
+		return
+	}
+
+	conn, err := grpc.NewClient(*addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Println("githook:", err)
+		return
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+*key)
+	reply, err := pb.NewServiceClient(conn).LinkCommits(ctx, &pb.LinkCommitsRequest{Repo: *repo, Commits: commits})
+	if err != nil {
+		log.Println("githook:", err)
+		return
+	}
+	for _, c := range reply.Linked {
+		fmt.Printf("githook: %s -> %s\n", c.Ref[:min(len(c.Ref), 10)], c.TaskId)
+	}
+}
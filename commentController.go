@@ -0,0 +1,212 @@
+package main
+
+import (
+	"OrderManager/pb"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const commentPreviewLen = 50
+
+var mentionPattern = regexp.MustCompile(`@([\p{L}\p{N}_.\-]+)`)
+
+// 解析内容中的 @姓名，只保留 user_table 中存在且启用的用户，按出现顺序去重
+func resolveMentions(content string) ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, m := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			names = append(names, m[1])
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	var existing []string
+	if err := db.Model(&UserInfo{}).Where("name IN ? AND active = ?", names, true).Pluck("name", &existing).Error; err != nil {
+		return nil, err
+	}
+	valid := make(map[string]bool, len(existing))
+	for _, name := range existing {
+		valid[name] = true
+	}
+	var mentions []string
+	for _, name := range names {
+		if valid[name] {
+			mentions = append(mentions, name)
+		}
+	}
+	return mentions, nil
+}
+
+func splitMentions(mentions string) []string {
+	if mentions == "" {
+		return nil
+	}
+	return strings.Split(mentions, ",")
+}
+
+func commentEntityExists(entityType, entityID string) error {
+	switch entityType {
+	case AUDIT_ENTITY_TASK:
+		task, err := findTask(db, entityID)
+		if err == nil && task == nil {
+			err = errors.New("task does not exist")
+		}
+		return err
+	case AUDIT_ENTITY_PATCH:
+		patch, err := findPatch(db, entityID)
+		if err == nil && patch == nil {
+			err = errors.New("patch does not exist")
+		}
+		return err
+	}
+	return fmt.Errorf("unknown entity type: %s", entityType)
+}
+
+// 通知消息用 <发送人>、<接收人> 路由，预览中的尖括号需要替换掉
+var previewReplacer = strings.NewReplacer("\n", " ", "<", "‹", ">", "›")
+
+func commentPreview(content string) string {
+	content = previewReplacer.Replace(content)
+	if utf8.RuneCountInString(content) <= commentPreviewLen {
+		return content
+	}
+	return string([]rune(content)[:commentPreviewLen]) + "..."
+}
+
+// 给被 @ 的用户发送定向通知，skip 中的用户（例如修改前已通知过的）不再通知
+func notifyMentions(c *CommentInfo, mentions []string, skip []string) {
+	skipped := make(map[string]bool, len(skip))
+	for _, name := range skip {
+		skipped[name] = true
+	}
+	for _, name := range mentions {
+		if skipped[name] || name == c.Author {
+			continue
+		}
+		msg := fmt.Sprintf("<%s> -> mentioned you in %s %s: %s -> <%s>", c.Author, c.EntityType, c.EntityID, commentPreview(c.Content), name)
+		NotificationServer.updateDatabaseAndNotify(msg)
+	}
+}
+
+func commentInfoToPbComment(c *CommentInfo) *pb.Comment {
+	return &pb.Comment{
+		Id:         uint64(c.ID),
+		EntityType: c.EntityType,
+		EntityId:   c.EntityID,
+		Author:     c.Author,
+		Content:    c.Content,
+		Mentions:   splitMentions(c.Mentions),
+		CreatedAt:  c.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:  c.UpdatedAt.Format("2006-01-02 15:04:05"),
+		Edited:     c.UpdatedAt.Sub(c.CreatedAt) > 0,
+	}
+}
+
+func findComment(id uint64) (*CommentInfo, error) {
+	var c CommentInfo
+	if err := db.First(&c, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("comment does not exist")
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (s *server) AddComment(ctx context.Context, in *pb.AddCommentRequest) (*pb.AddCommentReply, error) {
+	author := operatorName(ctx, in.User)
+	if author == "" || strings.TrimSpace(in.Content) == "" {
+		return nil, errors.New("user and content are required")
+	}
+	if err := requireEntityScope(ctx, in.EntityType); err != nil {
+		return nil, err
+	}
+	if err := commentEntityExists(in.EntityType, in.EntityId); err != nil {
+		return nil, err
+	}
+	mentions, err := resolveMentions(in.Content)
+	if err != nil {
+		return nil, err
+	}
+	c := &CommentInfo{
+		EntityType: in.EntityType,
+		EntityID:   in.EntityId,
+		Author:     author,
+		Content:    in.Content,
+		Mentions:   strings.Join(mentions, ","),
+	}
+	if err := db.Create(c).Error; err != nil {
+		return nil, err
+	}
+	notifyMentions(c, mentions, nil)
+	return &pb.AddCommentReply{C: commentInfoToPbComment(c)}, nil
+}
+
+func (s *server) EditComment(ctx context.Context, in *pb.EditCommentRequest) (*pb.EditCommentReply, error) {
+	if strings.TrimSpace(in.Content) == "" {
+		return nil, errors.New("content is required")
+	}
+	c, err := findComment(in.Id)
+	if err != nil {
+		return nil, err
+	}
+	if c.Author != operatorName(ctx, in.User) {
+		return nil, errPermissionDenied
+	}
+	if err := requireEntityScope(ctx, c.EntityType); err != nil {
+		return nil, err
+	}
+	mentions, err := resolveMentions(in.Content)
+	if err != nil {
+		return nil, err
+	}
+	previous := splitMentions(c.Mentions)
+	if err := db.Model(c).Updates(map[string]interface{}{
+		"content":  in.Content,
+		"mentions": strings.Join(mentions, ","),
+	}).Error; err != nil {
+		return nil, err
+	}
+	notifyMentions(c, mentions, previous)
+	return &pb.EditCommentReply{C: commentInfoToPbComment(c)}, nil
+}
+
+func (s *server) DeleteComment(ctx context.Context, in *pb.DeleteCommentRequest) (*pb.DeleteCommentReply, error) {
+	c, err := findComment(in.Id)
+	if err != nil {
+		return nil, err
+	}
+	operator := operatorName(ctx, in.User)
+	if c.Author != operator {
+		if err := requireAdmin(db, operator); err != nil {
+			return nil, err
+		}
+	}
+	if err := requireEntityScope(ctx, c.EntityType); err != nil {
+		return nil, err
+	}
+	if err := db.Delete(c).Error; err != nil {
+		return nil, err
+	}
+	return &pb.DeleteCommentReply{}, nil
+}
+
+func (s *server) ListComments(ctx context.Context, in *pb.ListCommentsRequest) (*pb.ListCommentsReply, error) {
+	var comments []CommentInfo
+	if err := db.Where("entity_type = ? AND entity_id = ?", in.EntityType, in.EntityId).Order("id").Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	reply := &pb.ListCommentsReply{Comments: make([]*pb.Comment, len(comments))}
+	for i := range comments {
+		reply.Comments[i] = commentInfoToPbComment(&comments[i])
+	}
+	return reply, nil
+}
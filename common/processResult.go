@@ -3,23 +3,41 @@ package common
 import (
 	"OrderManager/models"
 	"OrderManager/pb"
+	"math"
+	"strings"
 	"time"
 )
 
+func formatDateTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+func OneTaskInfoToPbTask(task models.TaskInfo) *pb.Task {
+	level := int32(task.EmergencyLevel)
+	return &pb.Task{
+		Comment:            task.Comment,
+		TaskId:             task.TaskID,
+		EmergencyLevel:     &level,
+		Deadline:           task.Deadline.Format("2006-01-02"), // 格式化日期
+		Principal:          task.Principal,
+		ReqNo:              task.ReqNo,
+		EstimatedWorkHours: int64(math.Round(task.EstimatedWorkHours)),
+		EstimatedHours:     task.EstimatedWorkHours,
+		State:              task.State,
+		TypeId:             int32(task.Type),
+		Version:            task.Version,
+		UpdatedAt:          formatDateTime(task.UpdatedAt),
+		ParentId:           task.ParentID,
+	}
+}
+
 func AllTaskInfoToPbTask(tasks []models.TaskInfo) []*pb.Task {
 	result := make([]*pb.Task, len(tasks))
 	for i, task := range tasks {
-		result[i] = &pb.Task{
-			Comment:            task.Comment,
-			TaskId:             task.TaskID,
-			EmergencyLevel:     int32(task.EmergencyLevel),
-			Deadline:           task.Deadline.Format("2006-01-02"), // 格式化日期
-			Principal:          task.Principal,
-			ReqNo:              task.ReqNo,
-			EstimatedWorkHours: int64(task.EstimatedWorkHours),
-			State:              task.State,
-			TypeId:             int32(task.Type),
-		}
+		result[i] = OneTaskInfoToPbTask(task)
 	}
 	return result
 }
@@ -27,18 +45,7 @@ func AllTaskInfoToPbTask(tasks []models.TaskInfo) []*pb.Task {
 func AllPbTaskToTaskInfo(tasks []*pb.Task) []models.TaskInfo {
 	res := make([]models.TaskInfo, len(tasks))
 	for i, task := range tasks {
-		t, _ := time.Parse("2006-01-02", task.Deadline)
-		res[i] = models.TaskInfo{
-			Comment:            task.Comment,
-			TaskID:             task.TaskId,
-			EmergencyLevel:     int(task.EmergencyLevel),
-			Deadline:           t, // 格式化日期
-			Principal:          task.Principal,
-			ReqNo:              task.ReqNo,
-			EstimatedWorkHours: float64(task.EstimatedWorkHours),
-			State:              task.State,
-			Type:               int(task.TypeId),
-		}
+		res[i] = *OnePbTaskToTaskInfo(task)
 	}
 	return res
 }
@@ -48,17 +55,27 @@ func OnePbTaskToTaskInfo(task *pb.Task) *models.TaskInfo {
 	res := &models.TaskInfo{
 		Comment:            task.Comment,
 		TaskID:             task.TaskId,
-		EmergencyLevel:     int(task.EmergencyLevel),
+		EmergencyLevel:     int(task.GetEmergencyLevel()),
 		Deadline:           t, // 格式化日期
 		Principal:          task.Principal,
 		ReqNo:              task.ReqNo,
-		EstimatedWorkHours: float64(task.EstimatedWorkHours),
+		EstimatedWorkHours: PbTaskEstimatedHours(task),
 		State:              task.State,
 		Type:               int(task.TypeId),
+		Version:            task.Version,
+		ParentID:           task.ParentId,
 	}
 	return res
 }
 
+// 优先使用支持小数的 estimatedHours，旧客户端只会填 estimatedWorkHours
+func PbTaskEstimatedHours(task *pb.Task) float64 {
+	if task.EstimatedHours != 0 {
+		return task.EstimatedHours
+	}
+	return float64(task.EstimatedWorkHours)
+}
+
 func AllPbPatchsToPatchsInfo(patchs []*pb.Patch) []models.PatchsInfo {
 	result := make([]models.PatchsInfo, len(patchs))
 	for i, p := range patchs {
@@ -72,6 +89,7 @@ func AllPbPatchsToPatchsInfo(patchs []*pb.Patch) []models.PatchsInfo {
 			Reason:     p.Reason,
 			Sponsor:    p.Sponsor,
 			State:      p.State,
+			Version:    p.Version,
 		}
 	}
 	return result
@@ -80,16 +98,7 @@ func AllPbPatchsToPatchsInfo(patchs []*pb.Patch) []models.PatchsInfo {
 func AllPatchsInfoToPbPatchs(patchs []models.PatchsInfo) []*pb.Patch {
 	result := make([]*pb.Patch, len(patchs))
 	for i, p := range patchs {
-		result[i] = &pb.Patch{
-			PatchNo:    p.PatchNo,
-			ClientName: p.ClientName,
-			Reason:     p.Reason,
-			ReqNo:      p.ReqNo,
-			Describe:   p.Describe,
-			Sponsor:    p.Sponsor,
-			Deadline:   p.Deadline.Format("2006-01-02"),
-			State:      p.State,
-		}
+		result[i] = OnePatchsInfoToPbPatchs(p)
 	}
 	return result
 }
@@ -104,6 +113,100 @@ func OnePatchsInfoToPbPatchs(patchs models.PatchsInfo) *pb.Patch {
 		Sponsor:    patchs.Sponsor,
 		Deadline:   patchs.Deadline.Format("2006-01-02"),
 		State:      patchs.State,
+		Version:    patchs.Version,
+		UpdatedAt:  formatDateTime(patchs.UpdatedAt),
 	}
 
 }
+
+// 不返回密码
+func UserInfoToPbUser(user models.UserInfo) *pb.User {
+	res := &pb.User{
+		Id:     uint64(user.ID),
+		Name:   user.Name,
+		Group:  int32(user.Group),
+		RoleNo: int32(user.RoleNo),
+		Active: user.Active,
+		Source: user.Source,
+	}
+	if user.JobNo != nil {
+		res.JobNum = int64(*user.JobNo)
+	}
+	if user.Email != nil {
+		res.Email = *user.Email
+	}
+	return res
+}
+
+func AllUserInfoToPbUser(users []models.UserInfo) []*pb.User {
+	result := make([]*pb.User, len(users))
+	for i, user := range users {
+		result[i] = UserInfoToPbUser(user)
+	}
+	return result
+}
+
+func AllAuthEventInfoToPbAuthEvent(events []models.AuthEventInfo) []*pb.AuthEvent {
+	result := make([]*pb.AuthEvent, len(events))
+	for i, e := range events {
+		result[i] = &pb.AuthEvent{
+			Id:       uint64(e.ID),
+			Time:     e.CreatedAt.Format("2006-01-02 15:04:05"),
+			Event:    e.Event,
+			Name:     e.Name,
+			PeerAddr: e.PeerAddr,
+			Detail:   e.Detail,
+		}
+	}
+	return result
+}
+
+func TaskTypeInfoToPbTaskType(t models.TaskTypeInfo) *pb.TaskType {
+	res := &pb.TaskType{
+		Id:                    int32(t.ID),
+		Name:                  t.Name,
+		Color:                 t.Color,
+		DefaultEstimatedHours: t.DefaultEstimatedHours,
+		DefaultEmergencyLevel: int32(t.DefaultEmergencyLevel),
+	}
+	if t.RequiredFields != "" {
+		res.RequiredFields = strings.Split(t.RequiredFields, ",")
+	}
+	return res
+}
+
+func AllTaskTypeInfoToPbTaskType(types []models.TaskTypeInfo) []*pb.TaskType {
+	result := make([]*pb.TaskType, len(types))
+	for i, t := range types {
+		result[i] = TaskTypeInfoToPbTaskType(t)
+	}
+	return result
+}
+
+func PbTaskTypeToTaskTypeInfo(t *pb.TaskType) *models.TaskTypeInfo {
+	return &models.TaskTypeInfo{
+		ID:                    int(t.Id),
+		Name:                  t.Name,
+		Color:                 t.Color,
+		DefaultEstimatedHours: t.DefaultEstimatedHours,
+		DefaultEmergencyLevel: int(t.DefaultEmergencyLevel),
+		RequiredFields:        strings.Join(t.RequiredFields, ","),
+	}
+}
+
+func LabelInfoToPbLabel(l models.LabelInfo) *pb.Label {
+	return &pb.Label{
+		Id:          uint64(l.ID),
+		Name:        l.Name,
+		Color:       l.Color,
+		Description: l.Description,
+	}
+}
+
+func AllLabelInfoToPbLabel(labels []models.LabelInfo) []*pb.Label {
+	result := make([]*pb.Label, len(labels))
+	for i, l := range labels {
+		result[i] = LabelInfoToPbLabel(l)
+	}
+	return result
+}
@@ -0,0 +1,8 @@
+package config
+
+// 日历订阅（iCalendar）的 HTTP 服务，CALENDAR_HTTP_ADDR 为空时不启动
+const (
+	CALENDAR_HTTP_ADDR = ":8002"
+	// 返回给客户端的订阅地址前缀
+	CALENDAR_BASE_URL = "http://127.0.0.1:8002"
+)
@@ -0,0 +1,11 @@
+package config
+
+// 代码仓库 Webhook（GitLab/GitHub/Gitea），GIT_WEBHOOK_SECRET 为空时不启动
+const (
+	GIT_WEBHOOK_ADDR   = ":8003"
+	GIT_WEBHOOK_SECRET = ""
+	// 提交信息、合并请求标题和分支名中可能包含任务单号的词，匹配到的单号还须存在于修改单表中
+	GIT_TASK_ID_PATTERN = `[A-Za-z0-9][A-Za-z0-9_\-]*[0-9][A-Za-z0-9_\-]*`
+	// 合并请求合并后修改单改为的状态，如 已完成；为空时不修改
+	GIT_MR_MERGED_STATE = ""
+)
@@ -0,0 +1,16 @@
+package config
+
+// LDAP_URL 为空时不启用目录认证
+const (
+	LDAP_URL           = ""
+	LDAP_BIND_DN       = ""
+	LDAP_BIND_PASSWORD = ""
+	LDAP_BASE_DN       = "ou=people,dc=example,dc=com"
+	LDAP_USER_FILTER   = "(&(objectClass=person)(uid=%s))"
+
+	// 目录属性到 UserInfo 字段的映射
+	LDAP_ATTR_NAME   = "uid"
+	LDAP_ATTR_JOB_NO = "employeeNumber"
+	LDAP_ATTR_EMAIL  = "mail"
+	LDAP_ATTR_GROUP  = "departmentNumber"
+)
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"OrderManager/common"
+	"OrderManager/pb"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+)
+
+// 修改单状态（state 字段）
+const (
+	TASK_STATE_TEXT_WAIT    = "带启动"
+	TASK_STATE_TEXT_ING     = "进行中"
+	TASK_STATE_TEXT_FINISH  = "已完成"
+	TASK_STATE_TEXT_BLOCKED = "阻塞"
+)
+
+// 修改单的截止日期早于其前置修改单
+type deadlineConflict struct {
+	task   TaskInfo
+	prereq TaskInfo
+}
+
+func (c deadlineConflict) String() string {
+	return fmt.Sprintf("task %s is due %s, before its prerequisite %s (due %s)",
+		c.task.TaskID, c.task.Deadline.Format("2006-01-02"), c.prereq.TaskID, c.prereq.Deadline.Format("2006-01-02"))
+}
+
+func conflictsToWarnings(conflicts []deadlineConflict) []string {
+	warnings := make([]string, len(conflicts))
+	for i, c := range conflicts {
+		warnings[i] = c.String()
+	}
+	return warnings
+}
+
+// 通知依赖方的负责人截止日期已不可行
+func notifyDeadlineConflicts(user string, conflicts []deadlineConflict) {
+	for _, c := range conflicts {
+		msg := fmt.Sprintf("<%s> -> deadline conflict: %s -> <%s>", user, c.String(), c.task.Principal)
+		NotificationServer.updateDatabaseAndNotify(msg)
+	}
+}
+
+// 从 from 出发沿前置关系查找 target，找到时返回经过的路径
+func dependencyPath(edges []TaskDependencyInfo, from, target string) []string {
+	prereqs := make(map[string][]string)
+	for _, e := range edges {
+		prereqs[e.TaskID] = append(prereqs[e.TaskID], e.DependsOn)
+	}
+	parent := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if cur == target {
+			var path []string
+			for id := cur; id != ""; id = parent[id] {
+				path = append([]string{id}, path...)
+			}
+			return path
+		}
+		for _, next := range prereqs[cur] {
+			if _, seen := parent[next]; !seen {
+				parent[next] = cur
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil
+}
+
+func dependentsOf(tx *gorm.DB, taskIDs []string) ([]string, error) {
+	var ids []string
+	if len(taskIDs) == 0 {
+		return ids, nil
+	}
+	err := tx.Model(&TaskDependencyInfo{}).Distinct("task_id").Where("depends_on IN ?", taskIDs).Pluck("task_id", &ids).Error
+	return ids, err
+}
+
+// 未完成的前置修改单数量，回收站中的前置修改单不计入
+func unfinishedPrerequisites(tx *gorm.DB, taskID string) (int64, error) {
+	var n int64
+	err := tx.Model(&TaskDependencyInfo{}).
+		Joins("JOIN tasklist_table ON tasklist_table.task_id = task_dependency_table.depends_on AND tasklist_table.deleted_at IS NULL").
+		Where("task_dependency_table.task_id = ? AND tasklist_table.state <> ?", taskID, TASK_STATE_TEXT_FINISH).
+		Count(&n).Error
+	return n, err
+}
+
+// 根据前置修改单的完成情况重新计算阻塞状态：有未完成的前置时置为阻塞，全部完成后恢复为阻塞前的状态
+func refreshBlocked(ctx context.Context, tx *gorm.DB, user string, taskIDs []string) error {
+	seen := make(map[string]bool, len(taskIDs))
+	for _, id := range taskIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		task, err := findTask(tx, id)
+		if err != nil {
+			return err
+		}
+		if task == nil {
+			continue
+		}
+		n, err := unfinishedPrerequisites(tx, id)
+		if err != nil {
+			return err
+		}
+		updates := map[string]interface{}{"version": gorm.Expr("version + 1")}
+		if n > 0 && task.State != TASK_STATE_TEXT_BLOCKED && task.State != TASK_STATE_TEXT_FINISH {
+			updates["state"], updates["state_before_blocked"] = TASK_STATE_TEXT_BLOCKED, task.State
+		} else if n == 0 && task.State == TASK_STATE_TEXT_BLOCKED {
+			updates["state"], updates["state_before_blocked"] = task.StateBeforeBlocked, ""
+			if task.StateBeforeBlocked == "" {
+				updates["state"] = TASK_STATE_TEXT_WAIT
+			}
+		} else {
+			continue
+		}
+		if err := tx.Model(&TaskInfo{}).Where("task_id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+		after, err := findTask(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, tx, user, AUDIT_ENTITY_TASK, task, after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 查找与 taskIDs 相关（作为依赖方或前置方）的截止日期冲突，已完成的依赖方不再提示
+func deadlineConflicts(tx *gorm.DB, taskIDs []string) ([]deadlineConflict, error) {
+	var conflicts []deadlineConflict
+	if len(taskIDs) == 0 {
+		return conflicts, nil
+	}
+	var edges []TaskDependencyInfo
+	if err := tx.Where("task_id IN ? OR depends_on IN ?", taskIDs, taskIDs).Order("id").Find(&edges).Error; err != nil {
+		return nil, err
+	}
+	if len(edges) == 0 {
+		return conflicts, nil
+	}
+	var ids []string
+	for _, e := range edges {
+		ids = append(ids, e.TaskID, e.DependsOn)
+	}
+	var tasks []TaskInfo
+	if err := tx.Where("task_id IN ?", ids).Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[string]TaskInfo, len(tasks))
+	for _, t := range tasks {
+		byID[t.TaskID] = t
+	}
+	for _, e := range edges {
+		task, ok1 := byID[e.TaskID]
+		prereq, ok2 := byID[e.DependsOn]
+		if !ok1 || !ok2 || task.State == TASK_STATE_TEXT_FINISH {
+			continue
+		}
+		if task.Deadline.Before(prereq.Deadline) {
+			conflicts = append(conflicts, deadlineConflict{task: task, prereq: prereq})
+		}
+	}
+	return conflicts, nil
+}
+
+func (s *server) AddTaskDependency(ctx context.Context, in *pb.AddTaskDependencyRequest) (*pb.AddTaskDependencyReply, error) {
+	if in.TaskId == in.DependsOn {
+		return nil, errors.New("task cannot depend on itself")
+	}
+	var after *TaskInfo
+	var prereq *TaskInfo
+	var conflicts []deadlineConflict
+	err := db.Transaction(func(tx *gorm.DB) error {
+		task, err := findTask(tx, in.TaskId)
+		if err != nil {
+			return err
+		}
+		if task == nil {
+			return errors.New("task does not exist")
+		}
+		if prereq, err = findTask(tx, in.DependsOn); err != nil {
+			return err
+		}
+		if prereq == nil {
+			return errors.New("prerequisite task does not exist")
+		}
+
+		// 回收站中的修改单恢复后依赖关系仍然有效，所以一并参与环检测。
+		// 加锁读取整张依赖表，使并发的新增依赖串行执行，否则两个事务各自检查通过后可能共同形成环
+		var edges []TaskDependencyInfo
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Find(&edges).Error; err != nil {
+			return err
+		}
+		for _, e := range edges {
+			if e.TaskID == in.TaskId && e.DependsOn == in.DependsOn {
+				return errors.New("dependency already exists")
+			}
+		}
+		if path := dependencyPath(edges, in.DependsOn, in.TaskId); path != nil {
+			return fmt.Errorf("dependency would create a cycle: %s -> %s", in.TaskId, strings.Join(path, " -> "))
+		}
+
+		if err := tx.Create(&TaskDependencyInfo{TaskID: in.TaskId, DependsOn: in.DependsOn, CreatedBy: operatorName(ctx, in.User)}).Error; err != nil {
+			return err
+		}
+		if err := refreshBlocked(ctx, tx, in.User, []string{in.TaskId}); err != nil {
+			return err
+		}
+		if after, err = findTask(tx, in.TaskId); err != nil {
+			return err
+		}
+		if after.State != TASK_STATE_TEXT_FINISH && after.Deadline.Before(prereq.Deadline) {
+			conflicts = append(conflicts, deadlineConflict{task: *after, prereq: *prereq})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	msg := fmt.Sprintf("<%s> -> add dependency: %s depends on %s -> <%s>", in.User, in.TaskId, in.DependsOn, after.Principal)
+	NotificationServer.updateDatabaseAndNotify(msg)
+	notifyDeadlineConflicts(in.User, conflicts)
+	return &pb.AddTaskDependencyReply{T: common.OneTaskInfoToPbTask(*after), Warnings: conflictsToWarnings(conflicts)}, nil
+}
+
+func (s *server) RemoveTaskDependency(ctx context.Context, in *pb.RemoveTaskDependencyRequest) (*pb.RemoveTaskDependencyReply, error) {
+	var after *TaskInfo
+	err := db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("task_id = ? AND depends_on = ?", in.TaskId, in.DependsOn).Delete(&TaskDependencyInfo{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errors.New("dependency does not exist")
+		}
+		if err := refreshBlocked(ctx, tx, in.User, []string{in.TaskId}); err != nil {
+			return err
+		}
+		var err error
+		after, err = findTask(tx.Unscoped(), in.TaskId)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if after == nil {
+		return &pb.RemoveTaskDependencyReply{}, nil
+	}
+	msg := fmt.Sprintf("<%s> -> remove dependency: %s depends on %s -> <%s>", in.User, in.TaskId, in.DependsOn, after.Principal)
+	NotificationServer.updateDatabaseAndNotify(msg)
+	return &pb.RemoveTaskDependencyReply{T: common.OneTaskInfoToPbTask(*after)}, nil
+}
+
+func (s *server) ListTaskDependencies(ctx context.Context, in *pb.ListTaskDependenciesRequest) (*pb.ListTaskDependenciesReply, error) {
+	var prereqs, dependents []TaskInfo
+	if err := db.Where("task_id IN (?)", db.Model(&TaskDependencyInfo{}).Select("depends_on").Where("task_id = ?", in.TaskId)).
+		Find(&prereqs).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("task_id IN (?)", db.Model(&TaskDependencyInfo{}).Select("task_id").Where("depends_on = ?", in.TaskId)).
+		Find(&dependents).Error; err != nil {
+		return nil, err
+	}
+	blocked := false
+	for _, t := range prereqs {
+		if t.State != TASK_STATE_TEXT_FINISH {
+			blocked = true
+			break
+		}
+	}
+	return &pb.ListTaskDependenciesReply{
+		Prerequisites: common.AllTaskInfoToPbTask(prereqs),
+		Dependents:    common.AllTaskInfoToPbTask(dependents),
+		Blocked:       blocked,
+	}, nil
+}
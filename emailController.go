@@ -50,12 +50,16 @@ func emailClock() {
 }
 
 func queryAndSendEmail() {
+	// 周末和节假日不发送提醒
+	if !currentBusinessCalendar().isWorkday(time.Now()) {
+		return
+	}
 	type nameAndEmail struct {
 		Name  string
 		Email string
 	}
 	var ne []nameAndEmail
-	if err := db.Table("user_table").Select("name", "email").Find(&ne).Error; err != nil {
+	if err := db.Table("user_table").Select("name", "email").Where("email IS NOT NULL").Find(&ne).Error; err != nil {
 		log.Println(err)
 		return
 	}
@@ -83,7 +87,7 @@ func sendEmail(name string, email string) {
 	}
 	msg := make([]byte, 0)
 	for _, content := range contents {
-		row := fmt.Sprintf("task_id:%s req_no:%s deadline:%s comment:%s taskTime:%s\n", content.taskId, content.reqNo, content.deadline, content.comment, content.estimatedWorkHour)
+		row := fmt.Sprintf("task_id:%s req_no:%s deadline:%s comment:%s taskTime:%v workdaysLeft:%d\n", content.taskId, content.reqNo, content.deadline.Format("2006-01-02"), content.comment, content.estimatedWorkHour, content.workdaysLeft)
 		msg = append(msg, []byte(row)...)
 	}
 	err = smtp.SendMail(config.Addr, config.Auth, config.Sender, []string{email}, msg)
@@ -98,14 +102,34 @@ type sendContent struct {
 	deadline          time.Time
 	comment           string
 	estimatedWorkHour float64
+	workdaysLeft      int // 距截止日期的工作日数，已过期时为负数，绝对值最大为 maxDisplayWorkdays
 }
 
+// 未完成的修改单，按截止日期排序
 func querySendContent(name string) ([]sendContent, error) {
-
-	var ct []sendContent
-	if err := db.Table("tasklist_table").Select("task_id", "req_no", "deadline", "comment", "estimated_work_hours").Where("principal = ?", name).Find(&ct).Error; err != nil {
+	var tasks []TaskInfo
+	if err := db.Select("task_id", "req_no", "deadline", "comment", "estimated_work_hours").
+		Where("principal = ? AND state <> ?", name, TASK_STATE_TEXT_FINISH).Order("deadline").Find(&tasks).Error; err != nil {
 		log.Println(err)
 		return nil, err
 	}
+	cal := currentBusinessCalendar()
+	today := dateOf(time.Now())
+	ct := make([]sendContent, len(tasks))
+	for i, t := range tasks {
+		ct[i] = sendContent{
+			taskId:            t.TaskID,
+			reqNo:             t.ReqNo,
+			deadline:          t.Deadline,
+			comment:           t.Comment,
+			estimatedWorkHour: t.EstimatedWorkHours,
+			workdaysLeft:      cal.workdaysBetweenWithin(today, dateOf(t.Deadline), maxDisplayWorkdays),
+		}
+	}
 	return ct, nil
 }
+
+func sendPasswordResetEmail(email string, code string) error {
+	msg := fmt.Sprintf("Subject: OrderManager 密码重置\r\n\r\n您的密码重置验证码为：%s，%d 分钟内有效。如非本人操作请忽略本邮件。\r\n", code, int(resetCodeTTL.Minutes()))
+	return smtp.SendMail(config.Addr, config.Auth, config.Sender, []string{email}, []byte(msg))
+}
@@ -0,0 +1,473 @@
+package main
+
+import (
+	"OrderManager/pb"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+const escalationMaxLimit = 500
+
+// maxWorkdaysLeft 的取值范围
+const maxEscalationWorkdays = 250
+
+// 定时任务与手动执行互斥
+var escalationMu sync.Mutex
+
+func validateEscalationRule(r *pb.EscalationRule) error {
+	if r == nil {
+		return errors.New("rule is required")
+	}
+	if _, err := required("name", r.Name, 50); err != nil {
+		return err
+	}
+	if r.Level < EMERGENCY_LEVEL_1 || r.Level > EMERGENCY_LEVEL_2 {
+		return fmt.Errorf("invalid level %d", r.Level)
+	}
+	if r.MaxWorkdaysLeft == nil && r.PatchLabel == "" && len(r.PatchClients) == 0 {
+		return errors.New("at least one condition is required")
+	}
+	if r.MaxWorkdaysLeft != nil && (r.GetMaxWorkdaysLeft() < -maxEscalationWorkdays || r.GetMaxWorkdaysLeft() > maxEscalationWorkdays) {
+		return fmt.Errorf("maxWorkdaysLeft must be between %d and %d", -maxEscalationWorkdays, maxEscalationWorkdays)
+	}
+	if _, err := maxLen("patchLabel", r.PatchLabel, 30); err != nil {
+		return err
+	}
+	for _, c := range r.PatchClients {
+		if c == "" || strings.Contains(c, ",") {
+			return fmt.Errorf("invalid client %q", c)
+		}
+	}
+	_, err := maxLen("patchClients", strings.Join(r.PatchClients, ","), 200)
+	return err
+}
+
+func pbEscalationRuleToInfo(r *pb.EscalationRule, operator string) *EscalationRuleInfo {
+	info := &EscalationRuleInfo{
+		ID:           uint(r.Id),
+		Name:         r.Name,
+		Level:        int(r.Level),
+		PatchClients: strings.Join(r.PatchClients, ","),
+		Enabled:      r.Enabled,
+		UpdatedBy:    operator,
+	}
+	if r.MaxWorkdaysLeft != nil {
+		n := int(r.GetMaxWorkdaysLeft())
+		info.MaxWorkdaysLeft = &n
+	}
+	return info
+}
+
+// 标签 ID -> 名称
+func labelNamesByID(tx *gorm.DB) (map[uint]string, error) {
+	var labels []LabelInfo
+	if err := tx.Select("id", "name").Find(&labels).Error; err != nil {
+		return nil, err
+	}
+	res := make(map[uint]string, len(labels))
+	for _, l := range labels {
+		res[l.ID] = l.Name
+	}
+	return res, nil
+}
+
+func escalationRuleInfoToPb(r *EscalationRuleInfo, labelNames map[uint]string) *pb.EscalationRule {
+	res := &pb.EscalationRule{
+		Id:        uint64(r.ID),
+		Name:      r.Name,
+		Level:     int32(r.Level),
+		Enabled:   r.Enabled,
+		UpdatedBy: r.UpdatedBy,
+		UpdatedAt: r.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if r.PatchLabelID != nil {
+		res.PatchLabel = labelNames[*r.PatchLabelID]
+	}
+	if r.MaxWorkdaysLeft != nil {
+		n := int32(*r.MaxWorkdaysLeft)
+		res.MaxWorkdaysLeft = &n
+	}
+	if r.PatchClients != "" {
+		res.PatchClients = strings.Split(r.PatchClients, ",")
+	}
+	return res
+}
+
+func escalationInfoToPb(e *EscalationInfo) *pb.Escalation {
+	return &pb.Escalation{
+		Id:        uint64(e.ID),
+		TaskId:    e.TaskID,
+		RuleId:    uint64(e.RuleID),
+		RuleName:  e.RuleName,
+		FromLevel: int32(e.FromLevel),
+		ToLevel:   int32(e.ToLevel),
+		CreatedAt: e.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// 标签须已存在，规则中保存标签 ID，标签改名不影响规则
+func saveEscalationRule(ctx context.Context, in *pb.EscalationRule, user string, create bool) (*pb.EscalationRule, error) {
+	if err := validateEscalationRule(in); err != nil {
+		return nil, err
+	}
+	operator := operatorName(ctx, user)
+	rule := pbEscalationRuleToInfo(in, operator)
+	var labelNames map[uint]string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := requireAdmin(tx, operator); err != nil {
+			return err
+		}
+		if in.PatchLabel != "" {
+			labels, err := findLabelsByName(tx, []string{in.PatchLabel})
+			if err != nil {
+				return err
+			}
+			rule.PatchLabelID = &labels[0].ID
+		}
+		if create {
+			rule.ID = 0
+			if err := tx.Create(rule).Error; err != nil {
+				return err
+			}
+		} else {
+			res := tx.Model(rule).Select("name", "level", "max_workdays_left", "patch_label_id", "patch_clients", "enabled", "updated_by", "updated_at").Updates(rule)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return errors.New("rule does not exist")
+			}
+			if err := tx.First(rule, rule.ID).Error; err != nil {
+				return err
+			}
+		}
+		var err error
+		labelNames, err = labelNamesByID(tx)
+		return err
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil, errors.New("rule name already exists")
+	} else if err != nil {
+		return nil, err
+	}
+	return escalationRuleInfoToPb(rule, labelNames), nil
+}
+
+func (s *server) CreateEscalationRule(ctx context.Context, in *pb.CreateEscalationRuleRequest) (*pb.CreateEscalationRuleReply, error) {
+	rule, err := saveEscalationRule(ctx, in.R, in.User, true)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreateEscalationRuleReply{R: rule}, nil
+}
+
+func (s *server) UpdateEscalationRule(ctx context.Context, in *pb.UpdateEscalationRuleRequest) (*pb.UpdateEscalationRuleReply, error) {
+	rule, err := saveEscalationRule(ctx, in.R, in.User, false)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.UpdateEscalationRuleReply{R: rule}, nil
+}
+
+// 已有的升级记录保留
+func (s *server) DeleteEscalationRule(ctx context.Context, in *pb.DeleteEscalationRuleRequest) (*pb.DeleteEscalationRuleReply, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := requireAdmin(tx, operatorName(ctx, in.User)); err != nil {
+			return err
+		}
+		res := tx.Delete(&EscalationRuleInfo{}, in.Id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errors.New("rule does not exist")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DeleteEscalationRuleReply{}, nil
+}
+
+func (s *server) ListEscalationRules(ctx context.Context, in *pb.ListEscalationRulesRequest) (*pb.ListEscalationRulesReply, error) {
+	var rules []EscalationRuleInfo
+	if err := db.Order("id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	labelNames, err := labelNamesByID(db)
+	if err != nil {
+		return nil, err
+	}
+	reply := &pb.ListEscalationRulesReply{Rules: make([]*pb.EscalationRule, len(rules))}
+	for i := range rules {
+		reply.Rules[i] = escalationRuleInfoToPb(&rules[i], labelNames)
+	}
+	return reply, nil
+}
+
+// 修改单的关联补丁（需求号相同）及其标签
+type linkedPatchs struct {
+	byReqNo    map[string][]PatchsInfo
+	labels     map[string][]string
+	labelNames map[uint]string
+}
+
+func loadLinkedPatchs(tx *gorm.DB) (*linkedPatchs, error) {
+	var patchs []PatchsInfo
+	if err := tx.Find(&patchs).Error; err != nil {
+		return nil, err
+	}
+	res := &linkedPatchs{byReqNo: make(map[string][]PatchsInfo)}
+	patchNos := make([]string, len(patchs))
+	for i, p := range patchs {
+		patchNos[i] = p.PatchNo
+		for _, reqNo := range strings.Split(p.ReqNo, ",") {
+			res.byReqNo[reqNo] = append(res.byReqNo[reqNo], p)
+		}
+	}
+	var err error
+	if res.labels, err = entityLabels(tx, AUDIT_ENTITY_PATCH, patchNos); err != nil {
+		return nil, err
+	}
+	res.labelNames, err = labelNamesByID(tx)
+	return res, err
+}
+
+// 设置了的条件都满足时返回 true；补丁的条件须由同一个关联补丁满足
+func escalationRuleMatches(rule *EscalationRuleInfo, task *TaskInfo, workdaysLeft int, linked *linkedPatchs) bool {
+	if rule.MaxWorkdaysLeft != nil && workdaysLeft > *rule.MaxWorkdaysLeft {
+		return false
+	}
+	if rule.PatchLabelID == nil && rule.PatchClients == "" {
+		return true
+	}
+	label := ""
+	if rule.PatchLabelID != nil {
+		var ok bool
+		if label, ok = linked.labelNames[*rule.PatchLabelID]; !ok {
+			return false
+		}
+	}
+	for _, p := range linked.byReqNo[task.ReqNo] {
+		if rule.PatchClients != "" && !containsString(strings.Split(rule.PatchClients, ","), p.ClientName) {
+			continue
+		}
+		if label != "" && !containsString(linked.labels[p.PatchNo], label) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// 找出需要升级的修改单，规则按 level 从高到低依次匹配，每个修改单只取第一个匹配的规则
+func planEscalations(tx *gorm.DB, rules []EscalationRuleInfo) ([]EscalationInfo, error) {
+	// 剩余工作日只需与各规则的 maxWorkdaysLeft 比较，数到绝对值最大者加一即可
+	maxLevel, needPatchs, workdayLimit := 0, false, 0
+	for _, r := range rules {
+		if r.Level > maxLevel {
+			maxLevel = r.Level
+		}
+		needPatchs = needPatchs || r.PatchLabelID != nil || r.PatchClients != ""
+		if r.MaxWorkdaysLeft != nil {
+			workdayLimit = max(workdayLimit, *r.MaxWorkdaysLeft+1, -*r.MaxWorkdaysLeft+1)
+		}
+	}
+	var tasks []TaskInfo
+	err := tx.Where("state <> ? AND emergency_level < ?", TASK_STATE_TEXT_FINISH, maxLevel).
+		Order("deadline, task_id").Find(&tasks).Error
+	if err != nil || len(tasks) == 0 {
+		return nil, err
+	}
+	ids := make([]string, len(tasks))
+	for i := range tasks {
+		ids[i] = tasks[i].TaskID
+	}
+	var done []EscalationInfo
+	if err := tx.Select("task_id", "rule_id").Where("task_id IN ?", ids).Find(&done).Error; err != nil {
+		return nil, err
+	}
+	escalated := make(map[string]bool, len(done))
+	for _, e := range done {
+		escalated[fmt.Sprintf("%s/%d", e.TaskID, e.RuleID)] = true
+	}
+	linked := &linkedPatchs{}
+	if needPatchs {
+		if linked, err = loadLinkedPatchs(tx); err != nil {
+			return nil, err
+		}
+	}
+
+	cal := currentBusinessCalendar()
+	today := dateOf(time.Now())
+	var res []EscalationInfo
+	for i := range tasks {
+		t := &tasks[i]
+		workdaysLeft := 0
+		if workdayLimit > 0 {
+			workdaysLeft = cal.workdaysBetweenWithin(today, dateOf(t.Deadline), workdayLimit)
+		}
+		for j := range rules {
+			r := &rules[j]
+			if t.EmergencyLevel >= r.Level || escalated[fmt.Sprintf("%s/%d", t.TaskID, r.ID)] {
+				continue
+			}
+			if escalationRuleMatches(r, t, workdaysLeft, linked) {
+				res = append(res, EscalationInfo{TaskID: t.TaskID, RuleID: r.ID, RuleName: r.Name, FromLevel: t.EmergencyLevel, ToLevel: r.Level})
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+// 负责人所在组的组长，不含负责人本人
+func groupLeaders(tx *gorm.DB, principal string) ([]string, error) {
+	var leaders []string
+	err := tx.Model(&UserInfo{}).
+		Where("`group` = (?) AND role_no = ? AND active = ? AND name <> ?",
+			tx.Model(&UserInfo{}).Select("`group`").Where("name = ?", principal), ROLE_LEADER, true, principal).
+		Pluck("name", &leaders).Error
+	return leaders, err
+}
+
+// 执行一次自动升级，每个升级都记入修改单的历史，提交后通知负责人及其组长
+func runEscalation(ctx context.Context, dryRun bool) ([]EscalationInfo, error) {
+	escalationMu.Lock()
+	defer escalationMu.Unlock()
+	var applied []EscalationInfo
+	var principals []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var rules []EscalationRuleInfo
+		if err := tx.Where("enabled = ?", true).Order("level desc, id").Find(&rules).Error; err != nil {
+			return err
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		planned, err := planEscalations(tx, rules)
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			applied = planned
+			return nil
+		}
+		for _, e := range planned {
+			before, err := findTask(tx.Clauses(clause.Locking{Strength: "UPDATE"}), e.TaskID)
+			if err != nil {
+				return err
+			}
+			if before == nil || before.State == TASK_STATE_TEXT_FINISH || before.EmergencyLevel >= e.ToLevel {
+				continue
+			}
+			err = tx.Model(&TaskInfo{}).Where("task_id = ? AND version = ?", e.TaskID, before.Version).
+				Updates(map[string]interface{}{"emergency_level": e.ToLevel, "version": before.Version + 1}).Error
+			if err != nil {
+				return err
+			}
+			after, err := findTask(tx, e.TaskID)
+			if err != nil {
+				return err
+			}
+			if err := recordAuditAction(ctx, tx, "system", AUDIT_ENTITY_TASK, AUDIT_ACTION_ESCALATE, before, after); err != nil {
+				return err
+			}
+			e.FromLevel = before.EmergencyLevel
+			if err := tx.Create(&e).Error; err != nil {
+				return err
+			}
+			applied = append(applied, e)
+			principals = append(principals, after.Principal)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, e := range applied {
+		if dryRun {
+			break
+		}
+		receivers := []string{principals[i]}
+		leaders, err := groupLeaders(db, principals[i])
+		if err != nil {
+			log.Println("查询组长失败：", err)
+		}
+		for _, name := range append(receivers, leaders...) {
+			msg := fmt.Sprintf("<system> -> escalate task: %s to level %d -> <%s>", e.TaskID, e.ToLevel, name)
+			NotificationServer.updateDatabaseAndNotify(msg)
+		}
+	}
+	return applied, nil
+}
+
+func (s *server) RunEscalation(ctx context.Context, in *pb.RunEscalationRequest) (*pb.RunEscalationReply, error) {
+	if err := requireAdmin(db, operatorName(ctx, in.User)); err != nil {
+		return nil, err
+	}
+	applied, err := runEscalation(ctx, in.DryRun)
+	if err != nil {
+		return nil, err
+	}
+	reply := &pb.RunEscalationReply{Escalations: make([]*pb.Escalation, len(applied))}
+	for i := range applied {
+		reply.Escalations[i] = escalationInfoToPb(&applied[i])
+	}
+	return reply, nil
+}
+
+func (s *server) ListEscalations(ctx context.Context, in *pb.ListEscalationsRequest) (*pb.ListEscalationsReply, error) {
+	query := db.Model(&EscalationInfo{})
+	if in.TaskId != "" {
+		query = query.Where("task_id = ?", in.TaskId)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+	limit := in.Limit
+	if limit <= 0 || limit > escalationMaxLimit {
+		limit = escalationMaxLimit
+	}
+	var escalations []EscalationInfo
+	if err := query.Order("id desc").Limit(int(limit)).Offset(int(in.Offset)).Find(&escalations).Error; err != nil {
+		return nil, err
+	}
+	reply := &pb.ListEscalationsReply{Escalations: make([]*pb.Escalation, len(escalations)), Total: total}
+	for i := range escalations {
+		reply.Escalations[i] = escalationInfoToPb(&escalations[i])
+	}
+	return reply, nil
+}
+
+// 每小时整点执行一次
+func escalationClock() {
+	for {
+		now := time.Now()
+		time.Sleep(now.Truncate(time.Hour).Add(time.Hour).Sub(now))
+		applied, err := runEscalation(context.Background(), false)
+		if err != nil {
+			log.Println("紧急程度自动升级失败：", err)
+		} else if len(applied) > 0 {
+			log.Printf("紧急程度自动升级：%d 个修改单", len(applied))
+		}
+	}
+}
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"OrderManager/pb"
+	"OrderManager/xlsx"
+	"encoding/csv"
+	"fmt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	EXPORT_FORMAT_XLSX = "xlsx"
+	EXPORT_FORMAT_CSV  = "csv"
+
+	EXPORT_LANG_ZH = "zh"
+	EXPORT_LANG_EN = "en"
+
+	EXPORT_SPLIT_BY_PRINCIPAL = "principal"
+	EXPORT_SPLIT_BY_PATCH     = "patch"
+)
+
+// 每个分片的大小
+const exportChunkSize = 64 << 10
+
+// 按补丁分表时，不属于任何补丁的修改单所在的工作表
+var exportNoPatchSheet = map[string]string{EXPORT_LANG_ZH: "未关联补丁", EXPORT_LANG_EN: "No Patch"}
+
+// 把写入的内容按 exportChunkSize 分片发送，第一个分片带上文件名和类型
+type chunkSender struct {
+	send        func(*pb.ExportChunk) error
+	fileName    string
+	contentType string
+	buf         []byte
+	sent        bool
+}
+
+func (c *chunkSender) Write(p []byte) (int, error) {
+	c.buf = append(c.buf, p...)
+	for len(c.buf) >= exportChunkSize {
+		if err := c.sendChunk(c.buf[:exportChunkSize]); err != nil {
+			return 0, err
+		}
+		c.buf = c.buf[exportChunkSize:]
+	}
+	return len(p), nil
+}
+
+func (c *chunkSender) sendChunk(data []byte) error {
+	chunk := &pb.ExportChunk{Data: append([]byte(nil), data...)}
+	if !c.sent {
+		chunk.FileName, chunk.ContentType = c.fileName, c.contentType
+		c.sent = true
+	}
+	return c.send(chunk)
+}
+
+// 发送剩余的内容，内容为空时也发送一个只带文件名的分片
+func (c *chunkSender) flush() error {
+	if len(c.buf) == 0 && c.sent {
+		return nil
+	}
+	err := c.sendChunk(c.buf)
+	c.buf = nil
+	return err
+}
+
+// xlsx 与 csv 共用的写入接口，csv 只有一个工作表
+type exportWriter interface {
+	addSheet(name string) error
+	writeRow(values []interface{}) error
+	close() error
+}
+
+type xlsxExportWriter struct {
+	w *xlsx.Writer
+}
+
+func (x *xlsxExportWriter) addSheet(name string) error {
+	_, err := x.w.AddSheet(name)
+	return err
+}
+
+func (x *xlsxExportWriter) writeRow(values []interface{}) error { return x.w.WriteRow(values) }
+func (x *xlsxExportWriter) close() error                        { return x.w.Close() }
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvExportWriter) addSheet(string) error { return nil }
+
+func (c *csvExportWriter) writeRow(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		switch t := v.(type) {
+		case nil:
+		case string:
+			record[i] = t
+		case float64:
+			record[i] = strconv.FormatFloat(t, 'f', -1, 64)
+		default:
+			record[i] = fmt.Sprint(t)
+		}
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvExportWriter) close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// 校验导出选项，返回写入器和底层的分片发送器；name 为不带扩展名的文件名
+func newExportWriter(opts *pb.ExportOptions, name string, send func(*pb.ExportChunk) error) (exportWriter, *chunkSender, string, error) {
+	lang := opts.Lang
+	if lang == "" {
+		lang = EXPORT_LANG_ZH
+	}
+	if lang != EXPORT_LANG_ZH && lang != EXPORT_LANG_EN {
+		return nil, nil, "", status.Errorf(codes.InvalidArgument, "unknown lang: %s", opts.Lang)
+	}
+	switch opts.Format {
+	case EXPORT_FORMAT_XLSX, "":
+		sender := &chunkSender{send: send, fileName: name + ".xlsx", contentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"}
+		return &xlsxExportWriter{w: xlsx.NewWriter(sender)}, sender, lang, nil
+	case EXPORT_FORMAT_CSV:
+		if opts.SplitBy != "" {
+			return nil, nil, "", status.Error(codes.InvalidArgument, "splitBy is only supported for xlsx")
+		}
+		sender := &chunkSender{send: send, fileName: name + ".csv", contentType: "text/csv; charset=utf-8"}
+		// 带 BOM，Excel 才能正确识别中文
+		if _, err := io.WriteString(sender, "\xef\xbb\xbf"); err != nil {
+			return nil, nil, "", err
+		}
+		return &csvExportWriter{w: csv.NewWriter(sender)}, sender, lang, nil
+	}
+	return nil, nil, "", status.Errorf(codes.InvalidArgument, "unknown format: %s", opts.Format)
+}
+
+func finishExport(w exportWriter, sender *chunkSender) error {
+	if err := w.close(); err != nil {
+		return err
+	}
+	return sender.flush()
+}
+
+// 要导出的字段，requested 为空时按 order 导出全部字段
+func exportFields[T any](columns map[string]importColumn[T], order, requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return order, nil
+	}
+	seen := make(map[string]bool, len(requested))
+	for _, field := range requested {
+		if _, ok := columns[field]; !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown column %q", field)
+		}
+		if seen[field] {
+			return nil, status.Errorf(codes.InvalidArgument, "duplicate column %q", field)
+		}
+		seen[field] = true
+	}
+	return requested, nil
+}
+
+func exportHeader[T any](columns map[string]importColumn[T], fields []string, lang string) []interface{} {
+	header := make([]interface{}, len(fields))
+	for i, field := range fields {
+		header[i] = columns[field].header
+		if lang == EXPORT_LANG_EN {
+			header[i] = columns[field].en
+		}
+	}
+	return header
+}
+
+func exportValues[T any](columns map[string]importColumn[T], fields []string, v *T) []interface{} {
+	values := make([]interface{}, len(fields))
+	for i, field := range fields {
+		values[i] = columns[field].get(v)
+	}
+	return values
+}
+
+// 新建一个工作表并写入表头和修改单
+func writeTaskSheet(w exportWriter, name string, header []interface{}, fields []string, tasks []TaskInfo) error {
+	if err := w.addSheet(name); err != nil {
+		return err
+	}
+	if err := w.writeRow(header); err != nil {
+		return err
+	}
+	for i := range tasks {
+		if err := w.writeRow(exportValues(taskImportColumns, fields, &tasks[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 可用于 field 筛选的列，与 QueryTaskWithField 一样使用列名
+func taskFilterColumn(field string) (string, error) {
+	columns, _ := auditValues(&TaskInfo{})
+	for _, c := range columns {
+		if c == field {
+			return c, nil
+		}
+	}
+	return "", status.Errorf(codes.InvalidArgument, "unknown field: %s", field)
+}
+
+func (s *server) ExportTasks(in *pb.ExportTasksRequest, stream pb.Service_ExportTasksServer) error {
+	opts := in.Options
+	if opts == nil {
+		opts = &pb.ExportOptions{}
+	}
+	fields, err := exportFields(taskImportColumns, taskColumnOrder, opts.Columns)
+	if err != nil {
+		return err
+	}
+	filter := func() (*gorm.DB, error) {
+		query := whereLabels(db, AUDIT_ENTITY_TASK, "task_id", in.Labels)
+		if in.Principal != "" {
+			query = query.Where("principal = ?", in.Principal)
+		}
+		if in.Field != "" {
+			column, err := taskFilterColumn(in.Field)
+			if err != nil {
+				return nil, err
+			}
+			query = query.Where(column+" = ?", in.FieldValue)
+		}
+		return query, nil
+	}
+	query, err := filter()
+	if err != nil {
+		return err
+	}
+	if opts.SplitBy != "" && opts.SplitBy != EXPORT_SPLIT_BY_PRINCIPAL && opts.SplitBy != EXPORT_SPLIT_BY_PATCH {
+		return status.Errorf(codes.InvalidArgument, "unknown splitBy: %s", opts.SplitBy)
+	}
+	w, sender, lang, err := newExportWriter(opts, "tasks", stream.Send)
+	if err != nil {
+		return err
+	}
+	header := exportHeader(taskImportColumns, fields, lang)
+
+	switch opts.SplitBy {
+	case EXPORT_SPLIT_BY_PATCH:
+		// 一个修改单可能属于多个补丁，会出现在每个补丁的工作表中
+		var patchs []PatchsInfo
+		if err := db.Order("patch_no").Find(&patchs).Error; err != nil {
+			return err
+		}
+		inPatch := make(map[string]bool)
+		written := false
+		for _, p := range patchs {
+			reqNos := strings.Split(p.ReqNo, ",")
+			for _, reqNo := range reqNos {
+				inPatch[reqNo] = true
+			}
+			q, _ := filter()
+			var tasks []TaskInfo
+			if err := q.Where("req_no IN ?", reqNos).Order("task_id").Find(&tasks).Error; err != nil {
+				return err
+			}
+			if len(tasks) == 0 {
+				continue
+			}
+			if err := writeTaskSheet(w, p.PatchNo, header, fields, tasks); err != nil {
+				return err
+			}
+			written = true
+		}
+		var tasks, rest []TaskInfo
+		if err := query.Order("task_id").Find(&tasks).Error; err != nil {
+			return err
+		}
+		for _, t := range tasks {
+			if !inPatch[t.ReqNo] {
+				rest = append(rest, t)
+			}
+		}
+		if len(rest) > 0 || !written {
+			if err := writeTaskSheet(w, exportNoPatchSheet[lang], header, fields, rest); err != nil {
+				return err
+			}
+		}
+	default:
+		order := "task_id"
+		if opts.SplitBy == EXPORT_SPLIT_BY_PRINCIPAL {
+			order = "principal, task_id"
+		}
+		rows, err := query.Model(&TaskInfo{}).Order(order).Rows()
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		sheet, started := "", false
+		for rows.Next() {
+			var t TaskInfo
+			if err := db.ScanRows(rows, &t); err != nil {
+				return err
+			}
+			if !started || (opts.SplitBy == EXPORT_SPLIT_BY_PRINCIPAL && t.Principal != sheet) {
+				started, sheet = true, t.Principal
+				name := ""
+				if opts.SplitBy == EXPORT_SPLIT_BY_PRINCIPAL {
+					name = sheet
+				}
+				if err := w.addSheet(name); err != nil {
+					return err
+				}
+				if err := w.writeRow(header); err != nil {
+					return err
+				}
+			}
+			if err := w.writeRow(exportValues(taskImportColumns, fields, &t)); err != nil {
+				return err
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if !started {
+			if err := w.writeRow(header); err != nil {
+				return err
+			}
+		}
+	}
+	return finishExport(w, sender)
+}
+
+func (s *server) ExportPatchs(in *pb.ExportPatchsRequest, stream pb.Service_ExportPatchsServer) error {
+	opts := in.Options
+	if opts == nil {
+		opts = &pb.ExportOptions{}
+	}
+	if opts.SplitBy != "" {
+		return status.Error(codes.InvalidArgument, "splitBy is not supported for patchs")
+	}
+	fields, err := exportFields(patchImportColumns, patchColumnOrder, opts.Columns)
+	if err != nil {
+		return err
+	}
+	query := whereLabels(db, AUDIT_ENTITY_PATCH, "patch_no", in.Labels)
+	if in.PatchNo != "" {
+		query = query.Where("patch_no = ?", in.PatchNo)
+	}
+	var patchs []PatchsInfo
+	if err := query.Order("patch_no").Find(&patchs).Error; err != nil {
+		return err
+	}
+	w, sender, lang, err := newExportWriter(opts, "patchs", stream.Send)
+	if err != nil {
+		return err
+	}
+	if err := w.writeRow(exportHeader(patchImportColumns, fields, lang)); err != nil {
+		return err
+	}
+	for i := range patchs {
+		if err := w.writeRow(exportValues(patchImportColumns, fields, &patchs[i])); err != nil {
+			return err
+		}
+	}
+	return finishExport(w, sender)
+}
+
+// 工时报表第一列的表头随分组方式变化
+var workReportKeyHeaders = map[string][2]string{
+	WORK_REPORT_BY_PRINCIPAL: {"负责人", "Principal"},
+	WORK_REPORT_BY_REQ_NO:    {"需求号", "Req No"},
+	WORK_REPORT_BY_PATCH:     {"补丁号", "Patch No"},
+}
+
+func (s *server) ExportWorkReport(in *pb.ExportWorkReportRequest, stream pb.Service_ExportWorkReportServer) error {
+	opts := in.Options
+	if opts == nil {
+		opts = &pb.ExportOptions{}
+	}
+	if len(opts.Columns) > 0 || opts.SplitBy != "" {
+		return status.Error(codes.InvalidArgument, "columns and splitBy are not supported for work reports")
+	}
+	req := in.Report
+	if req == nil {
+		req = &pb.GetWorkReportRequest{}
+	}
+	report, err := s.GetWorkReport(stream.Context(), req)
+	if err != nil {
+		return err
+	}
+	groupBy := req.GroupBy
+	if groupBy == "" {
+		groupBy = WORK_REPORT_BY_PRINCIPAL
+	}
+	w, sender, lang, err := newExportWriter(opts, "work_report_"+groupBy, stream.Send)
+	if err != nil {
+		return err
+	}
+	header := []interface{}{workReportKeyHeaders[groupBy][0], "修改单数", "预计工时", "实际工时", "差异"}
+	if lang == EXPORT_LANG_EN {
+		header = []interface{}{workReportKeyHeaders[groupBy][1], "Tasks", "Estimated Hours", "Actual Hours", "Variance"}
+	}
+	if err := w.writeRow(header); err != nil {
+		return err
+	}
+	for _, r := range report.Rows {
+		if err := w.writeRow([]interface{}{r.Key, r.TaskCount, r.EstimatedHours, r.ActualHours, r.Variance}); err != nil {
+			return err
+		}
+	}
+	return finishExport(w, sender)
+}
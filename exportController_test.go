@@ -0,0 +1,106 @@
+package main
+
+import (
+	"OrderManager/pb"
+	"bytes"
+	"context"
+	"google.golang.org/grpc"
+	"testing"
+	"time"
+)
+
+// 收集导出的分片
+type exportStream struct {
+	grpc.ServerStream
+	buf bytes.Buffer
+}
+
+func (s *exportStream) Send(chunk *pb.ExportChunk) error {
+	s.buf.Write(chunk.Data)
+	return nil
+}
+
+func testDate(t *testing.T, s string) time.Time {
+	t.Helper()
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	requireTestDB(t, &TaskInfo{}, &PatchsInfo{}, &UserInfo{})
+	users := []UserInfo{
+		{Name: "alice", Source: AUTH_SOURCE_LOCAL, Active: true},
+		{Name: "bob", Source: AUTH_SOURCE_LOCAL, Active: true},
+	}
+	tasks := []TaskInfo{
+		{TaskID: "T-1", Comment: "登录页, \"改版\"", EmergencyLevel: EMERGENCY_LEVEL_2, Deadline: testDate(t, "2030-01-07"),
+			Principal: "alice", ReqNo: "R-1", EstimatedWorkHours: 2.5, State: TASK_STATE_TEXT_ING},
+		{TaskID: "T-2", Comment: "接口联调", Deadline: testDate(t, "2030-01-08"),
+			Principal: "bob", ReqNo: "R-1", EstimatedWorkHours: 16, State: TASK_STATE_TEXT_WAIT, ParentID: "T-1"},
+		{TaskID: "T-3", Deadline: testDate(t, "2029-12-31"),
+			Principal: "bob", ReqNo: "R-2", EstimatedWorkHours: 8, State: TASK_STATE_TEXT_FINISH},
+	}
+	patchs := []PatchsInfo{
+		{PatchNo: "P-1", ReqNo: "R-1,R-2", Describe: "多行\n描述", ClientName: "客户A", Deadline: testDate(t, "2030-01-10"),
+			Reason: "紧急修复", Sponsor: "alice", State: "待发布"},
+		{PatchNo: "P-2", ReqNo: "R-2", ClientName: "客户B", Deadline: testDate(t, "2030-02-01"), Sponsor: "bob"},
+	}
+	for _, v := range []interface{}{&users, &tasks, &patchs} {
+		if err := db.Create(v).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := &server{}
+	tests := []struct {
+		name       string
+		entityType string
+		options    *pb.ExportOptions
+		allSheets  bool
+		rows       int
+	}{
+		{name: "tasks xlsx", entityType: AUDIT_ENTITY_TASK, options: &pb.ExportOptions{}, rows: len(tasks)},
+		{name: "tasks csv", entityType: AUDIT_ENTITY_TASK, options: &pb.ExportOptions{Format: EXPORT_FORMAT_CSV}, rows: len(tasks)},
+		{name: "tasks english header", entityType: AUDIT_ENTITY_TASK, options: &pb.ExportOptions{Lang: EXPORT_LANG_EN}, rows: len(tasks)},
+		{name: "tasks split by principal", entityType: AUDIT_ENTITY_TASK, options: &pb.ExportOptions{SplitBy: EXPORT_SPLIT_BY_PRINCIPAL}, allSheets: true, rows: len(tasks)},
+		{name: "patchs xlsx", entityType: AUDIT_ENTITY_PATCH, options: &pb.ExportOptions{}, rows: len(patchs)},
+		{name: "patchs csv", entityType: AUDIT_ENTITY_PATCH, options: &pb.ExportOptions{Format: EXPORT_FORMAT_CSV}, rows: len(patchs)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := &exportStream{}
+			var err error
+			if tt.entityType == AUDIT_ENTITY_TASK {
+				err = s.ExportTasks(&pb.ExportTasksRequest{Options: tt.options}, stream)
+			} else {
+				err = s.ExportPatchs(&pb.ExportPatchsRequest{Options: tt.options}, stream)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			reply, err := s.ImportXLSX(context.Background(), &pb.ImportXLSXRequest{
+				User:       "alice",
+				EntityType: tt.entityType,
+				Data:       stream.buf.Bytes(),
+				AllSheets:  tt.allSheets,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reply.Committed {
+				t.Error("dry run committed the import")
+			}
+			if len(reply.Rows) != tt.rows || int(reply.Unchanged) != tt.rows {
+				t.Errorf("rows, unchanged = %d, %d, want %d", len(reply.Rows), reply.Unchanged, tt.rows)
+			}
+			for _, r := range reply.Rows {
+				if r.Status != IMPORT_ROW_UNCHANGED {
+					t.Errorf("row %d (%s) status = %s, errors %q, changes %v", r.Row, r.Key, r.Status, r.Errors, r.Changes)
+				}
+			}
+		})
+	}
+}
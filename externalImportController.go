@@ -0,0 +1,616 @@
+package main
+
+import (
+	"OrderManager/pb"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	EXTERNAL_SOURCE_JIRA   = "jira"
+	EXTERNAL_SOURCE_GITLAB = "gitlab"
+)
+
+const (
+	EXTERNAL_REQNO_FROM_EPIC      = "epic"
+	EXTERNAL_REQNO_FROM_MILESTONE = "milestone"
+)
+
+const defaultJiraEpicField = "customfield_10014"
+
+// 各种导出格式统一转换后的问题
+type externalIssue struct {
+	row             int    // JSON 中的序号或 CSV 中的行号，从 1 开始
+	key             string // 问题编号，如 PROJ-12、group/project#12
+	shortKey        string // 用于生成任务单号
+	summary         string
+	assignee        string
+	dueDate         string
+	priority        string
+	status          string
+	epic            string
+	milestone       string
+	estimateSeconds int64
+}
+
+// Jira 的 CSV 导出使用 02/Jan/06 3:04 PM 格式的日期
+func parseExternalDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{"02/Jan/06 3:04 PM", "02/Jan/06", "2006-01-02T15:04:05.000-0700", "2006-01-02 15:04"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+		}
+	}
+	return parseCellDate("dueDate", s)
+}
+
+// 读取 CSV，返回表头（同名列可以出现多次）和数据行
+func readExternalCSV(data []byte) (map[string][]int, [][]string, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid csv: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("empty csv")
+	}
+	header := make(map[string][]int)
+	for i, h := range records[0] {
+		h = strings.TrimSpace(h)
+		header[h] = append(header[h], i)
+	}
+	return header, records[1:], nil
+}
+
+// 取第一个不为空的同名列
+func csvField(header map[string][]int, row []string, names ...string) string {
+	for _, name := range names {
+		for _, i := range header[name] {
+			if v := strings.TrimSpace(cellAt(row, i)); v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+func isJSON(data []byte) bool {
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	return len(data) > 0 && (data[0] == '{' || data[0] == '[')
+}
+
+type jiraUser struct {
+	Name         string `json:"name"`
+	EmailAddress string `json:"emailAddress"`
+	DisplayName  string `json:"displayName"`
+}
+
+type jiraIssue struct {
+	Key    string                     `json:"key"`
+	Fields map[string]json.RawMessage `json:"fields"`
+}
+
+type jiraFields struct {
+	Summary  string    `json:"summary"`
+	Assignee *jiraUser `json:"assignee"`
+	DueDate  string    `json:"duedate"`
+	Priority *struct {
+		Name string `json:"name"`
+	} `json:"priority"`
+	Status *struct {
+		Name string `json:"name"`
+	} `json:"status"`
+	Parent *struct {
+		Key    string `json:"key"`
+		Fields struct {
+			IssueType struct {
+				Name string `json:"name"`
+			} `json:"issuetype"`
+		} `json:"fields"`
+	} `json:"parent"`
+	FixVersions []struct {
+		Name string `json:"name"`
+	} `json:"fixVersions"`
+	TimeOriginalEstimate int64 `json:"timeoriginalestimate"`
+}
+
+// Jira 的 JSON 导出（搜索接口的返回 {"issues": [...]} 或问题数组）以及 CSV 导出
+func parseJiraIssues(data []byte, epicField string) ([]externalIssue, error) {
+	if epicField == "" {
+		epicField = defaultJiraEpicField
+	}
+	if !isJSON(data) {
+		header, rows, err := readExternalCSV(data)
+		if err != nil {
+			return nil, err
+		}
+		if len(header["Issue key"]) == 0 {
+			return nil, fmt.Errorf("column %q not found in jira csv", "Issue key")
+		}
+		issues := make([]externalIssue, 0, len(rows))
+		for i, row := range rows {
+			issue := externalIssue{
+				row:       i + 2,
+				key:       csvField(header, row, "Issue key"),
+				summary:   csvField(header, row, "Summary"),
+				assignee:  csvField(header, row, "Assignee"),
+				dueDate:   csvField(header, row, "Due Date", "Due date"),
+				priority:  csvField(header, row, "Priority"),
+				status:    csvField(header, row, "Status"),
+				epic:      csvField(header, row, "Custom field (Epic Link)", "Parent"),
+				milestone: csvField(header, row, "Fix Version/s", "Fix versions"),
+			}
+			issue.shortKey = issue.key
+			if v := csvField(header, row, "Original Estimate"); v != "" {
+				issue.estimateSeconds, _ = strconv.ParseInt(v, 10, 64)
+			}
+			issues = append(issues, issue)
+		}
+		return issues, nil
+	}
+
+	var list []jiraIssue
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if data[0] == '{' {
+		var search struct {
+			Issues []jiraIssue `json:"issues"`
+		}
+		if err := json.Unmarshal(data, &search); err != nil {
+			return nil, fmt.Errorf("invalid jira json: %v", err)
+		}
+		list = search.Issues
+	} else if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid jira json: %v", err)
+	}
+	issues := make([]externalIssue, 0, len(list))
+	for i, ji := range list {
+		raw, err := json.Marshal(ji.Fields)
+		if err != nil {
+			return nil, err
+		}
+		var f jiraFields
+		if err := json.Unmarshal(raw, &f); err != nil {
+			return nil, fmt.Errorf("issue %s: %v", ji.Key, err)
+		}
+		issue := externalIssue{
+			row:             i + 1,
+			key:             ji.Key,
+			shortKey:        ji.Key,
+			summary:         f.Summary,
+			dueDate:         f.DueDate,
+			estimateSeconds: f.TimeOriginalEstimate,
+		}
+		if f.Assignee != nil {
+			issue.assignee = f.Assignee.Name
+			if issue.assignee == "" {
+				issue.assignee, _, _ = strings.Cut(f.Assignee.EmailAddress, "@")
+			}
+			if issue.assignee == "" {
+				issue.assignee = f.Assignee.DisplayName
+			}
+		}
+		if f.Priority != nil {
+			issue.priority = f.Priority.Name
+		}
+		if f.Status != nil {
+			issue.status = f.Status.Name
+		}
+		// 旧版使用 Epic Link 自定义字段，新版的 Epic 为父问题
+		if v, ok := ji.Fields[epicField]; ok {
+			json.Unmarshal(v, &issue.epic)
+		}
+		if issue.epic == "" && f.Parent != nil && strings.EqualFold(f.Parent.Fields.IssueType.Name, "epic") {
+			issue.epic = f.Parent.Key
+		}
+		if len(f.FixVersions) > 0 {
+			issue.milestone = f.FixVersions[0].Name
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
+
+type gitlabIssue struct {
+	IID        int    `json:"iid"`
+	WebURL     string `json:"web_url"`
+	References struct {
+		Full string `json:"full"`
+	} `json:"references"`
+	Title     string `json:"title"`
+	State     string `json:"state"`
+	Assignees []struct {
+		Username string `json:"username"`
+	} `json:"assignees"`
+	Assignee *struct {
+		Username string `json:"username"`
+	} `json:"assignee"`
+	DueDate   string   `json:"due_date"`
+	Labels    []string `json:"labels"`
+	Milestone *struct {
+		Title string `json:"title"`
+	} `json:"milestone"`
+	Epic *struct {
+		Title string `json:"title"`
+	} `json:"epic"`
+	TimeStats struct {
+		TimeEstimate int64 `json:"time_estimate"`
+	} `json:"time_stats"`
+}
+
+// 从问题链接中取出 group/project#12 形式的编号
+func gitlabReference(webURL string) string {
+	u, err := url.Parse(webURL)
+	if err != nil {
+		return ""
+	}
+	project, iid, ok := strings.Cut(strings.Trim(u.Path, "/"), "/-/issues/")
+	if !ok || project == "" || iid == "" {
+		return ""
+	}
+	return project + "#" + iid
+}
+
+// group/project#12 的任务单号部分为 project-12
+func gitlabShortKey(ref string) string {
+	project, iid, _ := strings.Cut(ref, "#")
+	if i := strings.LastIndexByte(project, '/'); i >= 0 {
+		project = project[i+1:]
+	}
+	return project + "-" + iid
+}
+
+// GitLab 没有优先级字段，使用 priority::high 形式的标签
+func gitlabPriority(labels []string) string {
+	for _, l := range labels {
+		if name, value, ok := strings.Cut(strings.TrimSpace(l), "::"); ok && strings.EqualFold(strings.TrimSpace(name), "priority") {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// GitLab 问题接口返回的 JSON 数组以及问题列表导出的 CSV
+func parseGitlabIssues(data []byte) ([]externalIssue, error) {
+	if !isJSON(data) {
+		header, rows, err := readExternalCSV(data)
+		if err != nil {
+			return nil, err
+		}
+		if len(header["URL"]) == 0 && len(header["Issue ID"]) == 0 {
+			return nil, fmt.Errorf("column %q not found in gitlab csv", "URL")
+		}
+		issues := make([]externalIssue, 0, len(rows))
+		for i, row := range rows {
+			issue := externalIssue{
+				row:       i + 2,
+				key:       gitlabReference(csvField(header, row, "URL")),
+				summary:   csvField(header, row, "Title"),
+				assignee:  csvField(header, row, "Assignee Username"),
+				dueDate:   csvField(header, row, "Due Date"),
+				priority:  gitlabPriority(strings.Split(csvField(header, row, "Labels"), ",")),
+				status:    csvField(header, row, "State"),
+				epic:      csvField(header, row, "Epic Title"),
+				milestone: csvField(header, row, "Milestone"),
+			}
+			if issue.key == "" {
+				issue.key = csvField(header, row, "Issue ID")
+				issue.shortKey = issue.key
+			} else {
+				issue.shortKey = gitlabShortKey(issue.key)
+			}
+			// 多个经办人时只取第一个
+			issue.assignee, _, _ = strings.Cut(issue.assignee, ",")
+			issue.assignee = strings.TrimSpace(issue.assignee)
+			if v := csvField(header, row, "Time Estimate"); v != "" {
+				issue.estimateSeconds, _ = strconv.ParseInt(v, 10, 64)
+			}
+			issues = append(issues, issue)
+		}
+		return issues, nil
+	}
+
+	var list []gitlabIssue
+	if err := json.Unmarshal(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), &list); err != nil {
+		return nil, fmt.Errorf("invalid gitlab json: %v", err)
+	}
+	issues := make([]externalIssue, 0, len(list))
+	for i, gi := range list {
+		issue := externalIssue{
+			row:             i + 1,
+			key:             gi.References.Full,
+			summary:         gi.Title,
+			dueDate:         gi.DueDate,
+			priority:        gitlabPriority(gi.Labels),
+			status:          gi.State,
+			estimateSeconds: gi.TimeStats.TimeEstimate,
+		}
+		if issue.key == "" {
+			issue.key = gitlabReference(gi.WebURL)
+		}
+		issue.shortKey = gitlabShortKey(issue.key)
+		if len(gi.Assignees) > 0 {
+			issue.assignee = gi.Assignees[0].Username
+		} else if gi.Assignee != nil {
+			issue.assignee = gi.Assignee.Username
+		}
+		if gi.Milestone != nil {
+			issue.milestone = gi.Milestone.Title
+		}
+		if gi.Epic != nil {
+			issue.epic = gi.Epic.Title
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
+
+func lowerKeys[V any](m map[string]V) map[string]V {
+	res := make(map[string]V, len(m))
+	for k, v := range m {
+		res[strings.ToLower(strings.TrimSpace(k))] = v
+	}
+	return res
+}
+
+// 映射规则，未配置的优先级和状态使用默认对应关系
+type externalRules struct {
+	mapping    *pb.ExternalMapping
+	assignees  map[string]string
+	priorities map[string]int32
+	states     map[string]string
+	reqNos     map[string]string
+}
+
+func newExternalRules(m *pb.ExternalMapping) (*externalRules, error) {
+	if m == nil {
+		m = &pb.ExternalMapping{}
+	}
+	switch m.ReqNoFrom {
+	case "":
+		m.ReqNoFrom = EXTERNAL_REQNO_FROM_EPIC
+	case EXTERNAL_REQNO_FROM_EPIC, EXTERNAL_REQNO_FROM_MILESTONE:
+	default:
+		return nil, fmt.Errorf("invalid reqNoFrom %q", m.ReqNoFrom)
+	}
+	for k, v := range m.Priorities {
+		if v < EMERGENCY_LEVEL_0 || v > EMERGENCY_LEVEL_2 {
+			return nil, fmt.Errorf("invalid emergencyLevel %d for priority %q", v, k)
+		}
+	}
+	for k, v := range m.States {
+		switch v {
+		case TASK_STATE_TEXT_WAIT, TASK_STATE_TEXT_ING, TASK_STATE_TEXT_FINISH, TASK_STATE_TEXT_BLOCKED:
+		default:
+			return nil, fmt.Errorf("invalid task state %q for status %q", v, k)
+		}
+	}
+	return &externalRules{
+		mapping:    m,
+		assignees:  lowerKeys(m.Assignees),
+		priorities: lowerKeys(m.Priorities),
+		states:     lowerKeys(m.States),
+		reqNos:     lowerKeys(m.ReqNos),
+	}, nil
+}
+
+func (r *externalRules) emergencyLevel(priority string) int {
+	p := strings.ToLower(priority)
+	if v, ok := r.priorities[p]; ok {
+		return int(v)
+	}
+	switch p {
+	case "highest", "blocker", "critical":
+		return EMERGENCY_LEVEL_2
+	case "high":
+		return EMERGENCY_LEVEL_1
+	}
+	return EMERGENCY_LEVEL_0
+}
+
+func (r *externalRules) state(status string) string {
+	s := strings.ToLower(status)
+	if v, ok := r.states[s]; ok {
+		return v
+	}
+	switch s {
+	case "closed", "done", "resolved":
+		return TASK_STATE_TEXT_FINISH
+	case "in progress":
+		return TASK_STATE_TEXT_ING
+	}
+	return TASK_STATE_TEXT_WAIT
+}
+
+func (r *externalRules) reqNo(issue *externalIssue) string {
+	name := issue.epic
+	if r.mapping.ReqNoFrom == EXTERNAL_REQNO_FROM_MILESTONE {
+		name = issue.milestone
+	}
+	if name == "" {
+		return ""
+	}
+	if v, ok := r.reqNos[strings.ToLower(name)]; ok {
+		return v
+	}
+	return name
+}
+
+func (r *externalRules) principal(assignee string) string {
+	if v, ok := r.assignees[strings.ToLower(assignee)]; ok {
+		return v
+	}
+	return assignee
+}
+
+// 把问题的内容写入修改单，只修改导出文件中不为空的字段
+func (r *externalRules) apply(issue *externalIssue, taskID string, t *TaskInfo, res *pb.ImportRowResult) {
+	isNew := t.TaskID == ""
+	t.TaskID = taskID
+	if isNew && r.mapping.TypeId != 0 {
+		t.Type = int(r.mapping.TypeId)
+	}
+	if issue.summary != "" {
+		t.Comment = issue.summary
+		if utf8.RuneCountInString(t.Comment) > 100 {
+			t.Comment = string([]rune(t.Comment)[:100])
+			res.Warnings = append(res.Warnings, "summary is truncated to 100 characters")
+		}
+	}
+	if issue.assignee != "" {
+		t.Principal = r.principal(issue.assignee)
+	} else if t.Principal == "" {
+		t.Principal = r.mapping.DefaultPrincipal
+	}
+	if len(t.Principal) > 20 {
+		res.Errors = append(res.Errors, fmt.Sprintf("principal %q exceeds 20 characters, map it in assignees", t.Principal))
+	}
+	if issue.dueDate != "" {
+		deadline, err := parseExternalDate(issue.dueDate)
+		if err != nil {
+			res.Errors = append(res.Errors, err.Error())
+		} else {
+			t.Deadline = deadline
+		}
+	}
+	if issue.priority != "" {
+		t.EmergencyLevel = r.emergencyLevel(issue.priority)
+	}
+	if issue.status != "" {
+		t.State = r.state(issue.status)
+	}
+	if reqNo := r.reqNo(issue); reqNo != "" {
+		t.ReqNo = reqNo
+	} else if t.ReqNo == "" {
+		t.ReqNo = r.mapping.DefaultReqNo
+	}
+	if len(t.ReqNo) > 20 {
+		res.Errors = append(res.Errors, fmt.Sprintf("reqNo %q exceeds 20 characters, map it in reqNos", t.ReqNo))
+	}
+	if issue.estimateSeconds > 0 {
+		t.EstimatedWorkHours = float64(issue.estimateSeconds) / 3600
+	}
+}
+
+// 之前导入过的问题对应的任务单号
+func externalTaskIDs(tx *gorm.DB, source string, issues []externalIssue) (map[string]string, error) {
+	keys := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		if issue.key != "" {
+			keys = append(keys, issue.key)
+		}
+	}
+	var links []ExternalIssueInfo
+	if len(keys) > 0 {
+		if err := tx.Where("source = ? AND external_key IN ?", source, keys).Find(&links).Error; err != nil {
+			return nil, err
+		}
+	}
+	res := make(map[string]string, len(links))
+	for _, l := range links {
+		res[l.ExternalKey] = l.TaskID
+	}
+	return res, nil
+}
+
+func saveExternalLinks(tx *gorm.DB, source string, rows []*pb.ImportRowResult) error {
+	now := time.Now()
+	var links []ExternalIssueInfo
+	for _, r := range rows {
+		if r.ExternalKey != "" && r.Status != IMPORT_ROW_ERROR {
+			links = append(links, ExternalIssueInfo{Source: source, ExternalKey: r.ExternalKey, TaskID: r.Key, SyncedAt: now})
+		}
+	}
+	if len(links) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "source"}, {Name: "external_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"task_id", "synced_at"}),
+	}).Create(&links).Error
+}
+
+// 导入 Jira/GitLab 导出的问题，校验、试运行和导入批次与 ImportXLSX 相同
+func (s *server) ImportExternalIssues(ctx context.Context, in *pb.ImportExternalIssuesRequest) (*pb.ImportXLSXReply, error) {
+	if len(in.Data) > maxImportFileSize {
+		return nil, fmt.Errorf("file exceeds %d bytes", maxImportFileSize)
+	}
+	rules, err := newExternalRules(in.Mapping)
+	if err != nil {
+		return nil, err
+	}
+	var issues []externalIssue
+	switch in.Source {
+	case EXTERNAL_SOURCE_JIRA:
+		issues, err = parseJiraIssues(in.Data, rules.mapping.EpicField)
+	case EXTERNAL_SOURCE_GITLAB:
+		issues, err = parseGitlabIssues(in.Data)
+	default:
+		return nil, fmt.Errorf("unknown source: %s", in.Source)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var principals []string
+	reply, err := runImport(ctx, in.User, AUDIT_ENTITY_TASK, in.Commit, in.IdempotencyKey,
+		func(tx *gorm.DB) ([]*pb.ImportRowResult, int, func(string) error, error) {
+			linked, err := externalTaskIDs(tx, in.Source, issues)
+			if err != nil {
+				return nil, 0, nil, err
+			}
+			items := make([]taskImportItem, len(issues))
+			for i := range issues {
+				issue := &issues[i]
+				taskID := linked[issue.key]
+				if taskID == "" && issue.key != "" {
+					taskID = rules.mapping.TaskIdPrefix + issue.shortKey
+				}
+				items[i] = taskImportItem{
+					row:         issue.row,
+					key:         taskID,
+					externalKey: issue.key,
+					levelSet:    issue.priority != "",
+					apply: func(t *TaskInfo, res *pb.ImportRowResult) {
+						rules.apply(issue, taskID, t, res)
+					},
+				}
+				if len(taskID) > 25 {
+					items[i].apply = func(t *TaskInfo, res *pb.ImportRowResult) {
+						res.Errors = append(res.Errors, fmt.Sprintf("taskId %q exceeds 25 characters", taskID))
+					}
+				}
+			}
+			results, tasks, err := validateTaskRows(tx, items)
+			if err != nil {
+				return nil, 0, nil, err
+			}
+			return results, len(tasks), func(batchID string) error {
+				for _, t := range tasks {
+					principals = append(principals, t.Principal)
+				}
+				if err := importTasks(ctx, tx, in.User, tasks, batchID); err != nil {
+					return err
+				}
+				return saveExternalLinks(tx, in.Source, results)
+			}, nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	if reply.Committed && !reply.Replayed {
+		notifyImportedTasks(in.User, reply, principals)
+	}
+	return reply, nil
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"OrderManager/pb"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseExternalFixtures(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		parse   func(data []byte) ([]externalIssue, error)
+		want    []externalIssue
+	}{
+		{
+			name:    "jira json",
+			fixture: "jira_search.json",
+			parse:   func(data []byte) ([]externalIssue, error) { return parseJiraIssues(data, "") },
+			want: []externalIssue{
+				{row: 1, key: "PROJ-12", shortKey: "PROJ-12", summary: "Fix login timeout", assignee: "jsmith", dueDate: "2024-03-15",
+					priority: "High", status: "In Progress", epic: "PROJ-1", milestone: "v2.1", estimateSeconds: 28800},
+				{row: 2, key: "PROJ-13", shortKey: "PROJ-13", summary: "Export report to xlsx", assignee: "mli",
+					priority: "Highest", status: "Done", epic: "PROJ-2"},
+				{row: 3, key: "PROJ-14", shortKey: "PROJ-14", summary: "Write release notes", status: "To Do"},
+			},
+		},
+		{
+			name:    "jira csv",
+			fixture: "jira_issues.csv",
+			parse:   func(data []byte) ([]externalIssue, error) { return parseJiraIssues(data, "") },
+			want: []externalIssue{
+				{row: 2, key: "PROJ-12", shortKey: "PROJ-12", summary: "Fix login timeout", assignee: "jsmith", dueDate: "15/Mar/24 12:00 AM",
+					priority: "High", status: "In Progress", epic: "PROJ-1", milestone: "v2.1", estimateSeconds: 28800},
+				{row: 3, key: "PROJ-13", shortKey: "PROJ-13", summary: `Export, with "quotes"`, assignee: "mli",
+					priority: "Highest", status: "Done", milestone: "v2.2"},
+				{row: 4, key: "PROJ-14", shortKey: "PROJ-14", summary: "Write release notes", dueDate: "20/Mar/24",
+					priority: "Medium", status: "To Do"},
+			},
+		},
+		{
+			name:    "gitlab json",
+			fixture: "gitlab_issues.json",
+			parse:   parseGitlabIssues,
+			want: []externalIssue{
+				{row: 1, key: "uf/order-manager#6", shortKey: "order-manager-6", summary: "Fix login timeout", assignee: "jsmith", dueDate: "2024-03-15",
+					priority: "high", status: "opened", epic: "Authentication", milestone: "v2.1", estimateSeconds: 14400},
+				{row: 2, key: "uf/order-manager#7", shortKey: "order-manager-7", summary: "Export report to xlsx", assignee: "mli", status: "closed"},
+			},
+		},
+		{
+			name:    "gitlab csv",
+			fixture: "gitlab_issues.csv",
+			parse:   parseGitlabIssues,
+			want: []externalIssue{
+				{row: 2, key: "uf/order-manager#6", shortKey: "order-manager-6", summary: "Fix login timeout", assignee: "jsmith", dueDate: "2024-03-15",
+					priority: "high", status: "Open", epic: "Authentication", milestone: "v2.1", estimateSeconds: 14400},
+				{row: 3, key: "uf/order-manager#7", shortKey: "order-manager-7", summary: "Export report to xlsx", assignee: "mli", status: "Closed"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.parse(readFixture(t, tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("issues =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseExternalInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		data    string
+		wantErr string
+	}{
+		{name: "jira csv without issue key", source: EXTERNAL_SOURCE_JIRA, data: "Summary,Status\nx,Done\n", wantErr: "Issue key"},
+		{name: "jira broken json", source: EXTERNAL_SOURCE_JIRA, data: `{"issues": [`, wantErr: "invalid jira json"},
+		{name: "gitlab csv without url", source: EXTERNAL_SOURCE_GITLAB, data: "Title,State\nx,Open\n", wantErr: "URL"},
+		{name: "gitlab search object", source: EXTERNAL_SOURCE_GITLAB, data: `{"issues": []}`, wantErr: "invalid gitlab json"},
+		{name: "empty csv", source: EXTERNAL_SOURCE_GITLAB, data: "\xef\xbb\xbf", wantErr: "empty csv"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.source == EXTERNAL_SOURCE_JIRA {
+				_, err = parseJiraIssues([]byte(tt.data), "")
+			} else {
+				_, err = parseGitlabIssues([]byte(tt.data))
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewExternalRulesRejectsInvalidMapping(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping *pb.ExternalMapping
+		wantErr string
+	}{
+		{name: "nil mapping"},
+		{name: "known states", mapping: &pb.ExternalMapping{States: map[string]string{"Review": TASK_STATE_TEXT_ING, "Won't Do": TASK_STATE_TEXT_FINISH}}},
+		{name: "unknown reqNoFrom", mapping: &pb.ExternalMapping{ReqNoFrom: "sprint"}, wantErr: "invalid reqNoFrom"},
+		{name: "emergency level out of range", mapping: &pb.ExternalMapping{Priorities: map[string]int32{"urgent": 3}}, wantErr: "invalid emergencyLevel"},
+		{name: "unknown state", mapping: &pb.ExternalMapping{States: map[string]string{"Done": "closed"}}, wantErr: "invalid task state"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newExternalRules(tt.mapping)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("newExternalRules error = %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("newExternalRules error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExternalRulesApply(t *testing.T) {
+	jira, err := parseJiraIssues(readFixture(t, "jira_search.json"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gitlab, err := parseGitlabIssues(readFixture(t, "gitlab_issues.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		mapping  *pb.ExternalMapping
+		issue    externalIssue
+		existing TaskInfo
+		want     TaskInfo
+	}{
+		{
+			name:    "default rules",
+			mapping: &pb.ExternalMapping{},
+			issue:   jira[0],
+			want: TaskInfo{TaskID: "J-PROJ-12", Comment: "Fix login timeout", Principal: "jsmith", ReqNo: "PROJ-1",
+				Deadline: testDate(t, "2024-03-15"), EmergencyLevel: EMERGENCY_LEVEL_1, State: TASK_STATE_TEXT_ING, EstimatedWorkHours: 8},
+		},
+		{
+			name: "configured mapping",
+			mapping: &pb.ExternalMapping{
+				Assignees:  map[string]string{"MLI": "李梅"},
+				Priorities: map[string]int32{"highest": EMERGENCY_LEVEL_1},
+				States:     map[string]string{"done": TASK_STATE_TEXT_ING},
+				ReqNos:     map[string]string{"proj-2": "R-200"},
+				TypeId:     2,
+			},
+			issue: jira[1],
+			want: TaskInfo{TaskID: "J-PROJ-13", Comment: "Export report to xlsx", Principal: "李梅", ReqNo: "R-200",
+				EmergencyLevel: EMERGENCY_LEVEL_1, State: TASK_STATE_TEXT_ING, Type: 2},
+		},
+		{
+			name:    "defaults for missing assignee and epic",
+			mapping: &pb.ExternalMapping{DefaultPrincipal: "owner", DefaultReqNo: "R-0"},
+			issue:   jira[2],
+			want:    TaskInfo{TaskID: "J-PROJ-14", Comment: "Write release notes", Principal: "owner", ReqNo: "R-0", State: TASK_STATE_TEXT_WAIT},
+		},
+		{
+			name:     "empty fields keep the existing values",
+			mapping:  &pb.ExternalMapping{ReqNoFrom: EXTERNAL_REQNO_FROM_MILESTONE, TypeId: 2},
+			issue:    gitlab[1],
+			existing: TaskInfo{TaskID: "G-7", Principal: "old", ReqNo: "R-7", EmergencyLevel: EMERGENCY_LEVEL_2, EstimatedWorkHours: 4, Type: 1},
+			want: TaskInfo{TaskID: "G-7", Comment: "Export report to xlsx", Principal: "mli", ReqNo: "R-7",
+				EmergencyLevel: EMERGENCY_LEVEL_2, State: TASK_STATE_TEXT_FINISH, EstimatedWorkHours: 4, Type: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := newExternalRules(tt.mapping)
+			if err != nil {
+				t.Fatal(err)
+			}
+			task, res := tt.existing, &pb.ImportRowResult{}
+			taskID := task.TaskID
+			if taskID == "" {
+				taskID = "J-" + tt.issue.shortKey
+			}
+			rules.apply(&tt.issue, taskID, &task, res)
+			if len(res.Errors) > 0 {
+				t.Fatalf("apply errors = %q", res.Errors)
+			}
+			if !reflect.DeepEqual(task, tt.want) {
+				t.Errorf("task =\n%+v\nwant\n%+v", task, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"OrderManager/common"
+	"OrderManager/pb"
+	"fmt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+	"unicode/utf8"
+)
+
+// 字段掩码中的一个字段：对应的列名，以及校验并取值的函数
+type maskField[T any] struct {
+	column string
+	value  func(T) (interface{}, error)
+}
+
+func maxLen(field, value string, n int) (interface{}, error) {
+	if utf8.RuneCountInString(value) > n {
+		return nil, fmt.Errorf("%s exceeds %d characters", field, n)
+	}
+	return value, nil
+}
+
+func required(field, value string, n int) (interface{}, error) {
+	if value == "" {
+		return nil, fmt.Errorf("%s is required", field)
+	}
+	return maxLen(field, value, n)
+}
+
+func parseDate(field, value string) (interface{}, error) {
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q", field, value)
+	}
+	return t, nil
+}
+
+func taskEstimatedHours(t *pb.Task) (interface{}, error) {
+	hours := common.PbTaskEstimatedHours(t)
+	if hours < 0 {
+		return nil, fmt.Errorf("invalid estimatedHours %v", hours)
+	}
+	return hours, nil
+}
+
+var taskMaskFields = map[string]maskField[*pb.Task]{
+	"comment": {"comment", func(t *pb.Task) (interface{}, error) { return maxLen("comment", t.Comment, 100) }},
+	"emergencyLevel": {"emergency_level", func(t *pb.Task) (interface{}, error) {
+		level := t.GetEmergencyLevel()
+		if level < EMERGENCY_LEVEL_0 || level > EMERGENCY_LEVEL_2 {
+			return nil, fmt.Errorf("invalid emergencyLevel %d", level)
+		}
+		return level, nil
+	}},
+	"deadline":           {"deadline", func(t *pb.Task) (interface{}, error) { return parseDate("deadline", t.Deadline) }},
+	"principal":          {"principal", func(t *pb.Task) (interface{}, error) { return required("principal", t.Principal, 20) }},
+	"reqNo":              {"req_no", func(t *pb.Task) (interface{}, error) { return required("reqNo", t.ReqNo, 20) }},
+	"estimatedWorkHours": {"estimated_work_hours", taskEstimatedHours},
+	"estimatedHours":     {"estimated_work_hours", taskEstimatedHours},
+	"state":              {"state", func(t *pb.Task) (interface{}, error) { return maxLen("state", t.State, 20) }},
+	"typeId":             {"type", func(t *pb.Task) (interface{}, error) { return t.TypeId, nil }},
+}
+
+// deadline 需要同步到修改单，由 ModPatch 单独处理
+var patchMaskFields = map[string]maskField[*pb.Patch]{
+	"reqNo":      {"req_no", func(p *pb.Patch) (interface{}, error) { return required("reqNo", p.ReqNo, 40) }},
+	"describe":   {"describe", func(p *pb.Patch) (interface{}, error) { return p.Describe, nil }},
+	"clientName": {"client_name", func(p *pb.Patch) (interface{}, error) { return required("clientName", p.ClientName, 20) }},
+	"deadline":   {"deadline", func(p *pb.Patch) (interface{}, error) { return parseDate("deadline", p.Deadline) }},
+	"reason":     {"reason", func(p *pb.Patch) (interface{}, error) { return maxLen("reason", p.Reason, 100) }},
+	"sponsor":    {"sponsor", func(p *pb.Patch) (interface{}, error) { return required("sponsor", p.Sponsor, 20) }},
+	"state":      {"state", func(p *pb.Patch) (interface{}, error) { return maxLen("state", p.State, 10) }},
+}
+
+// 校验掩码中列出的字段，返回 列名 -> 新值，未知字段或校验失败返回 INVALID_ARGUMENT
+func maskUpdates[T any](fields map[string]maskField[T], msg T, paths []string) (map[string]interface{}, error) {
+	updates := make(map[string]interface{}, len(paths))
+	for _, path := range paths {
+		f, ok := fields[path]
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "field %q cannot be updated", path)
+		}
+		value, err := f.value(msg)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		updates[f.column] = value
+	}
+	return updates, nil
+}
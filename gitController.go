@@ -0,0 +1,273 @@
+package main
+
+import (
+	"OrderManager/config"
+	"OrderManager/gitwebhook"
+	"OrderManager/pb"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"io"
+	"log"
+	"net/http"
+)
+
+const (
+	TASK_COMMIT_KIND_COMMIT = gitwebhook.KIND_COMMIT
+	TASK_COMMIT_KIND_MR     = gitwebhook.KIND_MR
+)
+
+const (
+	MR_STATE_OPENED = gitwebhook.MR_STATE_OPENED
+	MR_STATE_MERGED = gitwebhook.MR_STATE_MERGED
+	MR_STATE_CLOSED = gitwebhook.MR_STATE_CLOSED
+)
+
+// Webhook 请求体大小上限
+const maxWebhookBodySize = 5 << 20
+
+type gitRef = gitwebhook.Ref
+type gitEvent = gitwebhook.Event
+
+// 文字中出现的、存在于修改单表中的任务单号
+func mentionedTasks(tx *gorm.DB, refs []gitRef) (map[string]bool, error) {
+	var candidates []string
+	seen := make(map[string]bool)
+	for _, r := range refs {
+		for _, id := range gitwebhook.TaskIDCandidates(r.Text) {
+			if !seen[id] {
+				seen[id] = true
+				candidates = append(candidates, id)
+			}
+		}
+	}
+	existing := make(map[string]bool)
+	if len(candidates) == 0 {
+		return existing, nil
+	}
+	var ids []string
+	if err := tx.Model(&TaskInfo{}).Where("task_id IN ?", candidates).Pluck("task_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		existing[id] = true
+	}
+	return existing, nil
+}
+
+// 合并请求合并后修改单改为配置的状态，已完成的修改单不变
+func moveMergedTask(ctx context.Context, tx *gorm.DB, operator, taskID string) (*TaskInfo, error) {
+	state := config.GIT_MR_MERGED_STATE
+	before, err := findTask(tx.Clauses(clause.Locking{Strength: "UPDATE"}), taskID)
+	if err != nil || before == nil {
+		return nil, err
+	}
+	if before.State == state || before.State == TASK_STATE_TEXT_FINISH {
+		return nil, nil
+	}
+	err = tx.Model(&TaskInfo{}).Where("task_id = ? AND version = ?", taskID, before.Version).
+		Updates(map[string]interface{}{"state": state, "version": before.Version + 1}).Error
+	if err != nil {
+		return nil, err
+	}
+	after, err := findTask(tx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordAudit(ctx, tx, operator, AUDIT_ENTITY_TASK, before, after); err != nil {
+		return nil, err
+	}
+	dependents, err := dependentsOf(tx, []string{taskID})
+	if err != nil {
+		return nil, err
+	}
+	if err := refreshBlocked(ctx, tx, operator, append([]string{taskID}, dependents...)); err != nil {
+		return nil, err
+	}
+	return findTask(tx, taskID)
+}
+
+type gitLinkResult struct {
+	linked []TaskCommitInfo
+	added  map[string]int // 新关联的条数，按任务单号
+	moved  []*TaskInfo
+}
+
+// 关联提交或合并请求与其中提到的修改单，重复上报时更新已有的关联
+func linkGitRefs(ctx context.Context, tx *gorm.DB, event *gitEvent) (*gitLinkResult, error) {
+	res := &gitLinkResult{added: make(map[string]int)}
+	existing, err := mentionedTasks(tx, event.Refs)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range event.Refs {
+		for _, taskID := range gitwebhook.MatchTaskIDs(r.Text, existing) {
+			var tc TaskCommitInfo
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("task_id = ? AND kind = ? AND repo = ? AND ref = ?", taskID, r.Kind, event.Repo, r.Ref).
+				First(&tc).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, err
+			}
+			isNew := err != nil
+			tc.TaskID, tc.Kind, tc.Repo, tc.Ref = taskID, r.Kind, event.Repo, r.Ref
+			tc.Title = gitwebhook.TruncateRunes(r.Title, 200)
+			tc.URL = gitwebhook.TruncateRunes(r.URL, 500)
+			tc.Author = gitwebhook.TruncateRunes(r.Author, 100)
+			tc.CommittedAt = r.At
+			prevState := tc.State
+			tc.State = r.State
+			if err := tx.Save(&tc).Error; err != nil {
+				return nil, err
+			}
+			if isNew {
+				res.added[taskID]++
+			}
+			res.linked = append(res.linked, tc)
+
+			if r.Kind == TASK_COMMIT_KIND_MR && r.State == MR_STATE_MERGED && prevState != MR_STATE_MERGED && config.GIT_MR_MERGED_STATE != "" {
+				after, err := moveMergedTask(ctx, tx, event.Operator, taskID)
+				if err != nil {
+					return nil, err
+				}
+				if after != nil {
+					res.moved = append(res.moved, after)
+				}
+			}
+		}
+	}
+	return res, nil
+}
+
+func notifyGitLinks(operator string, res *gitLinkResult) {
+	for _, t := range res.moved {
+		msg := fmt.Sprintf("<%s> -> modifiy task: %s -> <%s>", operator, t.TaskID, t.Principal)
+		NotificationServer.updateDatabaseAndNotify(msg)
+	}
+	for taskID, n := range res.added {
+		task, err := findTask(db, taskID)
+		if err != nil || task == nil {
+			continue
+		}
+		msg := fmt.Sprintf("<%s> -> link commits to task %s counts: %d -> <%s>", operator, taskID, n, task.Principal)
+		NotificationServer.updateDatabaseAndNotify(msg)
+	}
+}
+
+func taskCommitInfoToPbTaskCommit(c *TaskCommitInfo) *pb.TaskCommit {
+	return &pb.TaskCommit{
+		Id:          uint64(c.ID),
+		TaskId:      c.TaskID,
+		Kind:        c.Kind,
+		Repo:        c.Repo,
+		Ref:         c.Ref,
+		Title:       c.Title,
+		Url:         c.URL,
+		Author:      c.Author,
+		State:       c.State,
+		CommittedAt: c.CommittedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// 本地 git 钩子上报的提交
+func (s *server) LinkCommits(ctx context.Context, in *pb.LinkCommitsRequest) (*pb.LinkCommitsReply, error) {
+	if in.Repo == "" {
+		return nil, errors.New("repo is required")
+	}
+	event := &gitEvent{Repo: gitwebhook.TruncateRunes(in.Repo, 200), Operator: operatorName(ctx, in.User)}
+	for _, c := range in.Commits {
+		if c.Sha == "" || len(c.Sha) > 64 {
+			return nil, fmt.Errorf("invalid commit sha %q", c.Sha)
+		}
+		event.Refs = append(event.Refs, gitRef{
+			Kind:   TASK_COMMIT_KIND_COMMIT,
+			Ref:    c.Sha,
+			Title:  gitwebhook.CommitTitle(c.Message),
+			Text:   c.Message,
+			URL:    c.Url,
+			Author: c.Author,
+			At:     gitwebhook.ParseTime(c.CommittedAt),
+		})
+	}
+	var res *gitLinkResult
+	err := db.Transaction(func(tx *gorm.DB) (err error) {
+		res, err = linkGitRefs(ctx, tx, event)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	notifyGitLinks(event.Operator, res)
+	reply := &pb.LinkCommitsReply{Linked: make([]*pb.TaskCommit, len(res.linked))}
+	for i := range res.linked {
+		reply.Linked[i] = taskCommitInfoToPbTaskCommit(&res.linked[i])
+	}
+	return reply, nil
+}
+
+func (s *server) ListTaskCommits(ctx context.Context, in *pb.ListTaskCommitsRequest) (*pb.ListTaskCommitsReply, error) {
+	var commits []TaskCommitInfo
+	if err := db.Where("task_id = ?", in.TaskId).Order("committed_at, id").Find(&commits).Error; err != nil {
+		return nil, err
+	}
+	reply := &pb.ListTaskCommitsReply{Commits: make([]*pb.TaskCommit, len(commits))}
+	for i := range commits {
+		reply.Commits[i] = taskCommitInfoToPbTaskCommit(&commits[i])
+	}
+	return reply, nil
+}
+
+// POST /webhook/git
+func gitWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+	if err != nil {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	event, err := gitwebhook.Parse(r.Header, body, config.GIT_WEBHOOK_SECRET)
+	if errors.Is(err, gitwebhook.ErrBadSecret) {
+		http.Error(w, "invalid secret", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if event == nil {
+		// 不关心的事件（如 ping）
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	var res *gitLinkResult
+	err = db.Transaction(func(tx *gorm.DB) (err error) {
+		res, err = linkGitRefs(r.Context(), tx, event)
+		return err
+	})
+	if err != nil {
+		log.Println("git webhook:", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	notifyGitLinks(event.Operator, res)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"linked": len(res.linked), "moved": len(res.moved)})
+}
+
+func gitWebhookServe() {
+	if config.GIT_WEBHOOK_ADDR == "" || config.GIT_WEBHOOK_SECRET == "" {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/webhook/git", gitWebhookHandler)
+	log.Println("代码仓库 Webhook 服务监听：", config.GIT_WEBHOOK_ADDR)
+	if err := http.ListenAndServe(config.GIT_WEBHOOK_ADDR, mux); err != nil {
+		log.Println("代码仓库 Webhook 服务退出：", err)
+	}
+}
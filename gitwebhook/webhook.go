@@ -0,0 +1,344 @@
+// Package gitwebhook 解析 GitLab、GitHub、Gitea 的 Webhook 请求，并从提交信息、合并请求中找出任务单号。
+package gitwebhook
+
+import (
+	"OrderManager/config"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	KIND_COMMIT = "commit"
+	KIND_MR     = "mr"
+)
+
+const (
+	MR_STATE_OPENED = "opened"
+	MR_STATE_MERGED = "merged"
+	MR_STATE_CLOSED = "closed"
+)
+
+// 密钥或签名不正确
+var ErrBadSecret = errors.New("invalid secret")
+
+var taskIDPattern = regexp.MustCompile(config.GIT_TASK_ID_PATTERN)
+
+// 一条待关联的提交或合并请求，Text 为从中查找任务单号的文字
+type Ref struct {
+	Kind   string
+	Ref    string
+	Title  string
+	Text   string
+	URL    string
+	Author string
+	State  string
+	At     time.Time
+}
+
+// 同一次推送中的提交或同一个合并请求事件
+type Event struct {
+	Repo     string
+	Operator string
+	Refs     []Ref
+}
+
+func TruncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// 提交信息的首行
+func CommitTitle(message string) string {
+	title, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	return strings.TrimSpace(title)
+}
+
+// 支持 RFC 3339 以及 GitLab 旧版的 2006-01-02 15:04:05 UTC，无法解析时为当前时间
+func ParseTime(s string) time.Time {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05 MST", "2006-01-02 15:04:05 -0700"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Now()
+}
+
+// 匹配到的词中以 - 分隔的各段连续组合，如分支名 T-100-login 中的 T-100
+func wordSpans(word string, fn func(i, j int, id string) bool) {
+	parts := strings.Split(word, "-")
+	if len(parts) > 8 {
+		parts = parts[:8]
+	}
+	// 从长到短，fn 返回 true 时停止
+	for n := len(parts); n > 0; n-- {
+		for i := 0; i+n <= len(parts); i++ {
+			id := strings.Join(parts[i:i+n], "-")
+			if id != "" && len(id) <= 25 && strings.ContainsAny(id, "0123456789") && fn(i, i+n, id) {
+				return
+			}
+		}
+	}
+}
+
+// 文字中可能是任务单号的片段
+func TaskIDCandidates(text string) []string {
+	var res []string
+	seen := make(map[string]bool)
+	for _, word := range taskIDPattern.FindAllString(text, -1) {
+		wordSpans(word, func(_, _ int, id string) bool {
+			if !seen[id] {
+				seen[id] = true
+				res = append(res, id)
+			}
+			return false
+		})
+	}
+	return res
+}
+
+// 文字中提到的修改单：每个词中优先取最长的已存在的单号，互相重叠的片段只取一个，
+// 避免 UF20-123 同时关联到单号为 123 的修改单
+func MatchTaskIDs(text string, existing map[string]bool) []string {
+	var res []string
+	seen := make(map[string]bool)
+	for _, word := range taskIDPattern.FindAllString(text, -1) {
+		used := make(map[int]bool)
+		wordSpans(word, func(i, j int, id string) bool {
+			if !existing[id] {
+				return false
+			}
+			for k := i; k < j; k++ {
+				if used[k] {
+					return false
+				}
+			}
+			for k := i; k < j; k++ {
+				used[k] = true
+			}
+			if !seen[id] {
+				seen[id] = true
+				res = append(res, id)
+			}
+			return false
+		})
+	}
+	return res
+}
+
+type webhookCommit struct {
+	ID        string `json:"id"`
+	Message   string `json:"message"`
+	URL       string `json:"url"`
+	Timestamp string `json:"timestamp"`
+	Author    struct {
+		Name     string `json:"name"`
+		Username string `json:"username"`
+	} `json:"author"`
+}
+
+func webhookCommitRefs(commits []webhookCommit) []Ref {
+	refs := make([]Ref, 0, len(commits))
+	for _, c := range commits {
+		author := c.Author.Username
+		if author == "" {
+			author = c.Author.Name
+		}
+		refs = append(refs, Ref{
+			Kind:   KIND_COMMIT,
+			Ref:    c.ID,
+			Title:  CommitTitle(c.Message),
+			Text:   c.Message,
+			URL:    c.URL,
+			Author: author,
+			At:     ParseTime(c.Timestamp),
+		})
+	}
+	return refs
+}
+
+type gitlabPushHook struct {
+	UserUsername string `json:"user_username"`
+	Project      struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+	Commits []webhookCommit `json:"commits"`
+}
+
+type gitlabMergeRequestHook struct {
+	User struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		IID          int    `json:"iid"`
+		Title        string `json:"title"`
+		Description  string `json:"description"`
+		URL          string `json:"url"`
+		State        string `json:"state"`
+		SourceBranch string `json:"source_branch"`
+		UpdatedAt    string `json:"updated_at"`
+	} `json:"object_attributes"`
+}
+
+// GitLab 的 Push Hook 和 Merge Request Hook，其余事件返回 nil
+func parseGitlabWebhook(event string, body []byte) (*Event, error) {
+	switch event {
+	case "Push Hook":
+		var h gitlabPushHook
+		if err := json.Unmarshal(body, &h); err != nil {
+			return nil, err
+		}
+		return &Event{Repo: h.Project.PathWithNamespace, Operator: h.UserUsername, Refs: webhookCommitRefs(h.Commits)}, nil
+	case "Merge Request Hook":
+		var h gitlabMergeRequestHook
+		if err := json.Unmarshal(body, &h); err != nil {
+			return nil, err
+		}
+		mr := h.ObjectAttributes
+		state := mr.State
+		if state != MR_STATE_MERGED && state != MR_STATE_CLOSED {
+			state = MR_STATE_OPENED
+		}
+		return &Event{Repo: h.Project.PathWithNamespace, Operator: h.User.Username, Refs: []Ref{{
+			Kind:   KIND_MR,
+			Ref:    strconv.Itoa(mr.IID),
+			Title:  mr.Title,
+			Text:   strings.Join([]string{mr.Title, mr.Description, mr.SourceBranch}, "\n"),
+			URL:    mr.URL,
+			Author: h.User.Username,
+			State:  state,
+			At:     ParseTime(mr.UpdatedAt),
+		}}}, nil
+	}
+	return nil, nil
+}
+
+type githubPushHook struct {
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	Sender struct {
+		Login string `json:"login"`
+	} `json:"sender"`
+	Commits []webhookCommit `json:"commits"`
+}
+
+type githubPullRequestHook struct {
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	Sender struct {
+		Login string `json:"login"`
+	} `json:"sender"`
+	PullRequest struct {
+		Number    int    `json:"number"`
+		Title     string `json:"title"`
+		Body      string `json:"body"`
+		HTMLURL   string `json:"html_url"`
+		State     string `json:"state"`
+		Merged    bool   `json:"merged"`
+		UpdatedAt string `json:"updated_at"`
+		Head      struct {
+			Ref string `json:"ref"`
+		} `json:"head"`
+		User struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	} `json:"pull_request"`
+}
+
+// GitHub 和 Gitea 的 push、pull_request 事件格式相同，其余事件返回 nil
+func parseGithubWebhook(event string, body []byte) (*Event, error) {
+	switch event {
+	case "push":
+		var h githubPushHook
+		if err := json.Unmarshal(body, &h); err != nil {
+			return nil, err
+		}
+		return &Event{Repo: h.Repository.FullName, Operator: h.Sender.Login, Refs: webhookCommitRefs(h.Commits)}, nil
+	case "pull_request":
+		var h githubPullRequestHook
+		if err := json.Unmarshal(body, &h); err != nil {
+			return nil, err
+		}
+		pr := h.PullRequest
+		state := MR_STATE_OPENED
+		if pr.Merged {
+			state = MR_STATE_MERGED
+		} else if pr.State == "closed" {
+			state = MR_STATE_CLOSED
+		}
+		return &Event{Repo: h.Repository.FullName, Operator: h.Sender.Login, Refs: []Ref{{
+			Kind:   KIND_MR,
+			Ref:    strconv.Itoa(pr.Number),
+			Title:  pr.Title,
+			Text:   strings.Join([]string{pr.Title, pr.Body, pr.Head.Ref}, "\n"),
+			URL:    pr.HTMLURL,
+			Author: pr.User.Login,
+			State:  state,
+			At:     ParseTime(pr.UpdatedAt),
+		}}}, nil
+	}
+	return nil, nil
+}
+
+func webhookHMAC(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// 按平台解析 Webhook 并校验密钥：GitLab 直接比较 X-Gitlab-Token，GitHub/Gitea 校验请求体的 HMAC-SHA256。
+// 不关心的事件（如 ping）返回 nil
+func Parse(h http.Header, body []byte, secret string) (*Event, error) {
+	equal := func(a, b string) bool { return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1 }
+	var event *Event
+	var err error
+	switch {
+	case h.Get("X-Gitlab-Event") != "":
+		if !equal(h.Get("X-Gitlab-Token"), secret) {
+			return nil, ErrBadSecret
+		}
+		event, err = parseGitlabWebhook(h.Get("X-Gitlab-Event"), body)
+	case h.Get("X-Gitea-Event") != "":
+		if !equal(h.Get("X-Gitea-Signature"), webhookHMAC(secret, body)) {
+			return nil, ErrBadSecret
+		}
+		event, err = parseGithubWebhook(h.Get("X-Gitea-Event"), body)
+	case h.Get("X-GitHub-Event") != "":
+		if !equal(h.Get("X-Hub-Signature-256"), "sha256="+webhookHMAC(secret, body)) {
+			return nil, ErrBadSecret
+		}
+		event, err = parseGithubWebhook(h.Get("X-GitHub-Event"), body)
+	default:
+		return nil, errors.New("unknown webhook source")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload: %v", err)
+	}
+	if event != nil {
+		if event.Repo == "" {
+			return nil, errors.New("repository not found in payload")
+		}
+		event.Repo = TruncateRunes(event.Repo, 200)
+		if event.Operator == "" {
+			event.Operator = "webhook"
+		}
+	}
+	return event, nil
+}
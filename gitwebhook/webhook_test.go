@@ -0,0 +1,377 @@
+package gitwebhook
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testSecret = "s3cret"
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func header(kv ...string) http.Header {
+	h := make(http.Header)
+	for i := 0; i+1 < len(kv); i += 2 {
+		h.Set(kv[i], kv[i+1])
+	}
+	return h
+}
+
+func mustTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParseFixtures(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		header  func(body []byte) http.Header
+		want    *Event
+	}{
+		{
+			name:    "gitlab push",
+			fixture: "gitlab_push.json",
+			header: func([]byte) http.Header {
+				return header("X-Gitlab-Event", "Push Hook", "X-Gitlab-Token", testSecret)
+			},
+			want: &Event{Repo: "mike/diaspora", Operator: "jsmith", Refs: []Ref{
+				{
+					Kind:   KIND_COMMIT,
+					Ref:    "b6568db1bc1dcd7f8b4d5a946b0b91f9dacd7327",
+					Title:  "UF20-123 fix login timeout",
+					Text:   "UF20-123 fix login timeout\n\nAlso touches 456.",
+					URL:    "http://example.com/mike/diaspora/commit/b6568db1bc1dcd7f8b4d5a946b0b91f9dacd7327",
+					Author: "Jordi Mallach",
+					At:     mustTime("2011-12-12T14:27:31+02:00"),
+				},
+				{
+					Kind:   KIND_COMMIT,
+					Ref:    "da1560886d4f094c3e6c9ef40349f7d38b5d27d7",
+					Title:  "fixed readme",
+					Text:   "fixed readme",
+					URL:    "http://example.com/mike/diaspora/commit/da1560886d4f094c3e6c9ef40349f7d38b5d27d7",
+					Author: "GitLab dev user",
+					At:     mustTime("2012-01-03T23:36:29Z"),
+				},
+			}},
+		},
+		{
+			name:    "gitlab merge request",
+			fixture: "gitlab_merge_request.json",
+			header: func([]byte) http.Header {
+				return header("X-Gitlab-Event", "Merge Request Hook", "X-Gitlab-Token", testSecret)
+			},
+			want: &Event{Repo: "gitlabhq/gitlab-test", Operator: "root", Refs: []Ref{{
+				Kind:   KIND_MR,
+				Ref:    "1",
+				Title:  "Login page",
+				Text:   "Login page\nCloses UF20-7\nT-100-login",
+				URL:    "http://example.com/diaspora/merge_requests/1",
+				Author: "root",
+				State:  MR_STATE_MERGED,
+				At:     mustTime("2013-12-03T17:23:34Z"),
+			}}},
+		},
+		{
+			name:    "github push",
+			fixture: "github_push.json",
+			header: func(body []byte) http.Header {
+				return header("X-GitHub-Event", "push", "X-Hub-Signature-256", "sha256="+webhookHMAC(testSecret, body))
+			},
+			want: &Event{Repo: "octocat/Hello-World", Operator: "octocat", Refs: []Ref{{
+				Kind:   KIND_COMMIT,
+				Ref:    "0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c",
+				Title:  "Refs UF20-123 and UF20-124",
+				Text:   "Refs UF20-123 and UF20-124",
+				URL:    "https://github.com/octocat/Hello-World/commit/0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c",
+				Author: "octocat",
+				At:     mustTime("2015-05-05T19:40:15-04:00"),
+			}}},
+		},
+		{
+			name:    "github merged pull request",
+			fixture: "github_pull_request.json",
+			header: func(body []byte) http.Header {
+				return header("X-GitHub-Event", "pull_request", "X-Hub-Signature-256", "sha256="+webhookHMAC(testSecret, body))
+			},
+			want: &Event{Repo: "octocat/Hello-World", Operator: "octocat", Refs: []Ref{{
+				Kind:   KIND_MR,
+				Ref:    "42",
+				Title:  "Add export",
+				Text:   "Add export\nImplements UF20-9\nfeature/UF20-10",
+				URL:    "https://github.com/octocat/Hello-World/pull/42",
+				Author: "hubot",
+				State:  MR_STATE_MERGED,
+				At:     mustTime("2024-03-01T08:00:00Z"),
+			}}},
+		},
+		{
+			name:    "gitea push",
+			fixture: "gitea_push.json",
+			header: func(body []byte) http.Header {
+				return header("X-Gitea-Event", "push", "X-Gitea-Signature", webhookHMAC(testSecret, body))
+			},
+			want: &Event{Repo: "gitea/webhooks", Operator: "gitea", Refs: []Ref{{
+				Kind:   KIND_COMMIT,
+				Ref:    "bffeb74224043ba2feb48d137756c8a9331c449a",
+				Title:  "Webhooks Yay! T-100",
+				Text:   "Webhooks Yay! T-100\n",
+				URL:    "http://localhost:3000/gitea/webhooks/commit/bffeb74224043ba2feb48d137756c8a9331c449a",
+				Author: "gitea",
+				At:     mustTime("2017-03-13T13:52:11-04:00"),
+			}}},
+		},
+		{
+			name:    "gitea closed pull request",
+			fixture: "gitea_pull_request.json",
+			header: func(body []byte) http.Header {
+				return header("X-Gitea-Event", "pull_request", "X-Gitea-Signature", webhookHMAC(testSecret, body))
+			},
+			want: &Event{Repo: "gitea/webhooks", Operator: "admin", Refs: []Ref{{
+				Kind:   KIND_MR,
+				Ref:    "3",
+				Title:  "Drop legacy API",
+				Text:   "Drop legacy API\n\nUF20-5-drop-api",
+				URL:    "http://localhost:3000/gitea/webhooks/pulls/3",
+				Author: "gitea",
+				State:  MR_STATE_CLOSED,
+				At:     mustTime("2017-03-13T13:52:11-04:00"),
+			}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := readFixture(t, tt.fixture)
+			got, err := Parse(tt.header(body), body, testSecret)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got == nil {
+				t.Fatal("Parse returned no event")
+			}
+			if got.Repo != tt.want.Repo || got.Operator != tt.want.Operator {
+				t.Errorf("repo, operator = %q, %q, want %q, %q", got.Repo, got.Operator, tt.want.Repo, tt.want.Operator)
+			}
+			if len(got.Refs) != len(tt.want.Refs) {
+				t.Fatalf("got %d refs, want %d", len(got.Refs), len(tt.want.Refs))
+			}
+			for i := range got.Refs {
+				g, w := got.Refs[i], tt.want.Refs[i]
+				if !g.At.Equal(w.At) {
+					t.Errorf("ref %d: at = %v, want %v", i, g.At, w.At)
+				}
+				g.At, w.At = time.Time{}, time.Time{}
+				if !reflect.DeepEqual(g, w) {
+					t.Errorf("ref %d:\n got %+v\nwant %+v", i, g, w)
+				}
+			}
+		})
+	}
+}
+
+func TestParseSecret(t *testing.T) {
+	gitlab := readFixture(t, "gitlab_push.json")
+	github := readFixture(t, "github_push.json")
+	gitea := readFixture(t, "gitea_push.json")
+	tests := []struct {
+		name    string
+		header  http.Header
+		body    []byte
+		wantErr error
+	}{
+		{
+			name:    "gitlab wrong token",
+			header:  header("X-Gitlab-Event", "Push Hook", "X-Gitlab-Token", "wrong"),
+			body:    gitlab,
+			wantErr: ErrBadSecret,
+		},
+		{
+			name:    "gitlab missing token",
+			header:  header("X-Gitlab-Event", "Push Hook"),
+			body:    gitlab,
+			wantErr: ErrBadSecret,
+		},
+		{
+			name:    "github signature with other secret",
+			header:  header("X-GitHub-Event", "push", "X-Hub-Signature-256", "sha256="+webhookHMAC("other", github)),
+			body:    github,
+			wantErr: ErrBadSecret,
+		},
+		{
+			name:    "github signature without prefix",
+			header:  header("X-GitHub-Event", "push", "X-Hub-Signature-256", webhookHMAC(testSecret, github)),
+			body:    github,
+			wantErr: ErrBadSecret,
+		},
+		{
+			name:    "github body modified after signing",
+			header:  header("X-GitHub-Event", "push", "X-Hub-Signature-256", "sha256="+webhookHMAC(testSecret, github)),
+			body:    append(append([]byte{}, github...), ' '),
+			wantErr: ErrBadSecret,
+		},
+		{
+			name:    "gitea missing signature",
+			header:  header("X-Gitea-Event", "push"),
+			body:    gitea,
+			wantErr: ErrBadSecret,
+		},
+		{
+			name:    "gitea signature of other body",
+			header:  header("X-Gitea-Event", "push", "X-Gitea-Signature", webhookHMAC(testSecret, github)),
+			body:    gitea,
+			wantErr: ErrBadSecret,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := Parse(tt.header, tt.body, testSecret)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse error = %v, want %v", err, tt.wantErr)
+			}
+			if event != nil {
+				t.Errorf("Parse returned event %+v for rejected request", event)
+			}
+		})
+	}
+}
+
+func TestParseOtherRequests(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    http.Header
+		body      []byte
+		wantEvent bool
+		wantErr   bool
+	}{
+		{
+			name:    "unknown source",
+			header:  header("X-Other-Event", "push"),
+			body:    []byte(`{}`),
+			wantErr: true,
+		},
+		{
+			name:   "ignored event",
+			header: header("X-GitHub-Event", "ping", "X-Hub-Signature-256", "sha256="+webhookHMAC(testSecret, []byte(`{"zen":"hi"}`))),
+			body:   []byte(`{"zen":"hi"}`),
+		},
+		{
+			name:    "invalid json",
+			header:  header("X-Gitlab-Event", "Push Hook", "X-Gitlab-Token", testSecret),
+			body:    []byte(`{"commits":`),
+			wantErr: true,
+		},
+		{
+			name:    "missing repository",
+			header:  header("X-Gitlab-Event", "Push Hook", "X-Gitlab-Token", testSecret),
+			body:    []byte(`{"commits":[]}`),
+			wantErr: true,
+		},
+		{
+			name:      "missing operator defaults to webhook",
+			header:    header("X-Gitlab-Event", "Push Hook", "X-Gitlab-Token", testSecret),
+			body:      []byte(`{"project":{"path_with_namespace":"a/b"}}`),
+			wantEvent: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := Parse(tt.header, tt.body, testSecret)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse error = %v, want error %v", err, tt.wantErr)
+			}
+			if (event != nil) != tt.wantEvent {
+				t.Fatalf("Parse event = %+v, want event %v", event, tt.wantEvent)
+			}
+			if event != nil && event.Operator != "webhook" {
+				t.Errorf("operator = %q, want webhook", event.Operator)
+			}
+		})
+	}
+}
+
+func TestMatchTaskIDs(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		existing []string
+		want     []string
+	}{
+		{
+			name:     "prefers longest existing id",
+			text:     "UF20-123 fix login timeout",
+			existing: []string{"UF20-123", "123"},
+			want:     []string{"UF20-123"},
+		},
+		{
+			name:     "falls back to shorter id",
+			text:     "UF20-123 fix login timeout",
+			existing: []string{"123"},
+			want:     []string{"123"},
+		},
+		{
+			name:     "id inside branch name",
+			text:     "T-100-login",
+			existing: []string{"T-100"},
+			want:     []string{"T-100"},
+		},
+		{
+			name:     "non-overlapping ids in one word",
+			text:     "T-100-T-200",
+			existing: []string{"T-100", "T-200"},
+			want:     []string{"T-100", "T-200"},
+		},
+		{
+			name:     "multiple ids in order without duplicates",
+			text:     "Refs UF20-124 and UF20-123, again UF20-124",
+			existing: []string{"UF20-123", "UF20-124"},
+			want:     []string{"UF20-124", "UF20-123"},
+		},
+		{
+			name:     "unknown ids are ignored",
+			text:     "Refs UF20-999",
+			existing: []string{"UF20-123"},
+			want:     nil,
+		},
+		{
+			name:     "words without digits are ignored",
+			text:     "fixed readme",
+			existing: []string{"readme"},
+			want:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := make(map[string]bool)
+			for _, id := range tt.existing {
+				existing[id] = true
+			}
+			if got := MatchTaskIDs(tt.text, existing); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MatchTaskIDs(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaskIDCandidates(t *testing.T) {
+	got := TaskIDCandidates("UF20-123 fix, see UF20-123")
+	want := []string{"UF20-123", "UF20", "123"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TaskIDCandidates = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"OrderManager/pb"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+const IMPORT_ROW_CONFLICT = "conflict"
+
+// idempotencyKey 重复时回滚事务，改为返回第一次导入的结果
+var errImportReplayed = errors.New("import replayed")
+
+type importCounts struct {
+	created, updated, unchanged, conflict, failed int32
+}
+
+func countImportRows(rows []*pb.ImportRowResult) importCounts {
+	var c importCounts
+	for _, r := range rows {
+		switch r.Status {
+		case IMPORT_ROW_CREATED:
+			c.created++
+		case IMPORT_ROW_UPDATED:
+			c.updated++
+		case IMPORT_ROW_UNCHANGED:
+			c.unchanged++
+		case IMPORT_ROW_CONFLICT:
+			c.conflict++
+		default:
+			c.failed++
+		}
+	}
+	return c
+}
+
+// 有冲突或出错的行时整批不写入
+func (c importCounts) check(entityType string, rows []*pb.ImportRowResult) error {
+	if c.conflict > 0 {
+		return status.Errorf(codes.Aborted, "%d %ss have been modified by someone else, run a dry run for details", c.conflict, entityType)
+	}
+	for _, r := range rows {
+		if r.Status == IMPORT_ROW_ERROR && len(r.Errors) > 0 {
+			return fmt.Errorf("%s %s: %s", entityType, r.Key, r.Errors[0])
+		}
+	}
+	return nil
+}
+
+func toPbFieldChanges(changes []fieldChange) []*pb.FieldChange {
+	res := make([]*pb.FieldChange, len(changes))
+	for i, c := range changes {
+		res[i] = &pb.FieldChange{Field: c.Field, OldValue: c.Old, NewValue: c.New}
+	}
+	return res
+}
+
+// 在调用方的事务中登记导入批次，同一用户的 idempotencyKey 已存在时返回 errImportReplayed
+func beginImportBatch(ctx context.Context, tx *gorm.DB, user, entityType, key string) (*ImportBatchInfo, error) {
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	method, _ := grpc.Method(ctx)
+	batch := &ImportBatchInfo{
+		ID:         token[:32],
+		User:       operatorName(ctx, user),
+		Method:     method,
+		EntityType: entityType,
+	}
+	if key != "" {
+		if _, err := maxLen("idempotencyKey", key, 64); err != nil {
+			return nil, err
+		}
+		batch.IdempotencyKey = &key
+	}
+	if err := tx.Create(batch).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, errImportReplayed
+		}
+		return nil, err
+	}
+	return batch, nil
+}
+
+func finishImportBatch(tx *gorm.DB, batch *ImportBatchInfo, c importCounts) error {
+	batch.Created, batch.Updated, batch.Unchanged = int(c.created), int(c.updated), int(c.unchanged)
+	return tx.Model(batch).Select("created", "updated", "unchanged").Updates(batch).Error
+}
+
+func findReplayedBatch(ctx context.Context, user, key string) (*ImportBatchInfo, error) {
+	var batch ImportBatchInfo
+	if err := db.Where("user = ? AND idempotency_key = ?", operatorName(ctx, user), key).First(&batch).Error; err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// 记录批次中写入的一条记录，before 为 nil 表示新增；
+// 同一批次中多次写入的记录（如被多个补丁提前的修改单）只保留第一次的快照，更新导入后的版本号
+func recordImportRow(tx *gorm.DB, batchID, entityType, entityID string, before interface{}, afterVersion int64) error {
+	if batchID == "" {
+		return nil
+	}
+	var existing ImportBatchRowInfo
+	err := tx.Where("batch_id = ? AND entity_type = ? AND entity_id = ?", batchID, entityType, entityID).First(&existing).Error
+	if err == nil {
+		return tx.Model(&existing).Update("after_version", afterVersion).Error
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	row := ImportBatchRowInfo{BatchID: batchID, EntityType: entityType, EntityID: entityID, Action: IMPORT_ROW_CREATED, AfterVersion: afterVersion}
+	if !isNilEntity(before) {
+		data, err := json.Marshal(before)
+		if err != nil {
+			return err
+		}
+		row.Action, row.Before = IMPORT_ROW_UPDATED, string(data)
+	}
+	return tx.Create(&row).Error
+}
+
+func importBatchInfoToPbImportBatch(b *ImportBatchInfo) *pb.ImportBatch {
+	return &pb.ImportBatch{
+		Id:           b.ID,
+		User:         b.User,
+		EntityType:   b.EntityType,
+		Method:       b.Method,
+		Created:      int32(b.Created),
+		Updated:      int32(b.Updated),
+		Unchanged:    int32(b.Unchanged),
+		CreatedAt:    b.CreatedAt.Format("2006-01-02 15:04:05"),
+		RolledBackAt: formatOptionalTime(b.RolledBackAt),
+		RolledBackBy: b.RolledBackBy,
+	}
+}
+
+func (s *server) ListImportBatches(ctx context.Context, in *pb.ListImportBatchesRequest) (*pb.ListImportBatchesReply, error) {
+	query := db.Model(&ImportBatchInfo{})
+	if in.User != "" {
+		query = query.Where("user = ?", in.User)
+	}
+	reply := &pb.ListImportBatchesReply{}
+	if err := query.Count(&reply.Total).Error; err != nil {
+		return nil, err
+	}
+	limit := in.Limit
+	if limit <= 0 || limit > auditMaxLimit {
+		limit = auditMaxLimit
+	}
+	var batches []ImportBatchInfo
+	if err := query.Order("created_at desc").Limit(int(limit)).Offset(int(in.Offset)).Find(&batches).Error; err != nil {
+		return nil, err
+	}
+	for i := range batches {
+		reply.Batches = append(reply.Batches, importBatchInfoToPbImportBatch(&batches[i]))
+	}
+	return reply, nil
+}
+
+func findImportBatch(tx *gorm.DB, id string) (*ImportBatchInfo, error) {
+	var batch ImportBatchInfo
+	if err := tx.Where("id = ?", id).First(&batch).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("import batch does not exist")
+		}
+		return nil, err
+	}
+	return &batch, nil
+}
+
+func (s *server) GetImportBatch(ctx context.Context, in *pb.GetImportBatchRequest) (*pb.GetImportBatchReply, error) {
+	batch, err := findImportBatch(db, in.BatchId)
+	if err != nil {
+		return nil, err
+	}
+	var rows []ImportBatchRowInfo
+	if err := db.Where("batch_id = ?", batch.ID).Order("id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	reply := &pb.GetImportBatchReply{Batch: importBatchInfoToPbImportBatch(batch)}
+	for _, r := range rows {
+		reply.Rows = append(reply.Rows, &pb.ImportBatchRow{EntityType: r.EntityType, EntityId: r.EntityID, Action: r.Action, AfterVersion: r.AfterVersion})
+	}
+	return reply, nil
+}
+
+// 导入后被修改过的记录不能回滚；在恢复任何一条之前检查，
+// 因为恢复修改单会更新依赖方的阻塞状态，使批次中其他记录的版本号变化
+func checkImportRowUnchanged(tx *gorm.DB, row *ImportBatchRowInfo) error {
+	var version int64
+	var err error
+	if row.EntityType == AUDIT_ENTITY_PATCH {
+		var patch *PatchsInfo
+		if patch, err = findPatch(tx.Clauses(clause.Locking{Strength: "UPDATE"}), row.EntityID); patch != nil {
+			version = patch.Version
+		}
+	} else {
+		var task *TaskInfo
+		if task, err = findTask(tx.Clauses(clause.Locking{Strength: "UPDATE"}), row.EntityID); task != nil {
+			version = task.Version
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if version != row.AfterVersion {
+		return fmt.Errorf("%s %s has been modified after the import", row.EntityType, row.EntityID)
+	}
+	return nil
+}
+
+// 回滚一条修改单：新增的移入回收站，修改的恢复为快照
+func rollbackTaskRow(ctx context.Context, tx *gorm.DB, user, batchID string, row *ImportBatchRowInfo) (bool, error) {
+	current, err := findTask(tx.Clauses(clause.Locking{Strength: "UPDATE"}), row.EntityID)
+	if err != nil {
+		return false, err
+	}
+	if current == nil {
+		return false, fmt.Errorf("task %s has been modified after the import", row.EntityID)
+	}
+	if row.Action == IMPORT_ROW_CREATED {
+		return true, softDeleteTask(ctx, tx, user, current, "rollback import batch "+batchID, "")
+	}
+	var snapshot TaskInfo
+	if err := json.Unmarshal([]byte(row.Before), &snapshot); err != nil {
+		return false, err
+	}
+	snapshot.Version = current.Version + 1
+	if err := tx.Save(&snapshot).Error; err != nil {
+		return false, err
+	}
+	if err := recordAudit(ctx, tx, user, AUDIT_ENTITY_TASK, current, &snapshot); err != nil {
+		return false, err
+	}
+	dependents, err := dependentsOf(tx, []string{snapshot.TaskID})
+	if err != nil {
+		return false, err
+	}
+	return false, refreshBlocked(ctx, tx, user, append([]string{snapshot.TaskID}, dependents...))
+}
+
+func rollbackPatchRow(ctx context.Context, tx *gorm.DB, user, batchID string, row *ImportBatchRowInfo) (bool, error) {
+	current, err := findPatch(tx.Clauses(clause.Locking{Strength: "UPDATE"}), row.EntityID)
+	if err != nil {
+		return false, err
+	}
+	if current == nil {
+		return false, fmt.Errorf("patch %s has been modified after the import", row.EntityID)
+	}
+	if row.Action == IMPORT_ROW_CREATED {
+		return true, softDeletePatch(ctx, tx, user, current, "rollback import batch "+batchID)
+	}
+	var snapshot PatchsInfo
+	if err := json.Unmarshal([]byte(row.Before), &snapshot); err != nil {
+		return false, err
+	}
+	snapshot.Version = current.Version + 1
+	if err := tx.Save(&snapshot).Error; err != nil {
+		return false, err
+	}
+	return false, recordAudit(ctx, tx, user, AUDIT_ENTITY_PATCH, current, &snapshot)
+}
+
+func (s *server) RollbackImportBatch(ctx context.Context, in *pb.RollbackImportBatchRequest) (*pb.RollbackImportBatchReply, error) {
+	operator := operatorName(ctx, in.User)
+	reply := &pb.RollbackImportBatchReply{}
+	var entityType string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		reply.Deleted, reply.Restored = 0, 0
+		batch, err := findImportBatch(tx.Clauses(clause.Locking{Strength: "UPDATE"}), in.BatchId)
+		if err != nil {
+			return err
+		}
+		if batch.User != operator {
+			if err := requireAdmin(tx, operator); err != nil {
+				return err
+			}
+		}
+		if err := requireEntityScope(ctx, batch.EntityType); err != nil {
+			return err
+		}
+		if batch.RolledBackAt != nil {
+			return errors.New("import batch has already been rolled back")
+		}
+		entityType = batch.EntityType
+
+		var rows []ImportBatchRowInfo
+		if err := tx.Where("batch_id = ?", batch.ID).Order("id desc").Find(&rows).Error; err != nil {
+			return err
+		}
+		for i := range rows {
+			if err := checkImportRowUnchanged(tx, &rows[i]); err != nil {
+				return err
+			}
+		}
+		for i := range rows {
+			rollback := rollbackTaskRow
+			if rows[i].EntityType == AUDIT_ENTITY_PATCH {
+				rollback = rollbackPatchRow
+			}
+			deleted, err := rollback(ctx, tx, in.User, batch.ID, &rows[i])
+			if err != nil {
+				return err
+			}
+			if deleted {
+				reply.Deleted++
+			} else {
+				reply.Restored++
+			}
+		}
+		now := time.Now()
+		return tx.Model(batch).Updates(map[string]interface{}{"rolled_back_at": now, "rolled_back_by": operator}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	msg := fmt.Sprintf("<%s> -> rollback import batch %s: %d %ss -> <ALL>", in.User, in.BatchId, reply.Deleted+reply.Restored, entityType)
+	NotificationServer.updateDatabaseAndNotify(msg)
+	return reply, nil
+}
@@ -0,0 +1,834 @@
+package main
+
+import (
+	"OrderManager/common"
+	"OrderManager/pb"
+	"OrderManager/xlsx"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	IMPORT_ROW_CREATED   = "created"
+	IMPORT_ROW_UPDATED   = "updated"
+	IMPORT_ROW_UNCHANGED = "unchanged"
+	IMPORT_ROW_ERROR     = "error"
+)
+
+// 与 tasklist_table 的默认值一致，截止日期按工作日计算
+const (
+	defaultEstimatedWorkHours = 16
+	defaultDeadlineDays       = 3
+)
+
+// .xlsx 文件大小上限
+const maxImportFileSize = 10 << 20
+
+// 校验失败时回滚事务
+var errImportRollback = errors.New("import rolled back")
+
+// 导入导出列：header、en 为中英文表头，set 把单元格的值写入记录，get 取出导出的值
+type importColumn[T any] struct {
+	header string
+	en     string
+	set    func(v *T, cell string) error
+	get    func(v *T) interface{}
+}
+
+func cellString(field string, n int, dst *string) func(cell string) error {
+	return func(cell string) error {
+		if _, err := maxLen(field, cell, n); err != nil {
+			return err
+		}
+		*dst = cell
+		return nil
+	}
+}
+
+// 支持 2006-01-02、2006/1/2 等写法，Excel 日期格式的单元格已由 xlsx 转为 2006-01-02
+func parseCellDate(field, cell string) (time.Time, error) {
+	cell = strings.TrimSpace(cell)
+	if i := strings.IndexByte(cell, ' '); i > 0 {
+		cell = cell[:i]
+	}
+	for _, layout := range []string{"2006-01-02", "2006-1-2", "2006/01/02", "2006/1/2", "20060102"} {
+		if t, err := time.Parse(layout, cell); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid %s %q", field, cell)
+}
+
+func parseCellInt(field, cell string) (int, error) {
+	f, err := strconv.ParseFloat(cell, 64)
+	if err != nil || f != float64(int(f)) {
+		return 0, fmt.Errorf("invalid %s %q", field, cell)
+	}
+	return int(f), nil
+}
+
+func cellDate(t time.Time) interface{} {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func cellVersion(field, cell string, dst *int64) error {
+	n, err := parseCellInt(field, cell)
+	if err != nil {
+		return err
+	}
+	*dst = int64(n)
+	return nil
+}
+
+var taskImportColumns = map[string]importColumn[TaskInfo]{
+	"taskId": {"任务单号", "Task ID",
+		func(t *TaskInfo, cell string) error { return cellString("taskId", 25, &t.TaskID)(cell) },
+		func(t *TaskInfo) interface{} { return t.TaskID }},
+	"comment": {"任务描述", "Comment",
+		func(t *TaskInfo, cell string) error { return cellString("comment", 100, &t.Comment)(cell) },
+		func(t *TaskInfo) interface{} { return t.Comment }},
+	"emergencyLevel": {"紧急程度", "Emergency Level",
+		func(t *TaskInfo, cell string) error {
+			n, err := parseCellInt("emergencyLevel", cell)
+			if err != nil {
+				return err
+			}
+			if n < EMERGENCY_LEVEL_0 || n > EMERGENCY_LEVEL_2 {
+				return fmt.Errorf("invalid emergencyLevel %d", n)
+			}
+			t.EmergencyLevel = n
+			return nil
+		},
+		func(t *TaskInfo) interface{} { return t.EmergencyLevel }},
+	"deadline": {"截止日期", "Deadline",
+		func(t *TaskInfo, cell string) (err error) {
+			t.Deadline, err = parseCellDate("deadline", cell)
+			return err
+		},
+		func(t *TaskInfo) interface{} { return cellDate(t.Deadline) }},
+	"principal": {"负责人", "Principal",
+		func(t *TaskInfo, cell string) error { return cellString("principal", 20, &t.Principal)(cell) },
+		func(t *TaskInfo) interface{} { return t.Principal }},
+	"reqNo": {"需求号", "Req No",
+		func(t *TaskInfo, cell string) error { return cellString("reqNo", 20, &t.ReqNo)(cell) },
+		func(t *TaskInfo) interface{} { return t.ReqNo }},
+	"estimatedHours": {"预计工时", "Estimated Hours",
+		func(t *TaskInfo, cell string) error {
+			f, err := strconv.ParseFloat(cell, 64)
+			if err != nil || f < 0 {
+				return fmt.Errorf("invalid estimatedHours %q", cell)
+			}
+			t.EstimatedWorkHours = f
+			return nil
+		},
+		func(t *TaskInfo) interface{} { return t.EstimatedWorkHours }},
+	"state": {"任务状态", "State",
+		func(t *TaskInfo, cell string) error { return cellString("state", 20, &t.State)(cell) },
+		func(t *TaskInfo) interface{} { return t.State }},
+	"typeId": {"任务类型", "Type",
+		func(t *TaskInfo, cell string) (err error) {
+			t.Type, err = parseCellInt("typeId", cell)
+			return err
+		},
+		func(t *TaskInfo) interface{} { return t.Type }},
+	"parentId": {"父任务单号", "Parent ID",
+		func(t *TaskInfo, cell string) error { return cellString("parentId", 25, &t.ParentID)(cell) },
+		func(t *TaskInfo) interface{} { return t.ParentID }},
+	// 导出时的版本号，导入时与当前版本不一致视为冲突
+	"version": {"版本号", "Version",
+		func(t *TaskInfo, cell string) error { return cellVersion("version", cell, &t.Version) },
+		func(t *TaskInfo) interface{} { return t.Version }},
+}
+
+// 导出时默认的列顺序
+var taskColumnOrder = []string{"taskId", "comment", "emergencyLevel", "deadline", "principal", "reqNo", "estimatedHours", "state", "typeId", "parentId", "version"}
+
+var patchImportColumns = map[string]importColumn[PatchsInfo]{
+	"patchNo": {"补丁号", "Patch No",
+		func(p *PatchsInfo, cell string) error { return cellString("patchNo", 20, &p.PatchNo)(cell) },
+		func(p *PatchsInfo) interface{} { return p.PatchNo }},
+	"reqNo": {"需求号", "Req No",
+		func(p *PatchsInfo, cell string) error { return cellString("reqNo", 40, &p.ReqNo)(cell) },
+		func(p *PatchsInfo) interface{} { return p.ReqNo }},
+	"describe": {"问题描述", "Description",
+		func(p *PatchsInfo, cell string) error { p.Describe = cell; return nil },
+		func(p *PatchsInfo) interface{} { return p.Describe }},
+	"clientName": {"客户名称", "Client",
+		func(p *PatchsInfo, cell string) error { return cellString("clientName", 20, &p.ClientName)(cell) },
+		func(p *PatchsInfo) interface{} { return p.ClientName }},
+	"deadline": {"预计发布时间", "Deadline",
+		func(p *PatchsInfo, cell string) (err error) {
+			p.Deadline, err = parseCellDate("deadline", cell)
+			return err
+		},
+		func(p *PatchsInfo) interface{} { return cellDate(p.Deadline) }},
+	"reason": {"补丁原因", "Reason",
+		func(p *PatchsInfo, cell string) error { return cellString("reason", 100, &p.Reason)(cell) },
+		func(p *PatchsInfo) interface{} { return p.Reason }},
+	"sponsor": {"发起人", "Sponsor",
+		func(p *PatchsInfo, cell string) error { return cellString("sponsor", 20, &p.Sponsor)(cell) },
+		func(p *PatchsInfo) interface{} { return p.Sponsor }},
+	"state": {"发布状态", "State",
+		func(p *PatchsInfo, cell string) error { return cellString("state", 10, &p.State)(cell) },
+		func(p *PatchsInfo) interface{} { return p.State }},
+	"version": {"版本号", "Version",
+		func(p *PatchsInfo, cell string) error { return cellVersion("version", cell, &p.Version) },
+		func(p *PatchsInfo) interface{} { return p.Version }},
+}
+
+var patchColumnOrder = []string{"patchNo", "reqNo", "describe", "clientName", "deadline", "reason", "sponsor", "state", "version"}
+
+// 按表头找到各字段所在的列，mapping 为空时使用默认表头（中文或英文）
+func locateColumns[T any](columns map[string]importColumn[T], mapping map[string]string, header []string, keyField string) (map[string]int, error) {
+	useDefault := len(mapping) == 0
+	if useDefault {
+		mapping = make(map[string]string, len(columns))
+		for field, c := range columns {
+			mapping[field] = c.header
+		}
+	}
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		if h != "" {
+			index[h] = i
+		}
+	}
+	located := make(map[string]int, len(mapping))
+	for field, h := range mapping {
+		if _, ok := columns[field]; !ok {
+			return nil, fmt.Errorf("unknown field %q in column mapping", field)
+		}
+		i, ok := index[h]
+		if !ok && useDefault {
+			i, ok = index[columns[field].en]
+		}
+		if ok {
+			located[field] = i
+		} else if !useDefault || field == keyField {
+			// 使用默认表头时允许缺少非主键列
+			return nil, fmt.Errorf("column %q for %s not found in header row", h, field)
+		}
+	}
+	return located, nil
+}
+
+func cellAt(row []string, i int) string {
+	if i < len(row) {
+		return row[i]
+	}
+	return ""
+}
+
+func isBlankRow(row []string) bool {
+	for _, c := range row {
+		if c != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// 导入文件中的一个数据行
+type importRow struct {
+	sheet   string
+	row     int // 行号，从 1 开始
+	cells   []string
+	located map[string]int
+}
+
+// 不是 .xlsx（zip）格式的文件按 UTF-8 的 CSV 读取
+func readImportSheets(in *pb.ImportXLSXRequest) ([]xlsx.Sheet, error) {
+	if !bytes.HasPrefix(in.Data, []byte("PK\x03\x04")) {
+		r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(in.Data, []byte("\xef\xbb\xbf"))))
+		r.FieldsPerRecord = -1
+		records, err := r.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv: %v", err)
+		}
+		for _, record := range records {
+			for i := range record {
+				record[i] = strings.TrimSpace(record[i])
+			}
+		}
+		return []xlsx.Sheet{{Rows: records}}, nil
+	}
+	if in.AllSheets {
+		return xlsx.ReadSheets(in.Data)
+	}
+	rows, err := xlsx.ReadSheet(in.Data, in.Sheet)
+	if err != nil {
+		return nil, err
+	}
+	return []xlsx.Sheet{{Name: in.Sheet, Rows: rows}}, nil
+}
+
+// 各工作表分别定位表头，返回表头之后的非空行；多个工作表中内容相同的重复行只保留第一行
+func importRows[T any](sheets []xlsx.Sheet, columns map[string]importColumn[T], in *pb.ImportXLSXRequest, keyField string) ([]importRow, error) {
+	headerRow := int(in.HeaderRow)
+	if headerRow == 0 {
+		headerRow = 1
+	}
+	var rows []importRow
+	seen := make(map[string]string)
+	for _, sheet := range sheets {
+		if len(sheets) > 1 && len(sheet.Rows) == 0 {
+			continue
+		}
+		if headerRow < 0 || headerRow > len(sheet.Rows) {
+			return nil, fmt.Errorf("header row %d not found in sheet %q", headerRow, sheet.Name)
+		}
+		located, err := locateColumns(columns, in.Columns, sheet.Rows[headerRow-1], keyField)
+		if err != nil {
+			return nil, fmt.Errorf("sheet %q: %v", sheet.Name, err)
+		}
+		for r := headerRow; r < len(sheet.Rows); r++ {
+			cells := sheet.Rows[r]
+			if isBlankRow(cells) {
+				continue
+			}
+			if len(sheets) > 1 {
+				var values []string
+				for _, field := range sortedFields(located) {
+					values = append(values, field+"="+cellAt(cells, located[field]))
+				}
+				key, content := cellAt(cells, located[keyField]), strings.Join(values, "\x00")
+				if prev, ok := seen[key]; ok && prev == content {
+					continue
+				}
+				seen[key] = content
+			}
+			rows = append(rows, importRow{sheet: sheet.Name, row: r + 1, cells: cells, located: located})
+		}
+	}
+	return rows, nil
+}
+
+func sortedFields(located map[string]int) []string {
+	fields := make([]string, 0, len(located))
+	for field := range located {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// 把一行写入记录，空单元格不修改原值
+func applyRow[T any](columns map[string]importColumn[T], located map[string]int, row []string, v *T) []string {
+	fields := make([]string, 0, len(located))
+	for field := range located {
+		fields = append(fields, field)
+	}
+	sort.Slice(fields, func(i, j int) bool { return located[fields[i]] < located[fields[j]] })
+	var errs []string
+	for _, field := range fields {
+		cell := cellAt(row, located[field])
+		if cell == "" {
+			continue
+		}
+		if err := columns[field].set(v, cell); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	return errs
+}
+
+// 行的状态以及与当前数据的差异，before 为 nil 表示新增
+func rowDiff(before, after interface{}) (string, []*pb.FieldChange) {
+	if isNilEntity(before) {
+		return IMPORT_ROW_CREATED, toPbFieldChanges(diffFields(nil, after))
+	}
+	changes := diffFields(before, after)
+	if len(changes) == 0 {
+		return IMPORT_ROW_UNCHANGED, nil
+	}
+	return IMPORT_ROW_UPDATED, toPbFieldChanges(changes)
+}
+
+// 默认截止日期为 defaultDeadlineDays 个工作日之后，跳过周末和节假日
+func defaultTaskDeadline() time.Time {
+	today, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+	return currentBusinessCalendar().addWorkdays(today, defaultDeadlineDays)
+}
+
+func activeUserExists(tx *gorm.DB, name string) (bool, error) {
+	var n int64
+	err := tx.Model(&UserInfo{}).Where("name = ? AND active = ?", name, true).Count(&n).Error
+	return n > 0, err
+}
+
+// 一条待导入的修改单：key 为任务单号，apply 把该行的内容写入记录，出错或需要提示时写入 res
+type taskImportItem struct {
+	sheet       string
+	row         int
+	key         string
+	externalKey string
+	levelSet    bool // 是否填写了紧急程度，未填写时新建的修改单取任务类型的默认值
+	apply       func(t *TaskInfo, res *pb.ImportRowResult)
+}
+
+func taskItemsFromRows(rows []importRow) []taskImportItem {
+	items := make([]taskImportItem, len(rows))
+	for i, row := range rows {
+		row := row
+		level, hasLevel := row.located["emergencyLevel"]
+		items[i] = taskImportItem{
+			sheet:    row.sheet,
+			row:      row.row,
+			key:      cellAt(row.cells, row.located["taskId"]),
+			levelSet: hasLevel && cellAt(row.cells, level) != "",
+			apply: func(t *TaskInfo, res *pb.ImportRowResult) {
+				res.Errors = append(res.Errors, applyRow(taskImportColumns, row.located, row.cells, t)...)
+			},
+		}
+	}
+	return items
+}
+
+// 校验所有修改单行，返回每行的结果以及需要写入的记录（不含未变化的行）
+func validateTaskRows(tx *gorm.DB, items []taskImportItem) ([]*pb.ImportRowResult, []TaskInfo, error) {
+	types, err := loadTaskTypes(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	today := time.Now().Format("2006-01-02")
+	cal := currentBusinessCalendar()
+	var results []*pb.ImportRowResult
+	var toWrite []TaskInfo
+	inFile := make(map[string]*TaskInfo)
+	for _, item := range items {
+		res := &pb.ImportRowResult{Row: int32(item.row), Sheet: item.sheet, Key: item.key, ExternalKey: item.externalKey}
+		results = append(results, res)
+		if res.Key == "" {
+			res.Errors = append(res.Errors, "taskId is required")
+			continue
+		}
+		if _, dup := inFile[res.Key]; dup {
+			res.Errors = append(res.Errors, "duplicate taskId in file")
+			continue
+		}
+
+		before, err := findTask(tx.Unscoped(), res.Key)
+		if err != nil {
+			return nil, nil, err
+		}
+		if before != nil && before.DeletedAt.Valid {
+			res.Errors = append(res.Errors, "task is in the trash, restore it first")
+			continue
+		}
+		var task TaskInfo
+		if before != nil {
+			task = *before
+		}
+		item.apply(&task, res)
+		if before != nil && task.Version != before.Version {
+			res.Status = IMPORT_ROW_CONFLICT
+			res.Errors = append(res.Errors, fmt.Sprintf("task has been modified since version %d", task.Version))
+			continue
+		}
+		if before == nil {
+			task.Version = 0
+			// 先按类型校验必填字段，再补默认值，与 AddTask 一致
+			if err := applyTaskType(types, &task, item.levelSet); err != nil {
+				res.Errors = append(res.Errors, err.Error())
+			}
+			if task.Deadline.IsZero() {
+				task.Deadline = defaultTaskDeadline()
+			}
+			if task.State == "" {
+				task.State = TASK_STATE_TEXT_WAIT
+			}
+			if task.EstimatedWorkHours == 0 {
+				task.EstimatedWorkHours = defaultEstimatedWorkHours
+			}
+		}
+		if task.Principal == "" {
+			res.Errors = append(res.Errors, "principal is required")
+		}
+
+		// 父任务可以是文件中前面的行
+		if parent, ok := inFile[task.ParentID]; ok {
+			if task.ReqNo == "" {
+				task.ReqNo = parent.ReqNo
+			} else if task.ReqNo != parent.ReqNo {
+				res.Errors = append(res.Errors, fmt.Sprintf("subtask must belong to requirement %s of its parent", parent.ReqNo))
+			}
+		} else if err := checkParent(tx, &task); err != nil {
+			res.Errors = append(res.Errors, err.Error())
+		}
+		if task.ReqNo == "" {
+			res.Errors = append(res.Errors, "reqNo is required")
+		}
+		inFile[res.Key] = &task
+		if len(res.Errors) > 0 {
+			continue
+		}
+
+		if ok, err := activeUserExists(tx, task.Principal); err != nil {
+			return nil, nil, err
+		} else if !ok {
+			res.Warnings = append(res.Warnings, fmt.Sprintf("principal %s is not an active user", task.Principal))
+		}
+		if task.State != TASK_STATE_TEXT_FINISH && task.Deadline.Format("2006-01-02") < today {
+			res.Warnings = append(res.Warnings, "deadline is in the past")
+		}
+		if !cal.isWorkday(task.Deadline) {
+			res.Warnings = append(res.Warnings, "deadline is not a working day")
+		}
+		res.Status, res.Changes = rowDiff(before, &task)
+		if res.Status != IMPORT_ROW_UNCHANGED {
+			toWrite = append(toWrite, task)
+		}
+	}
+	return results, toWrite, nil
+}
+
+func validatePatchRows(tx *gorm.DB, rows []importRow) ([]*pb.ImportRowResult, []PatchsInfo, error) {
+	today := time.Now().Format("2006-01-02")
+	var results []*pb.ImportRowResult
+	var toWrite []PatchsInfo
+	inFile := make(map[string]bool)
+	for _, row := range rows {
+		res := &pb.ImportRowResult{Row: int32(row.row), Sheet: row.sheet, Key: cellAt(row.cells, row.located["patchNo"])}
+		results = append(results, res)
+		if res.Key == "" {
+			res.Errors = append(res.Errors, "patchNo is required")
+			continue
+		}
+		if inFile[res.Key] {
+			res.Errors = append(res.Errors, "duplicate patchNo in file")
+			continue
+		}
+		inFile[res.Key] = true
+
+		before, err := findPatch(tx.Unscoped(), res.Key)
+		if err != nil {
+			return nil, nil, err
+		}
+		if before != nil && before.DeletedAt.Valid {
+			res.Errors = append(res.Errors, "patch is in the trash, restore it first")
+			continue
+		}
+		var patch PatchsInfo
+		if before != nil {
+			patch = *before
+		}
+		res.Errors = append(res.Errors, applyRow(patchImportColumns, row.located, row.cells, &patch)...)
+		if before != nil && patch.Version != before.Version {
+			res.Status = IMPORT_ROW_CONFLICT
+			res.Errors = append(res.Errors, fmt.Sprintf("patch has been modified since version %d", patch.Version))
+			continue
+		}
+		if before == nil {
+			patch.Version = 0
+		}
+		for field, value := range map[string]string{"reqNo": patch.ReqNo, "clientName": patch.ClientName, "sponsor": patch.Sponsor} {
+			if value == "" {
+				res.Errors = append(res.Errors, field+" is required")
+			}
+		}
+		if patch.Deadline.IsZero() {
+			res.Errors = append(res.Errors, "deadline is required")
+		}
+		if len(res.Errors) > 0 {
+			continue
+		}
+
+		var n int64
+		if err := tx.Model(&TaskInfo{}).Where("req_no IN ?", strings.Split(patch.ReqNo, ",")).Count(&n).Error; err != nil {
+			return nil, nil, err
+		}
+		if n == 0 {
+			res.Warnings = append(res.Warnings, "no tasks found for reqNo "+patch.ReqNo)
+		}
+		if patch.Deadline.Format("2006-01-02") < today {
+			res.Warnings = append(res.Warnings, "deadline is in the past")
+		}
+		res.Status, res.Changes = rowDiff(before, &patch)
+		if res.Status != IMPORT_ROW_UNCHANGED {
+			toWrite = append(toWrite, patch)
+		}
+	}
+	return results, toWrite, nil
+}
+
+// 导入的公共流程：plan 校验所有行，返回每行的结果、需要写入的条数以及写入函数；
+// commit 时登记导入批次并写入，有任何一行出错时都不写入
+func runImport(ctx context.Context, user, entityType string, commit bool, idempotencyKey string,
+	plan func(tx *gorm.DB) ([]*pb.ImportRowResult, int, func(batchID string) error, error)) (*pb.ImportXLSXReply, error) {
+	reply := &pb.ImportXLSXReply{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var batch *ImportBatchInfo
+		if commit {
+			var err error
+			if batch, err = beginImportBatch(ctx, tx, user, entityType, idempotencyKey); err != nil {
+				return err
+			}
+		}
+		rows, toWriteCnt, write, err := plan(tx)
+		if err != nil {
+			return err
+		}
+		reply.Rows = rows
+		for _, r := range reply.Rows {
+			if len(r.Errors) > 0 && r.Status != IMPORT_ROW_CONFLICT {
+				r.Status = IMPORT_ROW_ERROR
+			}
+			switch r.Status {
+			case IMPORT_ROW_CREATED:
+				reply.Created++
+			case IMPORT_ROW_UPDATED:
+				reply.Updated++
+			case IMPORT_ROW_UNCHANGED:
+				reply.Unchanged++
+			default:
+				reply.Failed++
+			}
+		}
+		if !commit || reply.Failed > 0 || toWriteCnt == 0 {
+			return errImportRollback
+		}
+		if err := write(batch.ID); err != nil {
+			return err
+		}
+		reply.Committed, reply.BatchId = true, batch.ID
+		return finishImportBatch(tx, batch, importCounts{created: reply.Created, updated: reply.Updated, unchanged: reply.Unchanged})
+	})
+	if errors.Is(err, errImportReplayed) {
+		batch, err := findReplayedBatch(ctx, user, idempotencyKey)
+		if err != nil {
+			return nil, err
+		}
+		return &pb.ImportXLSXReply{
+			Created:   int32(batch.Created),
+			Updated:   int32(batch.Updated),
+			Unchanged: int32(batch.Unchanged),
+			Committed: true,
+			BatchId:   batch.ID,
+			Replayed:  true,
+		}, nil
+	}
+	if err != nil && !errors.Is(err, errImportRollback) {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// 导入修改单后按负责人通知
+func notifyImportedTasks(user string, reply *pb.ImportXLSXReply, principals []string) {
+	notified := make(map[string]bool)
+	for _, p := range principals {
+		if !notified[p] {
+			notified[p] = true
+			msg := fmt.Sprintf("<%s> -> import tasks counts: %d -> <%s>", user, reply.Created+reply.Updated, p)
+			NotificationServer.updateDatabaseAndNotify(msg)
+		}
+	}
+}
+
+func (s *server) ImportXLSX(ctx context.Context, in *pb.ImportXLSXRequest) (*pb.ImportXLSXReply, error) {
+	if len(in.Data) > maxImportFileSize {
+		return nil, fmt.Errorf("file exceeds %d bytes", maxImportFileSize)
+	}
+	if err := requireEntityScope(ctx, in.EntityType); err != nil {
+		return nil, err
+	}
+	sheets, err := readImportSheets(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []deadlineConflict
+	var principals []string
+	reply, err := runImport(ctx, in.User, in.EntityType, in.Commit, in.IdempotencyKey,
+		func(tx *gorm.DB) ([]*pb.ImportRowResult, int, func(string) error, error) {
+			switch in.EntityType {
+			case AUDIT_ENTITY_TASK:
+				rows, err := importRows(sheets, taskImportColumns, in, "taskId")
+				if err != nil {
+					return nil, 0, nil, err
+				}
+				results, tasks, err := validateTaskRows(tx, taskItemsFromRows(rows))
+				if err != nil {
+					return nil, 0, nil, err
+				}
+				return results, len(tasks), func(batchID string) error {
+					for _, t := range tasks {
+						principals = append(principals, t.Principal)
+					}
+					return importTasks(ctx, tx, in.User, tasks, batchID)
+				}, nil
+			case AUDIT_ENTITY_PATCH:
+				rows, err := importRows(sheets, patchImportColumns, in, "patchNo")
+				if err != nil {
+					return nil, 0, nil, err
+				}
+				results, patchs, err := validatePatchRows(tx, rows)
+				if err != nil {
+					return nil, 0, nil, err
+				}
+				return results, len(patchs), func(batchID string) (err error) {
+					conflicts, err = s.importPatches(ctx, tx, in.User, patchs, batchID)
+					return err
+				}, nil
+			}
+			return nil, 0, nil, fmt.Errorf("unknown entity type: %s", in.EntityType)
+		})
+	if err != nil {
+		return nil, err
+	}
+	if !reply.Committed || reply.Replayed {
+		return reply, nil
+	}
+
+	if in.EntityType == AUDIT_ENTITY_TASK {
+		notifyImportedTasks(in.User, reply, principals)
+	} else {
+		msg := fmt.Sprintf("<%s> -> import patchs counts: %d -> <ALL>", in.User, reply.Created+reply.Updated)
+		NotificationServer.updateDatabaseAndNotify(msg)
+		notifyDeadlineConflicts(in.User, conflicts)
+	}
+	return reply, nil
+}
+
+// 比较导入的修改单与当前数据，返回每行的差异以及需要写入的记录（不含未变化的行）
+func planTaskImport(tx *gorm.DB, tasks []*pb.Task) ([]*pb.ImportRowResult, []TaskInfo, error) {
+	types, err := loadTaskTypes(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var results []*pb.ImportRowResult
+	var toWrite []TaskInfo
+	inFile := make(map[string]*TaskInfo)
+	for i, t := range tasks {
+		task := common.OnePbTaskToTaskInfo(t)
+		res := &pb.ImportRowResult{Row: int32(i + 1), Key: task.TaskID, Status: IMPORT_ROW_ERROR}
+		results = append(results, res)
+		if task.TaskID == "" {
+			res.Errors = append(res.Errors, "taskId is required")
+			continue
+		}
+		if _, dup := inFile[task.TaskID]; dup {
+			res.Errors = append(res.Errors, "duplicate taskId")
+			continue
+		}
+		inFile[task.TaskID] = task
+		if t.Deadline != "" && task.Deadline.IsZero() {
+			res.Errors = append(res.Errors, fmt.Sprintf("invalid deadline %q", t.Deadline))
+			continue
+		}
+
+		before, err := findTask(tx.Unscoped(), task.TaskID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if before != nil && before.DeletedAt.Valid {
+			res.Errors = append(res.Errors, "task is in the trash, restore it first")
+			continue
+		}
+		if before != nil {
+			if t.Version != 0 && t.Version != before.Version {
+				res.Status = IMPORT_ROW_CONFLICT
+				res.Errors = append(res.Errors, fmt.Sprintf("task has been modified since version %d", t.Version))
+				continue
+			}
+			if task.ParentID == "" {
+				task.ParentID = before.ParentID
+			}
+			if task.Deadline.IsZero() {
+				task.Deadline = before.Deadline
+			}
+			task.StateBeforeBlocked = before.StateBeforeBlocked
+		}
+		if parent, ok := inFile[task.ParentID]; ok && parent != task {
+			if task.ReqNo == "" {
+				task.ReqNo = parent.ReqNo
+			} else if task.ReqNo != parent.ReqNo {
+				res.Errors = append(res.Errors, fmt.Sprintf("subtask must belong to requirement %s of its parent", parent.ReqNo))
+			}
+		} else if err := checkParent(tx, task); err != nil {
+			res.Errors = append(res.Errors, err.Error())
+		}
+		// 新建的修改单先按类型校验必填字段，再补默认的截止日期，与 AddTask 一致；
+		// 已存在的修改单按文件内容覆盖，不再补类型的默认值
+		if before == nil {
+			if err := applyTaskType(types, task, t.EmergencyLevel != nil); err != nil {
+				res.Errors = append(res.Errors, err.Error())
+			}
+		}
+		if task.Deadline.IsZero() {
+			task.Deadline = defaultTaskDeadline()
+		}
+		if len(res.Errors) > 0 {
+			continue
+		}
+		if before == nil {
+			res.Status, res.Changes = rowDiff(nil, task)
+		} else {
+			task.Version = before.Version
+			res.Status, res.Changes = rowDiff(before, task)
+		}
+		if res.Status != IMPORT_ROW_UNCHANGED {
+			toWrite = append(toWrite, *task)
+		}
+	}
+	return results, toWrite, nil
+}
+
+func planPatchImport(tx *gorm.DB, patchs []*pb.Patch) ([]*pb.ImportRowResult, []PatchsInfo, error) {
+	infos := common.AllPbPatchsToPatchsInfo(patchs)
+	var results []*pb.ImportRowResult
+	var toWrite []PatchsInfo
+	seen := make(map[string]bool)
+	for i, p := range patchs {
+		patch := &infos[i]
+		res := &pb.ImportRowResult{Row: int32(i + 1), Key: patch.PatchNo, Status: IMPORT_ROW_ERROR}
+		results = append(results, res)
+		if patch.PatchNo == "" {
+			res.Errors = append(res.Errors, "patchNo is required")
+			continue
+		}
+		if seen[patch.PatchNo] {
+			res.Errors = append(res.Errors, "duplicate patchNo")
+			continue
+		}
+		seen[patch.PatchNo] = true
+		if patch.Deadline.IsZero() {
+			res.Errors = append(res.Errors, fmt.Sprintf("invalid deadline %q", p.Deadline))
+			continue
+		}
+
+		before, err := findPatch(tx.Unscoped(), patch.PatchNo)
+		if err != nil {
+			return nil, nil, err
+		}
+		if before != nil && before.DeletedAt.Valid {
+			res.Errors = append(res.Errors, "patch is in the trash, restore it first")
+			continue
+		}
+		if before == nil {
+			res.Status, res.Changes = rowDiff(nil, patch)
+		} else if p.Version != 0 && p.Version != before.Version {
+			res.Status = IMPORT_ROW_CONFLICT
+			res.Errors = append(res.Errors, fmt.Sprintf("patch has been modified since version %d", p.Version))
+			continue
+		} else {
+			patch.Version = before.Version
+			res.Status, res.Changes = rowDiff(before, patch)
+		}
+		if res.Status != IMPORT_ROW_UNCHANGED {
+			toWrite = append(toWrite, *patch)
+		}
+	}
+	return results, toWrite, nil
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"OrderManager/common"
+	"OrderManager/pb"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+)
+
+func validateLabel(l *pb.Label) error {
+	if l == nil {
+		return errors.New("label is required")
+	}
+	if _, err := required("name", l.Name, 30); err != nil {
+		return err
+	}
+	// 标签名会出现在通知消息和审计记录中
+	if strings.ContainsAny(l.Name, "<>,") {
+		return errors.New("label name cannot contain '<', '>' or ','")
+	}
+	if l.Color != "" && !colorPattern.MatchString(l.Color) {
+		return fmt.Errorf("invalid color %q", l.Color)
+	}
+	_, err := maxLen("description", l.Description, 100)
+	return err
+}
+
+// 标签变更的通知对象：任务通知负责人，补丁通知所有人
+func labelTarget(tx *gorm.DB, entityType, entityID string) (string, error) {
+	switch entityType {
+	case AUDIT_ENTITY_TASK:
+		task, err := findTask(tx, entityID)
+		if err != nil {
+			return "", err
+		}
+		if task == nil {
+			return "", errors.New("task does not exist")
+		}
+		return task.Principal, nil
+	case AUDIT_ENTITY_PATCH:
+		patch, err := findPatch(tx, entityID)
+		if err != nil {
+			return "", err
+		}
+		if patch == nil {
+			return "", errors.New("patch does not exist")
+		}
+		return "ALL", nil
+	}
+	return "", fmt.Errorf("unknown entity type: %s", entityType)
+}
+
+// 各对象的标签名，按名称排序
+func entityLabels(tx *gorm.DB, entityType string, entityIDs []string) (map[string][]string, error) {
+	res := make(map[string][]string)
+	if len(entityIDs) == 0 {
+		return res, nil
+	}
+	var rows []struct {
+		EntityID string
+		Name     string
+	}
+	err := tx.Model(&EntityLabelInfo{}).
+		Select("entity_label_table.entity_id, label_table.name").
+		Joins("JOIN label_table ON label_table.id = entity_label_table.label_id").
+		Where("entity_label_table.entity_type = ? AND entity_label_table.entity_id IN ?", entityType, entityIDs).
+		Order("label_table.name").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		res[r.EntityID] = append(res[r.EntityID], r.Name)
+	}
+	return res, nil
+}
+
+func attachTaskLabels(tasks []*pb.Task) error {
+	ids := make([]string, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.TaskId
+	}
+	labels, err := entityLabels(db, AUDIT_ENTITY_TASK, ids)
+	if err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		t.Labels = labels[t.TaskId]
+	}
+	return nil
+}
+
+func attachPatchLabels(patchs []*pb.Patch) error {
+	ids := make([]string, len(patchs))
+	for i, p := range patchs {
+		ids[i] = p.PatchNo
+	}
+	labels, err := entityLabels(db, AUDIT_ENTITY_PATCH, ids)
+	if err != nil {
+		return err
+	}
+	for _, p := range patchs {
+		p.Labels = labels[p.PatchNo]
+	}
+	return nil
+}
+
+// 任务树中的所有任务
+func treeTasks(nodes []*pb.TaskNode) []*pb.Task {
+	var tasks []*pb.Task
+	for _, n := range nodes {
+		tasks = append(tasks, n.T)
+		tasks = append(tasks, treeTasks(n.Children)...)
+	}
+	return tasks
+}
+
+// 只保留带有全部 labels 的记录，column 为对象的主键列
+func whereLabels(query *gorm.DB, entityType, column string, labels []string) *gorm.DB {
+	for _, name := range labels {
+		query = query.Where(column+" IN (?)", db.Model(&EntityLabelInfo{}).
+			Select("entity_label_table.entity_id").
+			Joins("JOIN label_table ON label_table.id = entity_label_table.label_id").
+			Where("entity_label_table.entity_type = ? AND label_table.name = ?", entityType, name))
+	}
+	return query
+}
+
+func findLabelsByName(tx *gorm.DB, names []string) ([]LabelInfo, error) {
+	if len(names) == 0 {
+		return nil, errors.New("labels are required")
+	}
+	var labels []LabelInfo
+	if err := tx.Where("name IN ?", names).Find(&labels).Error; err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool, len(labels))
+	for _, l := range labels {
+		found[l.Name] = true
+	}
+	for _, name := range names {
+		if !found[name] {
+			return nil, fmt.Errorf("unknown label %q", name)
+		}
+	}
+	return labels, nil
+}
+
+func writeLabelAudit(ctx context.Context, tx *gorm.DB, user, entityType, entityID string, before, after []string) error {
+	oldLabels, newLabels := strings.Join(before, ","), strings.Join(after, ",")
+	if oldLabels == newLabels {
+		return nil
+	}
+	return writeAudit(ctx, tx, user, entityType, entityID, AUDIT_ACTION_UPDATE, []fieldChange{{Field: "labels", Old: oldLabels, New: newLabels}})
+}
+
+// 给对象添加或移除标签，返回修改后的标签；有变化时通知
+func changeLabels(ctx context.Context, user, entityType, entityID string, names []string, add bool) ([]string, error) {
+	var before, after []string
+	var target string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if target, err = labelTarget(tx, entityType, entityID); err != nil {
+			return err
+		}
+		labels, err := findLabelsByName(tx, names)
+		if err != nil {
+			return err
+		}
+		current, err := entityLabels(tx, entityType, []string{entityID})
+		if err != nil {
+			return err
+		}
+		before = current[entityID]
+		if add {
+			operator := operatorName(ctx, user)
+			for _, l := range labels {
+				if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&EntityLabelInfo{
+					EntityType: entityType,
+					EntityID:   entityID,
+					LabelID:    l.ID,
+					CreatedBy:  operator,
+				}).Error; err != nil {
+					return err
+				}
+			}
+		} else {
+			ids := make([]uint, len(labels))
+			for i, l := range labels {
+				ids[i] = l.ID
+			}
+			if err := tx.Where("entity_type = ? AND entity_id = ? AND label_id IN ?", entityType, entityID, ids).
+				Delete(&EntityLabelInfo{}).Error; err != nil {
+				return err
+			}
+		}
+		updated, err := entityLabels(tx, entityType, []string{entityID})
+		if err != nil {
+			return err
+		}
+		after = updated[entityID]
+		return writeLabelAudit(ctx, tx, user, entityType, entityID, before, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if strings.Join(before, ",") != strings.Join(after, ",") {
+		action := "add"
+		if !add {
+			action = "remove"
+		}
+		msg := fmt.Sprintf("<%s> -> %s labels [%s] on %s: %s -> <%s>", user, action, strings.Join(names, ","), entityType, entityID, target)
+		NotificationServer.updateDatabaseAndNotify(msg)
+	}
+	return after, nil
+}
+
+func (s *server) CreateLabel(ctx context.Context, in *pb.CreateLabelRequest) (*pb.CreateLabelReply, error) {
+	if err := validateLabel(in.L); err != nil {
+		return nil, err
+	}
+	label := &LabelInfo{
+		Name:        in.L.Name,
+		Color:       in.L.Color,
+		Description: in.L.Description,
+		CreatedBy:   operatorName(ctx, in.User),
+	}
+	if err := db.Create(label).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, errors.New("label already exists")
+		}
+		return nil, err
+	}
+	return &pb.CreateLabelReply{L: common.LabelInfoToPbLabel(*label)}, nil
+}
+
+// 改名时在每个使用该标签的对象上记录审计
+func (s *server) UpdateLabel(ctx context.Context, in *pb.UpdateLabelRequest) (*pb.UpdateLabelReply, error) {
+	if err := validateLabel(in.L); err != nil {
+		return nil, err
+	}
+	operator := operatorName(ctx, in.User)
+	var label LabelInfo
+	var oldName string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := requireAdmin(tx, operator); err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", in.L.Id).First(&label).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("label does not exist")
+			}
+			return err
+		}
+		oldName = label.Name
+		var assigned []EntityLabelInfo
+		if oldName != in.L.Name {
+			if err := tx.Where("label_id = ?", label.ID).Find(&assigned).Error; err != nil {
+				return err
+			}
+		}
+		before := make([][]string, len(assigned))
+		for i, a := range assigned {
+			current, err := entityLabels(tx, a.EntityType, []string{a.EntityID})
+			if err != nil {
+				return err
+			}
+			before[i] = current[a.EntityID]
+		}
+
+		label.Name, label.Color, label.Description = in.L.Name, in.L.Color, in.L.Description
+		if err := tx.Model(&label).Select("name", "color", "description").Updates(&label).Error; err != nil {
+			return err
+		}
+		for i, a := range assigned {
+			after, err := entityLabels(tx, a.EntityType, []string{a.EntityID})
+			if err != nil {
+				return err
+			}
+			if err := writeLabelAudit(ctx, tx, operator, a.EntityType, a.EntityID, before[i], after[a.EntityID]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil, errors.New("label already exists")
+	} else if err != nil {
+		return nil, err
+	}
+	if oldName != label.Name {
+		msg := fmt.Sprintf("<%s> -> rename label: %s to %s -> <ALL>", operator, oldName, label.Name)
+		NotificationServer.updateDatabaseAndNotify(msg)
+	}
+	return &pb.UpdateLabelReply{L: common.LabelInfoToPbLabel(label)}, nil
+}
+
+func (s *server) DeleteLabel(ctx context.Context, in *pb.DeleteLabelRequest) (*pb.DeleteLabelReply, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := requireAdmin(tx, operatorName(ctx, in.User)); err != nil {
+			return err
+		}
+		var label LabelInfo
+		if err := tx.Where("id = ?", in.Id).First(&label).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("label does not exist")
+			}
+			return err
+		}
+		var rules []string
+		if err := tx.Model(&EscalationRuleInfo{}).Where("patch_label_id = ?", label.ID).Pluck("name", &rules).Error; err != nil {
+			return err
+		}
+		if len(rules) > 0 {
+			return fmt.Errorf("label is used by escalation rules: %s", strings.Join(rules, ", "))
+		}
+
+		// 从所有对象上移除，并逐个记录审计
+		var assigned []EntityLabelInfo
+		if err := tx.Where("label_id = ?", label.ID).Find(&assigned).Error; err != nil {
+			return err
+		}
+		for _, a := range assigned {
+			current, err := entityLabels(tx, a.EntityType, []string{a.EntityID})
+			if err != nil {
+				return err
+			}
+			var after []string
+			for _, name := range current[a.EntityID] {
+				if name != label.Name {
+					after = append(after, name)
+				}
+			}
+			if err := writeLabelAudit(ctx, tx, in.User, a.EntityType, a.EntityID, current[a.EntityID], after); err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("label_id = ?", label.ID).Delete(&EntityLabelInfo{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&label).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DeleteLabelReply{}, nil
+}
+
+func (s *server) ListLabels(ctx context.Context, in *pb.ListLabelsRequest) (*pb.ListLabelsReply, error) {
+	var labels []LabelInfo
+	if err := db.Order("name").Find(&labels).Error; err != nil {
+		return nil, err
+	}
+	return &pb.ListLabelsReply{Labels: common.AllLabelInfoToPbLabel(labels)}, nil
+}
+
+func (s *server) AddLabels(ctx context.Context, in *pb.AddLabelsRequest) (*pb.AddLabelsReply, error) {
+	if err := requireEntityScope(ctx, in.EntityType); err != nil {
+		return nil, err
+	}
+	labels, err := changeLabels(ctx, in.User, in.EntityType, in.EntityId, in.Labels, true)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.AddLabelsReply{Labels: labels}, nil
+}
+
+func (s *server) RemoveLabels(ctx context.Context, in *pb.RemoveLabelsRequest) (*pb.RemoveLabelsReply, error) {
+	if err := requireEntityScope(ctx, in.EntityType); err != nil {
+		return nil, err
+	}
+	labels, err := changeLabels(ctx, in.User, in.EntityType, in.EntityId, in.Labels, false)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.RemoveLabelsReply{Labels: labels}, nil
+}
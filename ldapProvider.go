@@ -0,0 +1,170 @@
+package main
+
+import (
+	"OrderManager/config"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-ldap/ldap/v3"
+	"gorm.io/gorm"
+	"log"
+	"strconv"
+)
+
+// 目录条目，attrs 为原始属性
+type directoryEntry struct {
+	dn    string
+	attrs map[string]string
+}
+
+// 目录访问接口，便于用进程内的替身代替真实 LDAP 服务
+type directory interface {
+	// 按用户名查找条目，不存在时返回 nil, nil
+	lookup(name string) (*directoryEntry, error)
+	// 以用户身份 bind，密码错误返回 errDirectoryBindFailed
+	bind(dn, password string) error
+	close()
+}
+
+var errDirectoryBindFailed = errors.New("directory bind failed")
+
+var dialDirectory = dialLDAP
+
+type ldapProvider struct{}
+
+func (ldapProvider) authenticate(ctx context.Context, name, password string, existing *UserInfo) (*UserInfo, error) {
+	// 空密码在 LDAP 中是匿名 bind，会直接成功
+	if password == "" {
+		return nil, &authFailure{reason: "empty password"}
+	}
+	dir, err := dialDirectory()
+	if err != nil {
+		return nil, err
+	}
+	defer dir.close()
+
+	entry, err := dir.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, &authFailure{reason: "unknown user"}
+	}
+	if err := dir.bind(entry.dn, password); err != nil {
+		if errors.Is(err, errDirectoryBindFailed) {
+			return nil, &authFailure{reason: "wrong password"}
+		}
+		return nil, err
+	}
+
+	if existing != nil && !existing.Active {
+		return nil, &authFailure{reason: "deactivated user"}
+	}
+	return syncDirectoryUser(entryToUserInfo(entry, name), existing)
+}
+
+func entryToUserInfo(entry *directoryEntry, name string) UserInfo {
+	user := UserInfo{
+		Name:   entry.attrs[config.LDAP_ATTR_NAME],
+		Email:  optionalEmail(entry.attrs[config.LDAP_ATTR_EMAIL]),
+		Source: AUTH_SOURCE_LDAP,
+		Active: true,
+	}
+	if user.Name == "" {
+		user.Name = name
+	}
+	// 没有工号或工号不是数字时留空，不能都记为 0，否则会与其他用户的工号冲突
+	if raw := entry.attrs[config.LDAP_ATTR_JOB_NO]; raw != "" {
+		if jobNo, err := strconv.Atoi(raw); err == nil {
+			user.JobNo = optionalJobNo(int64(jobNo))
+		} else {
+			log.Printf("%s 的工号 %q 无效，已忽略", user.Name, raw)
+		}
+	}
+	user.Group, _ = strconv.Atoi(entry.attrs[config.LDAP_ATTR_GROUP])
+	return user
+}
+
+// 首次登录时创建本地用户，之后每次登录同步工号、邮箱和分组
+func syncDirectoryUser(fromDir UserInfo, existing *UserInfo) (*UserInfo, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if existing == nil {
+			if err := checkUserUnique(tx, 0, fromDir.Name, fromDir.JobNo, fromDir.Email); err != nil {
+				return err
+			}
+			return tx.Create(&fromDir).Error
+		}
+		if sameJobNo(existing.JobNo, fromDir.JobNo) && sameEmail(existing.Email, fromDir.Email) && existing.Group == fromDir.Group {
+			return nil
+		}
+		if err := checkUserUnique(tx, existing.ID, existing.Name, fromDir.JobNo, fromDir.Email); err != nil {
+			return err
+		}
+		return tx.Model(existing).Updates(map[string]interface{}{
+			"job_no": fromDir.JobNo,
+			"email":  fromDir.Email,
+			"group":  fromDir.Group,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return &fromDir, nil
+	}
+	return existing, nil
+}
+
+type ldapDirectory struct {
+	conn *ldap.Conn
+}
+
+func dialLDAP() (directory, error) {
+	conn, err := ldap.DialURL(config.LDAP_URL)
+	if err != nil {
+		return nil, err
+	}
+	if config.LDAP_BIND_DN != "" {
+		if err := conn.Bind(config.LDAP_BIND_DN, config.LDAP_BIND_PASSWORD); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return &ldapDirectory{conn: conn}, nil
+}
+
+func (d *ldapDirectory) lookup(name string) (*directoryEntry, error) {
+	attrs := []string{config.LDAP_ATTR_NAME, config.LDAP_ATTR_JOB_NO, config.LDAP_ATTR_EMAIL, config.LDAP_ATTR_GROUP}
+	req := ldap.NewSearchRequest(config.LDAP_BASE_DN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, 10, false,
+		fmt.Sprintf(config.LDAP_USER_FILTER, ldap.EscapeFilter(name)), attrs, nil)
+	res, err := d.conn.Search(req)
+	if err != nil {
+		return nil, err
+	}
+	switch len(res.Entries) {
+	case 0:
+		return nil, nil
+	case 1:
+	default:
+		return nil, fmt.Errorf("directory returned %d entries for %s", len(res.Entries), name)
+	}
+	entry := &directoryEntry{dn: res.Entries[0].DN, attrs: make(map[string]string, len(attrs))}
+	for _, attr := range attrs {
+		entry.attrs[attr] = res.Entries[0].GetAttributeValue(attr)
+	}
+	return entry, nil
+}
+
+func (d *ldapDirectory) bind(dn, password string) error {
+	if err := d.conn.Bind(dn, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return errDirectoryBindFailed
+		}
+		return err
+	}
+	return nil
+}
+
+func (d *ldapDirectory) close() {
+	d.conn.Close()
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"OrderManager/config"
+	"context"
+	"errors"
+	"testing"
+)
+
+// 进程内的目录替身，按用户名保存条目，按 dn 保存密码
+type fakeDirectory struct {
+	entries   map[string]*directoryEntry
+	passwords map[string]string
+	closed    bool
+}
+
+func (d *fakeDirectory) lookup(name string) (*directoryEntry, error) {
+	return d.entries[name], nil
+}
+
+func (d *fakeDirectory) bind(dn, password string) error {
+	if p, ok := d.passwords[dn]; !ok || p != password {
+		return errDirectoryBindFailed
+	}
+	return nil
+}
+
+func (d *fakeDirectory) close() {
+	d.closed = true
+}
+
+func newFakeDirectory(t *testing.T) *fakeDirectory {
+	t.Helper()
+	dir := &fakeDirectory{
+		entries: map[string]*directoryEntry{
+			"alice": {dn: "uid=alice,ou=people,dc=example,dc=com", attrs: map[string]string{
+				config.LDAP_ATTR_NAME:   "alice",
+				config.LDAP_ATTR_JOB_NO: "1001",
+				config.LDAP_ATTR_EMAIL:  "alice@example.com",
+				config.LDAP_ATTR_GROUP:  "3",
+			}},
+		},
+		passwords: map[string]string{"uid=alice,ou=people,dc=example,dc=com": "secret"},
+	}
+	saved := dialDirectory
+	dialDirectory = func() (directory, error) { return dir, nil }
+	t.Cleanup(func() { dialDirectory = saved })
+	return dir
+}
+
+func authFailureReason(err error) string {
+	var failure *authFailure
+	if errors.As(err, &failure) {
+		return failure.reason
+	}
+	return ""
+}
+
+func TestLDAPAuthenticateFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     string
+		password string
+		existing *UserInfo
+		reason   string
+	}{
+		{name: "empty password", user: "alice", password: "", reason: "empty password"},
+		{name: "unknown user", user: "bob", password: "secret", reason: "unknown user"},
+		{name: "bind failure", user: "alice", password: "wrong", reason: "wrong password"},
+		{name: "deactivated user", user: "alice", password: "secret", existing: &UserInfo{Name: "alice", Source: AUTH_SOURCE_LDAP}, reason: "deactivated user"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := newFakeDirectory(t)
+			user, err := ldapProvider{}.authenticate(context.Background(), tt.user, tt.password, tt.existing)
+			if user != nil {
+				t.Errorf("authenticate returned user %+v", user)
+			}
+			if got := authFailureReason(err); got != tt.reason {
+				t.Errorf("failure reason = %q (err %v), want %q", got, err, tt.reason)
+			}
+			if tt.password != "" && !dir.closed {
+				t.Error("directory connection was not closed")
+			}
+		})
+	}
+}
+
+func TestEntryToUserInfo(t *testing.T) {
+	tests := []struct {
+		name  string
+		attrs map[string]string
+		want  UserInfo
+	}{
+		{
+			name:  "all attributes",
+			attrs: map[string]string{config.LDAP_ATTR_NAME: "alice", config.LDAP_ATTR_JOB_NO: "1001", config.LDAP_ATTR_EMAIL: "alice@example.com", config.LDAP_ATTR_GROUP: "3"},
+			want:  UserInfo{Name: "alice", JobNo: optionalJobNo(1001), Email: optionalEmail("alice@example.com"), Group: 3},
+		},
+		{
+			name:  "missing attributes are left empty",
+			attrs: map[string]string{},
+			want:  UserInfo{Name: "login-name"},
+		},
+		{
+			name:  "invalid employee number",
+			attrs: map[string]string{config.LDAP_ATTR_NAME: "alice", config.LDAP_ATTR_JOB_NO: "A-17"},
+			want:  UserInfo{Name: "alice"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := entryToUserInfo(&directoryEntry{attrs: tt.attrs}, "login-name")
+			if got.Name != tt.want.Name || !sameJobNo(got.JobNo, tt.want.JobNo) || !sameEmail(got.Email, tt.want.Email) || got.Group != tt.want.Group {
+				t.Errorf("entryToUserInfo = %+v, want %+v", got, tt.want)
+			}
+			if got.Source != AUTH_SOURCE_LDAP || !got.Active {
+				t.Errorf("source, active = %q, %v, want ldap, true", got.Source, got.Active)
+			}
+		})
+	}
+}
+
+func TestLDAPFirstLoginCreatesUser(t *testing.T) {
+	requireTestDB(t, &UserInfo{})
+	newFakeDirectory(t)
+	user, err := ldapProvider{}.authenticate(context.Background(), "alice", "secret", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := findUserByName(db, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.ID != user.ID || stored.Source != AUTH_SOURCE_LDAP || !stored.Active {
+		t.Errorf("stored user = %+v", stored)
+	}
+	if !sameJobNo(stored.JobNo, optionalJobNo(1001)) || !sameEmail(stored.Email, optionalEmail("alice@example.com")) || stored.Group != 3 {
+		t.Errorf("stored attributes = %v, %v, %d", stored.JobNo, stored.Email, stored.Group)
+	}
+}
+
+func TestLDAPLoginSyncsAttributes(t *testing.T) {
+	requireTestDB(t, &UserInfo{})
+	dir := newFakeDirectory(t)
+	existing := UserInfo{Name: "alice", JobNo: optionalJobNo(1), Email: optionalEmail("old@example.com"), Group: 1, Source: AUTH_SOURCE_LDAP, Active: true}
+	if err := db.Create(&existing).Error; err != nil {
+		t.Fatal(err)
+	}
+	dir.entries["alice"].attrs[config.LDAP_ATTR_EMAIL] = ""
+	if _, err := (ldapProvider{}).authenticate(context.Background(), "alice", "secret", &existing); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := findUserByName(db, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameJobNo(stored.JobNo, optionalJobNo(1001)) || stored.Email != nil || stored.Group != 3 {
+		t.Errorf("synced attributes = %v, %v, %d, want 1001, nil, 3", stored.JobNo, stored.Email, stored.Group)
+	}
+}
+
+func TestLDAPFirstLoginRejectsTakenJobNo(t *testing.T) {
+	requireTestDB(t, &UserInfo{})
+	newFakeDirectory(t)
+	other := UserInfo{Name: "carol", JobNo: optionalJobNo(1001), Source: AUTH_SOURCE_LOCAL, Active: true}
+	if err := db.Create(&other).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (ldapProvider{}).authenticate(context.Background(), "alice", "secret", nil); err == nil {
+		t.Fatal("authenticate created a user with a job number already in use")
+	}
+	if _, err := findUserByName(db, "alice"); err == nil {
+		t.Error("user alice was created")
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"google.golang.org/grpc/peer"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	accountMaxFailures = 5
+	ipMaxFailures      = 20
+	lockoutBase        = time.Minute
+	lockoutMax         = time.Hour
+	failureWindow      = time.Hour
+)
+
+type attemptState struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// 按账号和客户端 IP 分别统计登录失败次数，超过阈值后锁定，锁定时长按失败次数指数增长
+type loginLimiter struct {
+	mu       sync.Mutex
+	attempts map[string]*attemptState
+}
+
+var loginGuard = &loginLimiter{attempts: make(map[string]*attemptState)}
+
+func accountKey(name string) string { return "user:" + name }
+func ipKey(ip string) string        { return "ip:" + ip }
+
+// 返回剩余锁定时长，0 表示未锁定
+func (l *loginLimiter) lockedFor(name, ip string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var wait time.Duration
+	for _, key := range []string{accountKey(name), ipKey(ip)} {
+		if st, ok := l.attempts[key]; ok && st.lockedUntil.After(now) {
+			if d := st.lockedUntil.Sub(now); d > wait {
+				wait = d
+			}
+		}
+	}
+	return wait
+}
+
+// 记录一次失败，返回本次是否触发了新的锁定
+func (l *loginLimiter) fail(name, ip string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	locked := false
+	for key, max := range map[string]int{accountKey(name): accountMaxFailures, ipKey(ip): ipMaxFailures} {
+		st, ok := l.attempts[key]
+		if !ok || now.Sub(st.lastFailure) > failureWindow {
+			st = &attemptState{}
+			l.attempts[key] = st
+		}
+		st.failures++
+		st.lastFailure = now
+		if st.failures >= max {
+			backoff := lockoutBase << uint(st.failures-max)
+			if backoff > lockoutMax || backoff <= 0 {
+				backoff = lockoutMax
+			}
+			st.lockedUntil = now.Add(backoff)
+			locked = true
+		}
+	}
+	return locked
+}
+
+// 登录成功后清空账号计数，IP 计数按时间窗口自然过期
+func (l *loginLimiter) succeed(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.attempts, accountKey(name))
+}
+
+// 清理已过期的记录，防止 map 无限增长
+func (l *loginLimiter) cleanup(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key, st := range l.attempts {
+		if now.Sub(st.lastFailure) > failureWindow && now.After(st.lockedUntil) {
+			delete(l.attempts, key)
+		}
+	}
+}
+
+func loginLimiterClock() {
+	for {
+		time.Sleep(10 * time.Minute)
+		loginGuard.cleanup(time.Now())
+	}
+}
+
+func peerAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// 不含端口的客户端 IP
+func peerIP(ctx context.Context) string {
+	addr := peerAddr(ctx)
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
@@ -5,12 +5,14 @@ import (
 	"OrderManager/models"
 	"OrderManager/pb"
 	"context"
+	"fmt"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/peer"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"log"
 	"net"
+	"os"
 )
 
 const (
@@ -21,6 +23,10 @@ const (
 	EMERGENCY_LEVEL_0 = 0
 	EMERGENCY_LEVEL_1 = 1
 	EMERGENCY_LEVEL_2 = 2
+
+	ROLE_MEMBER = 0
+	ROLE_LEADER = 1
+	ROLE_ADMIN  = 2
 )
 
 var db *gorm.DB
@@ -29,20 +35,42 @@ type TaskInfo = models.TaskInfo
 
 type PatchsInfo = models.PatchsInfo
 type UserInfo = models.UserInfo
+type SessionInfo = models.SessionInfo
+type PasswordResetInfo = models.PasswordResetInfo
+type AuthEventInfo = models.AuthEventInfo
+type APIKeyInfo = models.APIKeyInfo
+type AuditLogInfo = models.AuditLogInfo
+type CommentInfo = models.CommentInfo
+type WorkLogInfo = models.WorkLogInfo
+type TaskDependencyInfo = models.TaskDependencyInfo
+type TaskTypeInfo = models.TaskTypeInfo
+type LabelInfo = models.LabelInfo
+type EntityLabelInfo = models.EntityLabelInfo
+type ImportBatchInfo = models.ImportBatchInfo
+type ImportBatchRowInfo = models.ImportBatchRowInfo
+type CalendarTokenInfo = models.CalendarTokenInfo
+type ExternalIssueInfo = models.ExternalIssueInfo
+type TaskCommitInfo = models.TaskCommitInfo
+type WorkScheduleInfo = models.WorkScheduleInfo
+type LeaveInfo = models.LeaveInfo
+type BusinessDayInfo = models.BusinessDayInfo
+type EscalationRuleInfo = models.EscalationRuleInfo
+type EscalationInfo = models.EscalationInfo
 
-func init() {
-	tmpDb, err := gorm.Open(mysql.Open(config.GORM_DNS), &gorm.Config{})
+// 连接数据库并建表，测试时连接单独的测试库
+func openDatabase(dsn string) error {
+	tmpDb, err := gorm.Open(mysql.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		return fmt.Errorf("failed to connect to database: %v", err)
 	}
 	db = tmpDb
-	err = db.AutoMigrate(&TaskInfo{}, &PatchsInfo{}, &UserInfo{})
-	if err != nil {
-		log.Fatal(err)
+	if err := migrateUserKeys(db); err != nil {
+		return err
 	}
+	return db.AutoMigrate(&TaskInfo{}, &PatchsInfo{}, &UserInfo{}, &SessionInfo{}, &PasswordResetInfo{}, &AuthEventInfo{}, &APIKeyInfo{}, &AuditLogInfo{}, &CommentInfo{}, &WorkLogInfo{}, &TaskDependencyInfo{}, &TaskTypeInfo{}, &LabelInfo{}, &EntityLabelInfo{}, &ImportBatchInfo{}, &ImportBatchRowInfo{}, &CalendarTokenInfo{}, &ExternalIssueInfo{}, &TaskCommitInfo{}, &WorkScheduleInfo{}, &LeaveInfo{}, &BusinessDayInfo{}, &EscalationRuleInfo{}, &EscalationInfo{})
 }
 
-// 获得客户端ip端口
+// 获得客户端ip端口，并校验登录 token / API Key
 func unaryInterceptor(
 	ctx context.Context,
 	req interface{},
@@ -54,13 +82,62 @@ func unaryInterceptor(
 	if ok {
 		log.Printf("Received request from:%s", p.String())
 	}
+	ctx, err := authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeAPIKey(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
 	return handler(ctx, req)
 }
 
+// 流式 RPC 的 Context 需要替换为带有登录用户的 Context
+type authedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authedStream) Context() context.Context {
+	return s.ctx
+}
+
+// 流式 RPC 同样校验登录 token / API Key
+func streamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	ctx, err := authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	if err := authorizeAPIKey(ctx, info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, &authedStream{ServerStream: ss, ctx: ctx})
+}
+
 func main() {
+	if err := openDatabase(config.GORM_DNS); err != nil {
+		log.Fatal(err)
+	}
+	// 带有子命令时只执行备份或恢复
+	if len(os.Args) > 1 {
+		if err := runBackupCommand(os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 	go emailClock()
+	go loginLimiterClock()
+	go trashPurgeClock()
+	go escalationClock()
+	go calendarServe()
+	go gitWebhookServe()
 	//go testSendEmail()
-	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(unaryInterceptor))
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(unaryInterceptor), grpc.StreamInterceptor(streamInterceptor))
 	pb.RegisterServiceServer(grpcServer, Server)
 
 	pb.RegisterNotificationServiceServer(grpcServer, NotificationServer)
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"gorm.io/gorm"
+	"log"
+	"os"
+	"testing"
+)
+
+// 需要数据库的测试连接 ORDERMANAGER_TEST_DSN 指定的 MySQL 测试库，会清空其中的表；未设置时跳过
+func TestMain(m *testing.M) {
+	if dsn := os.Getenv("ORDERMANAGER_TEST_DSN"); dsn != "" {
+		if err := openDatabase(dsn); err != nil {
+			log.Fatal(err)
+		}
+	}
+	os.Exit(m.Run())
+}
+
+// 跳过需要数据库的测试，并清空给定的表
+func requireTestDB(t *testing.T, models ...interface{}) {
+	t.Helper()
+	if db == nil {
+		t.Skip("ORDERMANAGER_TEST_DSN is not set")
+	}
+	for _, model := range models {
+		if err := db.Session(&gorm.Session{AllowGlobalUpdate: true}).Unscoped().Delete(model).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"gorm.io/gorm"
 	"time"
 )
 
@@ -14,6 +15,16 @@ type TaskInfo struct {
 	EstimatedWorkHours float64   `gorm:"column:estimated_work_hours;default:16;comment:预计工时"`
 	State              string    `gorm:"column:state;type:varchar(20);default:带启动;comment:任务状态"`
 	Type               int       `gorm:"column:type;default:0;comment:任务类型"`
+	ParentID           string    `gorm:"column:parent_id;type:varchar(25);not null;default:'';index:tasklist_parent_id_index;comment:父任务单号"`
+	Version            int64     `gorm:"column:version;not null;default:0;comment:版本号" audit:"-"`
+	UpdatedAt          time.Time `gorm:"column:updated_at;comment:更新时间" audit:"-"`
+	// 被置为阻塞前的状态，前置修改单全部完成后恢复
+	StateBeforeBlocked string `gorm:"column:state_before_blocked;type:varchar(20);not null;default:'';comment:阻塞前的状态" audit:"-"`
+	// 软删除，回收站保留一段时间后清理
+	DeletedAt        gorm.DeletedAt `gorm:"column:deleted_at;index:tasklist_deleted_at_index;comment:删除时间"`
+	DeletedBy        string         `gorm:"column:deleted_by;type:varchar(20);comment:删除人"`
+	DeleteReason     string         `gorm:"column:delete_reason;type:varchar(100);comment:删除原因"`
+	DeletedWithPatch string         `gorm:"column:deleted_with_patch;type:varchar(20);index:tasklist_deleted_with_patch_index;comment:随补丁一同删除时的补丁号"`
 }
 
 func (TaskInfo) TableName() string {
@@ -29,6 +40,12 @@ type PatchsInfo struct {
 	Reason     string    `gorm:"column:reason;type:varchar(100);comment:补丁原因"`
 	Sponsor    string    `gorm:"column:sponsor;type:varchar(20);not null;comment:发起人"`
 	State      string    `gorm:"column:state;type:varchar(10);comment:发布状态"`
+	Version    int64     `gorm:"column:version;not null;default:0;comment:版本号" audit:"-"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;comment:更新时间" audit:"-"`
+	// 软删除，回收站保留一段时间后清理
+	DeletedAt    gorm.DeletedAt `gorm:"column:deleted_at;index:patch_table_deleted_at_index;comment:删除时间"`
+	DeletedBy    string         `gorm:"column:deleted_by;type:varchar(20);comment:删除人"`
+	DeleteReason string         `gorm:"column:delete_reason;type:varchar(100);comment:删除原因"`
 }
 
 func (PatchsInfo) TableName() string {
@@ -36,14 +53,334 @@ func (PatchsInfo) TableName() string {
 }
 
 type UserInfo struct {
-	Name     string `gorm:"column:name;type:varchar(20);not null;index;comment:姓名"`
-	JobNo    int    `gorm:"column:job_no;not null;comment:工号"`
-	Password string `gorm:"column:password;type:varchar(60);not null;comment:密码"`
-	Email    string `gorm:"column:email;type:varchar(50);not null;comment:邮箱"`
-	Group    int    `gorm:"column:group;default:0;comment:分组编号"`
-	RoleNo   int    `gorm:"column:role_no;default:0;comment:角色编号"`
+	ID   uint   `gorm:"column:id;primaryKey;autoIncrement;comment:用户ID"`
+	Name string `gorm:"column:name;type:varchar(20);not null;uniqueIndex:user_table_name_uindex;comment:姓名"`
+	// 工号和邮箱可以为空，为空时存 NULL，不参与唯一性约束
+	JobNo    *int    `gorm:"column:job_no;uniqueIndex:user_table_job_no_uindex;comment:工号"`
+	Password string  `gorm:"column:password;type:varchar(60);not null;comment:密码"`
+	Email    *string `gorm:"column:email;type:varchar(50);uniqueIndex:user_table_email_uindex;comment:邮箱"`
+	Group    int     `gorm:"column:group;default:0;comment:分组编号"`
+	RoleNo   int     `gorm:"column:role_no;default:0;comment:角色编号"`
+	Active   bool    `gorm:"column:active;not null;default:true;comment:是否启用"`
+	Source   string  `gorm:"column:source;type:varchar(10);not null;default:local;comment:认证来源"`
 }
 
 func (UserInfo) TableName() string {
 	return "user_table"
 }
+
+// 登录会话，只保存 token 的哈希
+type SessionInfo struct {
+	TokenHash string    `gorm:"column:token_hash;type:char(64);primaryKey;comment:token哈希"`
+	UserID    uint      `gorm:"column:user_id;not null;index:session_table_user_id_index;comment:用户ID"`
+	CreatedAt time.Time `gorm:"column:created_at;comment:创建时间"`
+	ExpiresAt time.Time `gorm:"column:expires_at;not null;comment:过期时间"`
+}
+
+func (SessionInfo) TableName() string {
+	return "session_table"
+}
+
+// 密码重置验证码，只保存验证码的哈希
+type PasswordResetInfo struct {
+	ID        uint       `gorm:"column:id;primaryKey;autoIncrement"`
+	UserID    uint       `gorm:"column:user_id;not null;index:password_reset_table_user_id_index;comment:用户ID"`
+	CodeHash  string     `gorm:"column:code_hash;type:varchar(60);not null;comment:验证码哈希"`
+	Attempts  int        `gorm:"column:attempts;default:0;comment:错误尝试次数"`
+	CreatedAt time.Time  `gorm:"column:created_at;comment:创建时间"`
+	ExpiresAt time.Time  `gorm:"column:expires_at;not null;comment:过期时间"`
+	UsedAt    *time.Time `gorm:"column:used_at;comment:使用时间"`
+}
+
+func (PasswordResetInfo) TableName() string {
+	return "password_reset_table"
+}
+
+// 认证事件日志，只追加
+type AuthEventInfo struct {
+	ID        uint      `gorm:"column:id;primaryKey;autoIncrement"`
+	CreatedAt time.Time `gorm:"column:created_at;index:auth_event_table_created_at_index;comment:发生时间"`
+	Event     string    `gorm:"column:event;type:varchar(30);not null;index:auth_event_table_event_index;comment:事件类型"`
+	Name      string    `gorm:"column:name;type:varchar(20);index:auth_event_table_name_index;comment:用户名"`
+	PeerAddr  string    `gorm:"column:peer_addr;type:varchar(64);comment:客户端地址"`
+	Detail    string    `gorm:"column:detail;type:varchar(200);comment:详情"`
+}
+
+func (AuthEventInfo) TableName() string {
+	return "auth_event_table"
+}
+
+// 服务账号的 API Key，只保存 key 的哈希
+type APIKeyInfo struct {
+	ID          uint       `gorm:"column:id;primaryKey;autoIncrement"`
+	UserID      uint       `gorm:"column:user_id;not null;index:api_key_table_user_id_index;comment:服务账号ID"`
+	Prefix      string     `gorm:"column:prefix;type:varchar(16);not null;comment:key前缀，用于识别"`
+	KeyHash     string     `gorm:"column:key_hash;type:char(64);not null;uniqueIndex:api_key_table_key_hash_uindex;comment:key哈希"`
+	Scopes      string     `gorm:"column:scopes;type:varchar(100);not null;comment:权限范围，逗号分隔"`
+	Description string     `gorm:"column:description;type:varchar(100);comment:用途"`
+	CreatedBy   string     `gorm:"column:created_by;type:varchar(20);comment:创建人"`
+	CreatedAt   time.Time  `gorm:"column:created_at;comment:创建时间"`
+	ExpiresAt   *time.Time `gorm:"column:expires_at;comment:过期时间"`
+	LastUsedAt  *time.Time `gorm:"column:last_used_at;comment:最近使用时间"`
+	RevokedAt   *time.Time `gorm:"column:revoked_at;comment:吊销时间"`
+}
+
+func (APIKeyInfo) TableName() string {
+	return "api_key_table"
+}
+
+// 任务与补丁的变更记录，只追加
+type AuditLogInfo struct {
+	ID         uint      `gorm:"column:id;primaryKey;autoIncrement"`
+	CreatedAt  time.Time `gorm:"column:created_at;index:audit_log_table_created_at_index;comment:发生时间"`
+	Actor      string    `gorm:"column:actor;type:varchar(20);index:audit_log_table_actor_index;comment:操作人"`
+	Method     string    `gorm:"column:method;type:varchar(80);comment:RPC"`
+	EntityType string    `gorm:"column:entity_type;type:varchar(10);not null;index:audit_log_table_entity_index;comment:对象类型"`
+	EntityID   string    `gorm:"column:entity_id;type:varchar(40);not null;index:audit_log_table_entity_index;comment:对象ID"`
+	Action     string    `gorm:"column:action;type:varchar(10);not null;comment:操作"`
+	Changes    string    `gorm:"column:changes;type:text;comment:字段变更(JSON)"`
+	PeerAddr   string    `gorm:"column:peer_addr;type:varchar(64);comment:客户端地址"`
+}
+
+func (AuditLogInfo) TableName() string {
+	return "audit_log_table"
+}
+
+// 修改单/补丁的评论，每个修改单、每个补丁各有一个讨论串
+type CommentInfo struct {
+	ID         uint           `gorm:"column:id;primaryKey;autoIncrement"`
+	EntityType string         `gorm:"column:entity_type;type:varchar(10);not null;index:comment_table_entity_index;comment:对象类型"`
+	EntityID   string         `gorm:"column:entity_id;type:varchar(40);not null;index:comment_table_entity_index;comment:对象ID"`
+	Author     string         `gorm:"column:author;type:varchar(20);not null;comment:作者"`
+	Content    string         `gorm:"column:content;type:text;not null;comment:内容"`
+	Mentions   string         `gorm:"column:mentions;type:varchar(255);comment:@到的用户，逗号分隔"`
+	CreatedAt  time.Time      `gorm:"column:created_at;comment:创建时间"`
+	UpdatedAt  time.Time      `gorm:"column:updated_at;comment:修改时间"`
+	DeletedAt  gorm.DeletedAt `gorm:"column:deleted_at;index;comment:删除时间"`
+}
+
+func (CommentInfo) TableName() string {
+	return "comment_table"
+}
+
+// 实际工时记录
+type WorkLogInfo struct {
+	ID        uint      `gorm:"column:id;primaryKey;autoIncrement"`
+	TaskID    string    `gorm:"column:task_id;type:varchar(25);not null;index:work_log_table_task_id_index;comment:任务单号"`
+	Name      string    `gorm:"column:name;type:varchar(20);not null;index:work_log_table_name_date_index;comment:填报人"`
+	WorkDate  time.Time `gorm:"column:work_date;type:date;not null;index:work_log_table_name_date_index;comment:工作日期"`
+	Hours     float64   `gorm:"column:hours;not null;comment:工时"`
+	Note      string    `gorm:"column:note;type:varchar(200);comment:备注"`
+	CreatedAt time.Time `gorm:"column:created_at;comment:填报时间"`
+}
+
+func (WorkLogInfo) TableName() string {
+	return "work_log_table"
+}
+
+// 修改单之间的依赖关系：TaskID 依赖于 DependsOn，前置修改单完成前 TaskID 处于阻塞状态
+type TaskDependencyInfo struct {
+	ID        uint      `gorm:"column:id;primaryKey;autoIncrement"`
+	TaskID    string    `gorm:"column:task_id;type:varchar(25);not null;uniqueIndex:task_dependency_table_task_id_depends_on_uindex;comment:任务单号"`
+	DependsOn string    `gorm:"column:depends_on;type:varchar(25);not null;uniqueIndex:task_dependency_table_task_id_depends_on_uindex;index:task_dependency_table_depends_on_index;comment:前置任务单号"`
+	CreatedBy string    `gorm:"column:created_by;type:varchar(20);comment:创建人"`
+	CreatedAt time.Time `gorm:"column:created_at;comment:创建时间"`
+}
+
+func (TaskDependencyInfo) TableName() string {
+	return "task_dependency_table"
+}
+
+// 任务类型，对应 TaskInfo.Type
+type TaskTypeInfo struct {
+	ID                    int       `gorm:"column:id;primaryKey;autoIncrement:false;comment:类型编号"`
+	Name                  string    `gorm:"column:name;type:varchar(20);not null;uniqueIndex:task_type_table_name_uindex;comment:类型名称"`
+	Color                 string    `gorm:"column:color;type:varchar(7);comment:显示颜色"`
+	DefaultEstimatedHours float64   `gorm:"column:default_estimated_hours;not null;default:0;comment:默认预计工时"`
+	DefaultEmergencyLevel int       `gorm:"column:default_emergency_level;not null;default:0;comment:默认紧急程度"`
+	RequiredFields        string    `gorm:"column:required_fields;type:varchar(200);comment:必填字段，逗号分隔"`
+	CreatedAt             time.Time `gorm:"column:created_at;comment:创建时间"`
+	UpdatedAt             time.Time `gorm:"column:updated_at;comment:更新时间"`
+}
+
+func (TaskTypeInfo) TableName() string {
+	return "task_type_table"
+}
+
+// 标签
+type LabelInfo struct {
+	ID          uint      `gorm:"column:id;primaryKey;autoIncrement"`
+	Name        string    `gorm:"column:name;type:varchar(30);not null;uniqueIndex:label_table_name_uindex;comment:标签名"`
+	Color       string    `gorm:"column:color;type:varchar(7);comment:显示颜色"`
+	Description string    `gorm:"column:description;type:varchar(100);comment:说明"`
+	CreatedBy   string    `gorm:"column:created_by;type:varchar(20);comment:创建人"`
+	CreatedAt   time.Time `gorm:"column:created_at;comment:创建时间"`
+}
+
+func (LabelInfo) TableName() string {
+	return "label_table"
+}
+
+// 任务、补丁与标签的多对多关系
+type EntityLabelInfo struct {
+	ID         uint      `gorm:"column:id;primaryKey;autoIncrement"`
+	EntityType string    `gorm:"column:entity_type;type:varchar(10);not null;uniqueIndex:entity_label_table_entity_label_uindex;comment:对象类型 task/patch"`
+	EntityID   string    `gorm:"column:entity_id;type:varchar(25);not null;uniqueIndex:entity_label_table_entity_label_uindex;comment:任务单号或补丁号"`
+	LabelID    uint      `gorm:"column:label_id;not null;uniqueIndex:entity_label_table_entity_label_uindex;index:entity_label_table_label_id_index;comment:标签编号"`
+	CreatedBy  string    `gorm:"column:created_by;type:varchar(20);comment:添加人"`
+	CreatedAt  time.Time `gorm:"column:created_at;comment:添加时间"`
+}
+
+func (EntityLabelInfo) TableName() string {
+	return "entity_label_table"
+}
+
+// 导入批次，同一用户的 idempotency_key 唯一
+type ImportBatchInfo struct {
+	ID             string     `gorm:"column:id;type:varchar(32);primaryKey;comment:批次号"`
+	User           string     `gorm:"column:user;type:varchar(20);not null;uniqueIndex:import_batch_table_user_key_uindex;index:import_batch_table_user_index;comment:导入人"`
+	IdempotencyKey *string    `gorm:"column:idempotency_key;type:varchar(64);uniqueIndex:import_batch_table_user_key_uindex;comment:幂等键"`
+	Method         string     `gorm:"column:method;type:varchar(100);comment:RPC 方法"`
+	EntityType     string     `gorm:"column:entity_type;type:varchar(10);not null;comment:对象类型 task/patch"`
+	Created        int        `gorm:"column:created;not null;default:0;comment:新增条数"`
+	Updated        int        `gorm:"column:updated;not null;default:0;comment:修改条数"`
+	Unchanged      int        `gorm:"column:unchanged;not null;default:0;comment:未变化条数"`
+	CreatedAt      time.Time  `gorm:"column:created_at;index:import_batch_table_created_at_index;comment:导入时间"`
+	RolledBackAt   *time.Time `gorm:"column:rolled_back_at;comment:回滚时间"`
+	RolledBackBy   string     `gorm:"column:rolled_back_by;type:varchar(20);comment:回滚人"`
+}
+
+func (ImportBatchInfo) TableName() string {
+	return "import_batch_table"
+}
+
+// 导入批次中写入的每条记录，before 为导入前的 JSON 快照
+type ImportBatchRowInfo struct {
+	ID           uint   `gorm:"column:id;primaryKey;autoIncrement"`
+	BatchID      string `gorm:"column:batch_id;type:varchar(32);not null;index:import_batch_row_table_batch_id_index;comment:批次号"`
+	EntityType   string `gorm:"column:entity_type;type:varchar(10);not null;comment:对象类型 task/patch"`
+	EntityID     string `gorm:"column:entity_id;type:varchar(25);not null;comment:任务单号或补丁号"`
+	Action       string `gorm:"column:action;type:varchar(10);not null;comment:created/updated"`
+	Before       string `gorm:"column:before;type:text;comment:导入前的内容"`
+	AfterVersion int64  `gorm:"column:after_version;not null;comment:导入后的版本号"`
+}
+
+func (ImportBatchRowInfo) TableName() string {
+	return "import_batch_row_table"
+}
+
+// 日历订阅密钥，每个用户一个，只保存哈希
+type CalendarTokenInfo struct {
+	UserID    uint      `gorm:"column:user_id;primaryKey;comment:用户ID"`
+	TokenHash string    `gorm:"column:token_hash;type:char(64);not null;uniqueIndex:calendar_token_table_token_hash_uindex;comment:密钥哈希"`
+	CreatedAt time.Time `gorm:"column:created_at;comment:创建时间"`
+}
+
+func (CalendarTokenInfo) TableName() string {
+	return "calendar_token_table"
+}
+
+// Jira/GitLab 问题与修改单的对应关系，再次导入同一问题时更新对应的修改单
+type ExternalIssueInfo struct {
+	ID          uint      `gorm:"column:id;primaryKey;autoIncrement"`
+	Source      string    `gorm:"column:source;type:varchar(10);not null;uniqueIndex:external_issue_table_source_key_uindex;comment:来源 jira/gitlab"`
+	ExternalKey string    `gorm:"column:external_key;type:varchar(100);not null;uniqueIndex:external_issue_table_source_key_uindex;comment:问题编号"`
+	TaskID      string    `gorm:"column:task_id;type:varchar(25);not null;index:external_issue_table_task_id_index;comment:任务单号"`
+	SyncedAt    time.Time `gorm:"column:synced_at;comment:最近导入时间"`
+}
+
+func (ExternalIssueInfo) TableName() string {
+	return "external_issue_table"
+}
+
+// 提交记录或合并请求与修改单的关联，Ref 为提交的 SHA 或合并请求的编号
+type TaskCommitInfo struct {
+	ID          uint      `gorm:"column:id;primaryKey;autoIncrement"`
+	TaskID      string    `gorm:"column:task_id;type:varchar(25);not null;uniqueIndex:task_commit_table_task_kind_repo_ref_uindex;comment:任务单号"`
+	Kind        string    `gorm:"column:kind;type:varchar(10);not null;uniqueIndex:task_commit_table_task_kind_repo_ref_uindex;comment:commit/mr"`
+	Repo        string    `gorm:"column:repo;type:varchar(200);not null;uniqueIndex:task_commit_table_task_kind_repo_ref_uindex;comment:仓库"`
+	Ref         string    `gorm:"column:ref;type:varchar(64);not null;uniqueIndex:task_commit_table_task_kind_repo_ref_uindex;comment:提交 SHA 或合并请求编号"`
+	Title       string    `gorm:"column:title;type:varchar(200);comment:提交信息首行或合并请求标题"`
+	URL         string    `gorm:"column:url;type:varchar(500);comment:链接"`
+	Author      string    `gorm:"column:author;type:varchar(100);comment:作者"`
+	State       string    `gorm:"column:state;type:varchar(20);comment:合并请求状态 opened/merged/closed"`
+	CommittedAt time.Time `gorm:"column:committed_at;comment:提交时间"`
+	CreatedAt   time.Time `gorm:"column:created_at;comment:关联时间"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;comment:更新时间"`
+}
+
+func (TaskCommitInfo) TableName() string {
+	return "task_commit_table"
+}
+
+// 个人每周的工作时间，没有记录时按周一至周五每天 8 小时计算
+type WorkScheduleInfo struct {
+	UserID       uint      `gorm:"column:user_id;primaryKey;comment:用户ID"`
+	WeekdayHours string    `gorm:"column:weekday_hours;type:varchar(60);not null;comment:周一至周日每天的工时，逗号分隔"`
+	UpdatedAt    time.Time `gorm:"column:updated_at;comment:更新时间"`
+}
+
+func (WorkScheduleInfo) TableName() string {
+	return "work_schedule_table"
+}
+
+// 请假，Hours 为每天请假的小时数，0 表示全天
+type LeaveInfo struct {
+	ID        uint      `gorm:"column:id;primaryKey;autoIncrement"`
+	Name      string    `gorm:"column:name;type:varchar(20);not null;index:leave_table_name_index;comment:请假人"`
+	StartDate time.Time `gorm:"column:start_date;type:date;not null;comment:开始日期"`
+	EndDate   time.Time `gorm:"column:end_date;type:date;not null;comment:结束日期（含）"`
+	Hours     float64   `gorm:"column:hours;not null;default:0;comment:每天请假的小时数，0 表示全天"`
+	Reason    string    `gorm:"column:reason;type:varchar(100);comment:事由"`
+	CreatedBy string    `gorm:"column:created_by;type:varchar(20);not null;comment:登记人"`
+	CreatedAt time.Time `gorm:"column:created_at;comment:登记时间"`
+}
+
+func (LeaveInfo) TableName() string {
+	return "leave_table"
+}
+
+// 工作日历中与周末规则不同的日期：工作日放假（节假日）或周末上班（调休）
+type BusinessDayInfo struct {
+	Date      time.Time `gorm:"column:date;type:date;primaryKey;comment:日期"`
+	Workday   bool      `gorm:"column:workday;not null;comment:是否上班"`
+	Name      string    `gorm:"column:name;type:varchar(50);comment:名称，如 国庆节"`
+	UpdatedBy string    `gorm:"column:updated_by;type:varchar(20);comment:修改人"`
+	UpdatedAt time.Time `gorm:"column:updated_at;comment:修改时间"`
+}
+
+func (BusinessDayInfo) TableName() string {
+	return "business_day_table"
+}
+
+// 紧急程度自动升级规则：设置了的条件同时满足时，把未完成的修改单升到 Level
+type EscalationRuleInfo struct {
+	ID              uint      `gorm:"column:id;primaryKey;autoIncrement"`
+	Name            string    `gorm:"column:name;type:varchar(50);not null;uniqueIndex:escalation_rule_table_name_uindex;comment:规则名"`
+	Level           int       `gorm:"column:level;not null;comment:升到的紧急程度"`
+	MaxWorkdaysLeft *int      `gorm:"column:max_workdays_left;comment:距截止日期不超过的工作日数，为空表示不限"`
+	PatchLabelID    *uint     `gorm:"column:patch_label_id;index:escalation_rule_table_patch_label_id_index;comment:关联补丁带有的标签ID"`
+	PatchClients    string    `gorm:"column:patch_clients;type:varchar(200);comment:关联补丁的客户，逗号分隔"`
+	Enabled         bool      `gorm:"column:enabled;not null;default:true;comment:是否启用"`
+	UpdatedBy       string    `gorm:"column:updated_by;type:varchar(20);comment:修改人"`
+	UpdatedAt       time.Time `gorm:"column:updated_at;comment:修改时间"`
+}
+
+func (EscalationRuleInfo) TableName() string {
+	return "escalation_rule_table"
+}
+
+// 自动升级的记录，同一规则对同一修改单只升级一次，之后手工调低不会被再次升级
+type EscalationInfo struct {
+	ID        uint      `gorm:"column:id;primaryKey;autoIncrement"`
+	TaskID    string    `gorm:"column:task_id;type:varchar(25);not null;uniqueIndex:escalation_table_task_id_rule_id_uindex;comment:任务单号"`
+	RuleID    uint      `gorm:"column:rule_id;not null;uniqueIndex:escalation_table_task_id_rule_id_uindex;comment:规则ID"`
+	RuleName  string    `gorm:"column:rule_name;type:varchar(50);comment:规则名"`
+	FromLevel int       `gorm:"column:from_level;not null;comment:原紧急程度"`
+	ToLevel   int       `gorm:"column:to_level;not null;comment:升级后的紧急程度"`
+	CreatedAt time.Time `gorm:"column:created_at;index:escalation_table_created_at_index;comment:升级时间"`
+}
+
+func (EscalationInfo) TableName() string {
+	return "escalation_table"
+}
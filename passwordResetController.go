@@ -0,0 +1,144 @@
+package main
+
+import (
+	"OrderManager/pb"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"math/big"
+	"time"
+)
+
+const (
+	resetCodeTTL         = 15 * time.Minute
+	resetRequestInterval = time.Minute
+	resetRequestsPerHour = 3
+	resetMaxAttempts     = 5
+)
+
+var errInvalidResetCode = errors.New("invalid or expired code")
+
+func randomResetCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+var errTooManyResetRequests = errors.New("too many password reset requests")
+
+// 生成验证码并发送邮件
+func issueResetCode(ctx context.Context, user *UserInfo) error {
+	now := time.Now()
+	var recent []PasswordResetInfo
+	if err := db.Where("user_id = ? AND created_at > ?", user.ID, now.Add(-time.Hour)).Order("created_at desc").Find(&recent).Error; err != nil {
+		return err
+	}
+	if len(recent) >= resetRequestsPerHour || (len(recent) > 0 && now.Sub(recent[0].CreatedAt) < resetRequestInterval) {
+		return errTooManyResetRequests
+	}
+
+	code, err := randomResetCode()
+	if err != nil {
+		return err
+	}
+	codeHash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	reset := PasswordResetInfo{UserID: user.ID, CodeHash: string(codeHash), ExpiresAt: now.Add(resetCodeTTL)}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// 新验证码生效后，旧验证码全部作废
+		if err := tx.Model(&PasswordResetInfo{}).
+			Where("user_id = ? AND used_at IS NULL AND expires_at > ?", user.ID, now).
+			Update("expires_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&reset).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := sendPasswordResetEmail(*user.Email, code); err != nil {
+		db.Delete(&reset)
+		return fmt.Errorf("send email: %v", err)
+	}
+	recordAuthEvent(ctx, db, AUTH_EVENT_RESET_REQUEST, user.Name, "")
+	return nil
+}
+
+// 无论用户是否存在、请求是否过于频繁、邮件是否发送成功都返回成功，避免泄露用户名，失败只记录日志
+func (s *server) RequestPasswordReset(ctx context.Context, in *pb.RequestPasswordResetRequest) (*pb.RequestPasswordResetReply, error) {
+	user, err := findUserByName(db, in.Name)
+	if err != nil || !user.Active || user.Email == nil || user.Source != AUTH_SOURCE_LOCAL {
+		return &pb.RequestPasswordResetReply{}, nil
+	}
+	if err := issueResetCode(ctx, user); err != nil {
+		log.Printf("%s 的密码重置失败：%v", user.Name, err)
+	}
+	return &pb.RequestPasswordResetReply{}, nil
+}
+
+func (s *server) ConfirmPasswordReset(ctx context.Context, in *pb.ConfirmPasswordResetRequest) (*pb.ConfirmPasswordResetReply, error) {
+	if in.NewPassword == "" {
+		return nil, errors.New("new password is required")
+	}
+	user, err := findUserByName(db, in.Name)
+	if err != nil || !user.Active || user.Source != AUTH_SOURCE_LOCAL {
+		return nil, errInvalidResetCode
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(in.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := false
+	err = db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		var reset PasswordResetInfo
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND used_at IS NULL AND expires_at > ?", user.ID, now).
+			Order("id desc").First(&reset).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		if bcrypt.CompareHashAndPassword([]byte(reset.CodeHash), []byte(in.Code)) != nil {
+			// 错误次数达到上限后验证码作废
+			updates := map[string]interface{}{"attempts": reset.Attempts + 1}
+			if reset.Attempts+1 >= resetMaxAttempts {
+				updates["expires_at"] = now
+			}
+			return tx.Model(&reset).Updates(updates).Error
+		}
+
+		matched = true
+		if err := tx.Model(&reset).Update("used_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(user).Update("password", string(hashedPassword)).Error; err != nil {
+			return err
+		}
+		if err := revokeSessions(tx, user.ID); err != nil {
+			return err
+		}
+		recordAuthEvent(ctx, tx, AUTH_EVENT_PASSWORD_RESET, user.Name, "")
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !matched {
+		return nil, errInvalidResetCode
+	}
+	return &pb.ConfirmPasswordResetReply{}, nil
+}
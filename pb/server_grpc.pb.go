@@ -145,21 +145,90 @@ var NotificationService_ServiceDesc = grpc.ServiceDesc{
 }
 
 const (
-	Service_Login_FullMethodName                 = "/notification.Service/Login"
-	Service_Register_FullMethodName              = "/notification.Service/Register"
-	Service_GetTaskListAll_FullMethodName        = "/notification.Service/GetTaskListAll"
-	Service_GetTaskListOne_FullMethodName        = "/notification.Service/GetTaskListOne"
-	Service_ImportXLSToTaskTable_FullMethodName  = "/notification.Service/ImportXLSToTaskTable"
-	Service_DelTask_FullMethodName               = "/notification.Service/DelTask"
-	Service_ModTask_FullMethodName               = "/notification.Service/ModTask"
-	Service_AddTask_FullMethodName               = "/notification.Service/AddTask"
-	Service_QueryTaskWithSQL_FullMethodName      = "/notification.Service/QueryTaskWithSQL"
-	Service_QueryTaskWithField_FullMethodName    = "/notification.Service/QueryTaskWithField"
-	Service_GetPatchsAll_FullMethodName          = "/notification.Service/GetPatchsAll"
-	Service_GetOnePatchs_FullMethodName          = "/notification.Service/GetOnePatchs"
-	Service_DelPatch_FullMethodName              = "/notification.Service/DelPatch"
-	Service_ImportXLSToPatchTable_FullMethodName = "/notification.Service/ImportXLSToPatchTable"
-	Service_ModPatch_FullMethodName              = "/notification.Service/ModPatch"
+	Service_Login_FullMethodName                  = "/notification.Service/Login"
+	Service_Register_FullMethodName               = "/notification.Service/Register"
+	Service_GetProfile_FullMethodName             = "/notification.Service/GetProfile"
+	Service_UpdateProfile_FullMethodName          = "/notification.Service/UpdateProfile"
+	Service_ChangePassword_FullMethodName         = "/notification.Service/ChangePassword"
+	Service_DeactivateUser_FullMethodName         = "/notification.Service/DeactivateUser"
+	Service_ListUsers_FullMethodName              = "/notification.Service/ListUsers"
+	Service_Logout_FullMethodName                 = "/notification.Service/Logout"
+	Service_RequestPasswordReset_FullMethodName   = "/notification.Service/RequestPasswordReset"
+	Service_ConfirmPasswordReset_FullMethodName   = "/notification.Service/ConfirmPasswordReset"
+	Service_ListAuthEvents_FullMethodName         = "/notification.Service/ListAuthEvents"
+	Service_CreateServiceAccount_FullMethodName   = "/notification.Service/CreateServiceAccount"
+	Service_CreateAPIKey_FullMethodName           = "/notification.Service/CreateAPIKey"
+	Service_ListAPIKeys_FullMethodName            = "/notification.Service/ListAPIKeys"
+	Service_RevokeAPIKey_FullMethodName           = "/notification.Service/RevokeAPIKey"
+	Service_GetTaskListAll_FullMethodName         = "/notification.Service/GetTaskListAll"
+	Service_GetTaskListOne_FullMethodName         = "/notification.Service/GetTaskListOne"
+	Service_ImportXLSToTaskTable_FullMethodName   = "/notification.Service/ImportXLSToTaskTable"
+	Service_DelTask_FullMethodName                = "/notification.Service/DelTask"
+	Service_ModTask_FullMethodName                = "/notification.Service/ModTask"
+	Service_AddTask_FullMethodName                = "/notification.Service/AddTask"
+	Service_QueryTaskWithSQL_FullMethodName       = "/notification.Service/QueryTaskWithSQL"
+	Service_QueryTaskWithField_FullMethodName     = "/notification.Service/QueryTaskWithField"
+	Service_GetPatchsAll_FullMethodName           = "/notification.Service/GetPatchsAll"
+	Service_GetOnePatchs_FullMethodName           = "/notification.Service/GetOnePatchs"
+	Service_DelPatch_FullMethodName               = "/notification.Service/DelPatch"
+	Service_ImportXLSToPatchTable_FullMethodName  = "/notification.Service/ImportXLSToPatchTable"
+	Service_ModPatch_FullMethodName               = "/notification.Service/ModPatch"
+	Service_GetTaskHistory_FullMethodName         = "/notification.Service/GetTaskHistory"
+	Service_GetPatchHistory_FullMethodName        = "/notification.Service/GetPatchHistory"
+	Service_SearchAuditLog_FullMethodName         = "/notification.Service/SearchAuditLog"
+	Service_ListTrash_FullMethodName              = "/notification.Service/ListTrash"
+	Service_RestoreTask_FullMethodName            = "/notification.Service/RestoreTask"
+	Service_RestorePatch_FullMethodName           = "/notification.Service/RestorePatch"
+	Service_AddComment_FullMethodName             = "/notification.Service/AddComment"
+	Service_EditComment_FullMethodName            = "/notification.Service/EditComment"
+	Service_DeleteComment_FullMethodName          = "/notification.Service/DeleteComment"
+	Service_ListComments_FullMethodName           = "/notification.Service/ListComments"
+	Service_LogWork_FullMethodName                = "/notification.Service/LogWork"
+	Service_ListWorkLogs_FullMethodName           = "/notification.Service/ListWorkLogs"
+	Service_GetWorkReport_FullMethodName          = "/notification.Service/GetWorkReport"
+	Service_AddTaskDependency_FullMethodName      = "/notification.Service/AddTaskDependency"
+	Service_RemoveTaskDependency_FullMethodName   = "/notification.Service/RemoveTaskDependency"
+	Service_ListTaskDependencies_FullMethodName   = "/notification.Service/ListTaskDependencies"
+	Service_MoveTask_FullMethodName               = "/notification.Service/MoveTask"
+	Service_CreateTaskType_FullMethodName         = "/notification.Service/CreateTaskType"
+	Service_UpdateTaskType_FullMethodName         = "/notification.Service/UpdateTaskType"
+	Service_DeleteTaskType_FullMethodName         = "/notification.Service/DeleteTaskType"
+	Service_ListTaskTypes_FullMethodName          = "/notification.Service/ListTaskTypes"
+	Service_CreateLabel_FullMethodName            = "/notification.Service/CreateLabel"
+	Service_UpdateLabel_FullMethodName            = "/notification.Service/UpdateLabel"
+	Service_DeleteLabel_FullMethodName            = "/notification.Service/DeleteLabel"
+	Service_ListLabels_FullMethodName             = "/notification.Service/ListLabels"
+	Service_AddLabels_FullMethodName              = "/notification.Service/AddLabels"
+	Service_RemoveLabels_FullMethodName           = "/notification.Service/RemoveLabels"
+	Service_ImportXLSX_FullMethodName             = "/notification.Service/ImportXLSX"
+	Service_ImportExternalIssues_FullMethodName   = "/notification.Service/ImportExternalIssues"
+	Service_ListImportBatches_FullMethodName      = "/notification.Service/ListImportBatches"
+	Service_GetImportBatch_FullMethodName         = "/notification.Service/GetImportBatch"
+	Service_RollbackImportBatch_FullMethodName    = "/notification.Service/RollbackImportBatch"
+	Service_ExportTasks_FullMethodName            = "/notification.Service/ExportTasks"
+	Service_ExportPatchs_FullMethodName           = "/notification.Service/ExportPatchs"
+	Service_ExportWorkReport_FullMethodName       = "/notification.Service/ExportWorkReport"
+	Service_ResetCalendarToken_FullMethodName     = "/notification.Service/ResetCalendarToken"
+	Service_RevokeCalendarToken_FullMethodName    = "/notification.Service/RevokeCalendarToken"
+	Service_LinkCommits_FullMethodName            = "/notification.Service/LinkCommits"
+	Service_ListTaskCommits_FullMethodName        = "/notification.Service/ListTaskCommits"
+	Service_SetWorkSchedule_FullMethodName        = "/notification.Service/SetWorkSchedule"
+	Service_AddLeave_FullMethodName               = "/notification.Service/AddLeave"
+	Service_DeleteLeave_FullMethodName            = "/notification.Service/DeleteLeave"
+	Service_ListLeaves_FullMethodName             = "/notification.Service/ListLeaves"
+	Service_GetCapacity_FullMethodName            = "/notification.Service/GetCapacity"
+	Service_SetBusinessDays_FullMethodName        = "/notification.Service/SetBusinessDays"
+	Service_DeleteBusinessDays_FullMethodName     = "/notification.Service/DeleteBusinessDays"
+	Service_ListBusinessDays_FullMethodName       = "/notification.Service/ListBusinessDays"
+	Service_ImportBusinessCalendar_FullMethodName = "/notification.Service/ImportBusinessCalendar"
+	Service_CreateEscalationRule_FullMethodName   = "/notification.Service/CreateEscalationRule"
+	Service_UpdateEscalationRule_FullMethodName   = "/notification.Service/UpdateEscalationRule"
+	Service_DeleteEscalationRule_FullMethodName   = "/notification.Service/DeleteEscalationRule"
+	Service_ListEscalationRules_FullMethodName    = "/notification.Service/ListEscalationRules"
+	Service_RunEscalation_FullMethodName          = "/notification.Service/RunEscalation"
+	Service_ListEscalations_FullMethodName        = "/notification.Service/ListEscalations"
+	Service_Backup_FullMethodName                 = "/notification.Service/Backup"
+	Service_Restore_FullMethodName                = "/notification.Service/Restore"
 )
 
 // ServiceClient is the client API for Service service.
@@ -170,6 +239,21 @@ const (
 type ServiceClient interface {
 	Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginReply, error)
 	Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterReply, error)
+	// 账号
+	GetProfile(ctx context.Context, in *GetProfileRequest, opts ...grpc.CallOption) (*GetProfileReply, error)
+	UpdateProfile(ctx context.Context, in *UpdateProfileRequest, opts ...grpc.CallOption) (*UpdateProfileReply, error)
+	ChangePassword(ctx context.Context, in *ChangePasswordRequest, opts ...grpc.CallOption) (*ChangePasswordReply, error)
+	DeactivateUser(ctx context.Context, in *DeactivateUserRequest, opts ...grpc.CallOption) (*DeactivateUserReply, error)
+	ListUsers(ctx context.Context, in *ListUsersRequest, opts ...grpc.CallOption) (*ListUsersReply, error)
+	Logout(ctx context.Context, in *LogoutRequest, opts ...grpc.CallOption) (*LogoutReply, error)
+	RequestPasswordReset(ctx context.Context, in *RequestPasswordResetRequest, opts ...grpc.CallOption) (*RequestPasswordResetReply, error)
+	ConfirmPasswordReset(ctx context.Context, in *ConfirmPasswordResetRequest, opts ...grpc.CallOption) (*ConfirmPasswordResetReply, error)
+	ListAuthEvents(ctx context.Context, in *ListAuthEventsRequest, opts ...grpc.CallOption) (*ListAuthEventsReply, error)
+	// 服务账号与 API Key
+	CreateServiceAccount(ctx context.Context, in *CreateServiceAccountRequest, opts ...grpc.CallOption) (*CreateServiceAccountReply, error)
+	CreateAPIKey(ctx context.Context, in *CreateAPIKeyRequest, opts ...grpc.CallOption) (*CreateAPIKeyReply, error)
+	ListAPIKeys(ctx context.Context, in *ListAPIKeysRequest, opts ...grpc.CallOption) (*ListAPIKeysReply, error)
+	RevokeAPIKey(ctx context.Context, in *RevokeAPIKeyRequest, opts ...grpc.CallOption) (*RevokeAPIKeyReply, error)
 	// 修改单/任务
 	GetTaskListAll(ctx context.Context, in *GetTaskListAllRequest, opts ...grpc.CallOption) (*GetTaskListAllReply, error)
 	GetTaskListOne(ctx context.Context, in *GetTaskListOneRequest, opts ...grpc.CallOption) (*GetTaskListOneReply, error)
@@ -186,6 +270,78 @@ type ServiceClient interface {
 	DelPatch(ctx context.Context, in *DelPatchRequest, opts ...grpc.CallOption) (*DelPatchReply, error)
 	ImportXLSToPatchTable(ctx context.Context, in *ImportXLSToPatchRequest, opts ...grpc.CallOption) (*ImportXLSToPatchReply, error)
 	ModPatch(ctx context.Context, in *ModPatchRequest, opts ...grpc.CallOption) (*ModPatchReply, error)
+	// 变更历史
+	GetTaskHistory(ctx context.Context, in *GetTaskHistoryRequest, opts ...grpc.CallOption) (*GetTaskHistoryReply, error)
+	GetPatchHistory(ctx context.Context, in *GetPatchHistoryRequest, opts ...grpc.CallOption) (*GetPatchHistoryReply, error)
+	SearchAuditLog(ctx context.Context, in *SearchAuditLogRequest, opts ...grpc.CallOption) (*SearchAuditLogReply, error)
+	// 回收站
+	ListTrash(ctx context.Context, in *ListTrashRequest, opts ...grpc.CallOption) (*ListTrashReply, error)
+	RestoreTask(ctx context.Context, in *RestoreTaskRequest, opts ...grpc.CallOption) (*RestoreTaskReply, error)
+	RestorePatch(ctx context.Context, in *RestorePatchRequest, opts ...grpc.CallOption) (*RestorePatchReply, error)
+	// 评论
+	AddComment(ctx context.Context, in *AddCommentRequest, opts ...grpc.CallOption) (*AddCommentReply, error)
+	EditComment(ctx context.Context, in *EditCommentRequest, opts ...grpc.CallOption) (*EditCommentReply, error)
+	DeleteComment(ctx context.Context, in *DeleteCommentRequest, opts ...grpc.CallOption) (*DeleteCommentReply, error)
+	ListComments(ctx context.Context, in *ListCommentsRequest, opts ...grpc.CallOption) (*ListCommentsReply, error)
+	// 工时
+	LogWork(ctx context.Context, in *LogWorkRequest, opts ...grpc.CallOption) (*LogWorkReply, error)
+	ListWorkLogs(ctx context.Context, in *ListWorkLogsRequest, opts ...grpc.CallOption) (*ListWorkLogsReply, error)
+	GetWorkReport(ctx context.Context, in *GetWorkReportRequest, opts ...grpc.CallOption) (*GetWorkReportReply, error)
+	// 修改单依赖
+	AddTaskDependency(ctx context.Context, in *AddTaskDependencyRequest, opts ...grpc.CallOption) (*AddTaskDependencyReply, error)
+	RemoveTaskDependency(ctx context.Context, in *RemoveTaskDependencyRequest, opts ...grpc.CallOption) (*RemoveTaskDependencyReply, error)
+	ListTaskDependencies(ctx context.Context, in *ListTaskDependenciesRequest, opts ...grpc.CallOption) (*ListTaskDependenciesReply, error)
+	// 子任务
+	MoveTask(ctx context.Context, in *MoveTaskRequest, opts ...grpc.CallOption) (*MoveTaskReply, error)
+	// 任务类型
+	CreateTaskType(ctx context.Context, in *CreateTaskTypeRequest, opts ...grpc.CallOption) (*CreateTaskTypeReply, error)
+	UpdateTaskType(ctx context.Context, in *UpdateTaskTypeRequest, opts ...grpc.CallOption) (*UpdateTaskTypeReply, error)
+	DeleteTaskType(ctx context.Context, in *DeleteTaskTypeRequest, opts ...grpc.CallOption) (*DeleteTaskTypeReply, error)
+	ListTaskTypes(ctx context.Context, in *ListTaskTypesRequest, opts ...grpc.CallOption) (*ListTaskTypesReply, error)
+	// 标签
+	CreateLabel(ctx context.Context, in *CreateLabelRequest, opts ...grpc.CallOption) (*CreateLabelReply, error)
+	UpdateLabel(ctx context.Context, in *UpdateLabelRequest, opts ...grpc.CallOption) (*UpdateLabelReply, error)
+	DeleteLabel(ctx context.Context, in *DeleteLabelRequest, opts ...grpc.CallOption) (*DeleteLabelReply, error)
+	ListLabels(ctx context.Context, in *ListLabelsRequest, opts ...grpc.CallOption) (*ListLabelsReply, error)
+	AddLabels(ctx context.Context, in *AddLabelsRequest, opts ...grpc.CallOption) (*AddLabelsReply, error)
+	RemoveLabels(ctx context.Context, in *RemoveLabelsRequest, opts ...grpc.CallOption) (*RemoveLabelsReply, error)
+	// 服务端解析 Excel 导入
+	ImportXLSX(ctx context.Context, in *ImportXLSXRequest, opts ...grpc.CallOption) (*ImportXLSXReply, error)
+	ImportExternalIssues(ctx context.Context, in *ImportExternalIssuesRequest, opts ...grpc.CallOption) (*ImportXLSXReply, error)
+	ListImportBatches(ctx context.Context, in *ListImportBatchesRequest, opts ...grpc.CallOption) (*ListImportBatchesReply, error)
+	GetImportBatch(ctx context.Context, in *GetImportBatchRequest, opts ...grpc.CallOption) (*GetImportBatchReply, error)
+	RollbackImportBatch(ctx context.Context, in *RollbackImportBatchRequest, opts ...grpc.CallOption) (*RollbackImportBatchReply, error)
+	// 导出为 .xlsx 或 CSV，文件内容分片返回
+	ExportTasks(ctx context.Context, in *ExportTasksRequest, opts ...grpc.CallOption) (Service_ExportTasksClient, error)
+	ExportPatchs(ctx context.Context, in *ExportPatchsRequest, opts ...grpc.CallOption) (Service_ExportPatchsClient, error)
+	ExportWorkReport(ctx context.Context, in *ExportWorkReportRequest, opts ...grpc.CallOption) (Service_ExportWorkReportClient, error)
+	// 日历订阅（iCalendar）的密钥
+	ResetCalendarToken(ctx context.Context, in *ResetCalendarTokenRequest, opts ...grpc.CallOption) (*ResetCalendarTokenReply, error)
+	RevokeCalendarToken(ctx context.Context, in *RevokeCalendarTokenRequest, opts ...grpc.CallOption) (*RevokeCalendarTokenReply, error)
+	// 提交记录和合并请求关联修改单
+	LinkCommits(ctx context.Context, in *LinkCommitsRequest, opts ...grpc.CallOption) (*LinkCommitsReply, error)
+	ListTaskCommits(ctx context.Context, in *ListTaskCommitsRequest, opts ...grpc.CallOption) (*ListTaskCommitsReply, error)
+	// 工作时间、请假与负载
+	SetWorkSchedule(ctx context.Context, in *SetWorkScheduleRequest, opts ...grpc.CallOption) (*SetWorkScheduleReply, error)
+	AddLeave(ctx context.Context, in *AddLeaveRequest, opts ...grpc.CallOption) (*AddLeaveReply, error)
+	DeleteLeave(ctx context.Context, in *DeleteLeaveRequest, opts ...grpc.CallOption) (*DeleteLeaveReply, error)
+	ListLeaves(ctx context.Context, in *ListLeavesRequest, opts ...grpc.CallOption) (*ListLeavesReply, error)
+	GetCapacity(ctx context.Context, in *GetCapacityRequest, opts ...grpc.CallOption) (*GetCapacityReply, error)
+	// 工作日历：节假日与调休
+	SetBusinessDays(ctx context.Context, in *SetBusinessDaysRequest, opts ...grpc.CallOption) (*SetBusinessDaysReply, error)
+	DeleteBusinessDays(ctx context.Context, in *DeleteBusinessDaysRequest, opts ...grpc.CallOption) (*DeleteBusinessDaysReply, error)
+	ListBusinessDays(ctx context.Context, in *ListBusinessDaysRequest, opts ...grpc.CallOption) (*ListBusinessDaysReply, error)
+	ImportBusinessCalendar(ctx context.Context, in *ImportBusinessCalendarRequest, opts ...grpc.CallOption) (*ImportBusinessCalendarReply, error)
+	// 紧急程度自动升级
+	CreateEscalationRule(ctx context.Context, in *CreateEscalationRuleRequest, opts ...grpc.CallOption) (*CreateEscalationRuleReply, error)
+	UpdateEscalationRule(ctx context.Context, in *UpdateEscalationRuleRequest, opts ...grpc.CallOption) (*UpdateEscalationRuleReply, error)
+	DeleteEscalationRule(ctx context.Context, in *DeleteEscalationRuleRequest, opts ...grpc.CallOption) (*DeleteEscalationRuleReply, error)
+	ListEscalationRules(ctx context.Context, in *ListEscalationRulesRequest, opts ...grpc.CallOption) (*ListEscalationRulesReply, error)
+	RunEscalation(ctx context.Context, in *RunEscalationRequest, opts ...grpc.CallOption) (*RunEscalationReply, error)
+	ListEscalations(ctx context.Context, in *ListEscalationsRequest, opts ...grpc.CallOption) (*ListEscalationsReply, error)
+	// 备份与恢复，仅管理员
+	Backup(ctx context.Context, in *BackupRequest, opts ...grpc.CallOption) (Service_BackupClient, error)
+	Restore(ctx context.Context, opts ...grpc.CallOption) (Service_RestoreClient, error)
 }
 
 type serviceClient struct {
@@ -216,6 +372,136 @@ func (c *serviceClient) Register(ctx context.Context, in *RegisterRequest, opts
 	return out, nil
 }
 
+func (c *serviceClient) GetProfile(ctx context.Context, in *GetProfileRequest, opts ...grpc.CallOption) (*GetProfileReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetProfileReply)
+	err := c.cc.Invoke(ctx, Service_GetProfile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) UpdateProfile(ctx context.Context, in *UpdateProfileRequest, opts ...grpc.CallOption) (*UpdateProfileReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateProfileReply)
+	err := c.cc.Invoke(ctx, Service_UpdateProfile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) ChangePassword(ctx context.Context, in *ChangePasswordRequest, opts ...grpc.CallOption) (*ChangePasswordReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ChangePasswordReply)
+	err := c.cc.Invoke(ctx, Service_ChangePassword_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) DeactivateUser(ctx context.Context, in *DeactivateUserRequest, opts ...grpc.CallOption) (*DeactivateUserReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeactivateUserReply)
+	err := c.cc.Invoke(ctx, Service_DeactivateUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) ListUsers(ctx context.Context, in *ListUsersRequest, opts ...grpc.CallOption) (*ListUsersReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListUsersReply)
+	err := c.cc.Invoke(ctx, Service_ListUsers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) Logout(ctx context.Context, in *LogoutRequest, opts ...grpc.CallOption) (*LogoutReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LogoutReply)
+	err := c.cc.Invoke(ctx, Service_Logout_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) RequestPasswordReset(ctx context.Context, in *RequestPasswordResetRequest, opts ...grpc.CallOption) (*RequestPasswordResetReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RequestPasswordResetReply)
+	err := c.cc.Invoke(ctx, Service_RequestPasswordReset_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) ConfirmPasswordReset(ctx context.Context, in *ConfirmPasswordResetRequest, opts ...grpc.CallOption) (*ConfirmPasswordResetReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConfirmPasswordResetReply)
+	err := c.cc.Invoke(ctx, Service_ConfirmPasswordReset_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) ListAuthEvents(ctx context.Context, in *ListAuthEventsRequest, opts ...grpc.CallOption) (*ListAuthEventsReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAuthEventsReply)
+	err := c.cc.Invoke(ctx, Service_ListAuthEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) CreateServiceAccount(ctx context.Context, in *CreateServiceAccountRequest, opts ...grpc.CallOption) (*CreateServiceAccountReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateServiceAccountReply)
+	err := c.cc.Invoke(ctx, Service_CreateServiceAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) CreateAPIKey(ctx context.Context, in *CreateAPIKeyRequest, opts ...grpc.CallOption) (*CreateAPIKeyReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateAPIKeyReply)
+	err := c.cc.Invoke(ctx, Service_CreateAPIKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) ListAPIKeys(ctx context.Context, in *ListAPIKeysRequest, opts ...grpc.CallOption) (*ListAPIKeysReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAPIKeysReply)
+	err := c.cc.Invoke(ctx, Service_ListAPIKeys_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) RevokeAPIKey(ctx context.Context, in *RevokeAPIKeyRequest, opts ...grpc.CallOption) (*RevokeAPIKeyReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RevokeAPIKeyReply)
+	err := c.cc.Invoke(ctx, Service_RevokeAPIKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *serviceClient) GetTaskListAll(ctx context.Context, in *GetTaskListAllRequest, opts ...grpc.CallOption) (*GetTaskListAllReply, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(GetTaskListAllReply)
@@ -346,365 +632,2598 @@ func (c *serviceClient) ModPatch(ctx context.Context, in *ModPatchRequest, opts
 	return out, nil
 }
 
-// ServiceServer is the server API for Service service.
-// All implementations must embed UnimplementedServiceServer
-// for forward compatibility
-//
-// 定义服务
-type ServiceServer interface {
-	Login(context.Context, *LoginRequest) (*LoginReply, error)
-	Register(context.Context, *RegisterRequest) (*RegisterReply, error)
-	// 修改单/任务
-	GetTaskListAll(context.Context, *GetTaskListAllRequest) (*GetTaskListAllReply, error)
-	GetTaskListOne(context.Context, *GetTaskListOneRequest) (*GetTaskListOneReply, error)
-	ImportXLSToTaskTable(context.Context, *ImportToTaskListRequest) (*ImportToTaskListReply, error)
-	// CURD
-	DelTask(context.Context, *DelTaskRequest) (*DelTaskReply, error)
-	ModTask(context.Context, *ModTaskRequest) (*ModTaskReply, error)
-	AddTask(context.Context, *AddTaskRequest) (*AddTaskReply, error)
-	QueryTaskWithSQL(context.Context, *QueryTaskWithSQLRequest) (*QueryTaskWithSQLReply, error)
-	QueryTaskWithField(context.Context, *QueryTaskWithFieldRequest) (*QueryTaskWithFieldReply, error)
-	// 补丁
-	GetPatchsAll(context.Context, *GetPatchsAllRequest) (*GetPatchsAllReply, error)
-	GetOnePatchs(context.Context, *GetOnePatchsRequest) (*GetOnePatchsReply, error)
-	DelPatch(context.Context, *DelPatchRequest) (*DelPatchReply, error)
-	ImportXLSToPatchTable(context.Context, *ImportXLSToPatchRequest) (*ImportXLSToPatchReply, error)
-	ModPatch(context.Context, *ModPatchRequest) (*ModPatchReply, error)
-	mustEmbedUnimplementedServiceServer()
+func (c *serviceClient) GetTaskHistory(ctx context.Context, in *GetTaskHistoryRequest, opts ...grpc.CallOption) (*GetTaskHistoryReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetTaskHistoryReply)
+	err := c.cc.Invoke(ctx, Service_GetTaskHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-// UnimplementedServiceServer must be embedded to have forward compatible implementations.
-type UnimplementedServiceServer struct {
+func (c *serviceClient) GetPatchHistory(ctx context.Context, in *GetPatchHistoryRequest, opts ...grpc.CallOption) (*GetPatchHistoryReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetPatchHistoryReply)
+	err := c.cc.Invoke(ctx, Service_GetPatchHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-func (UnimplementedServiceServer) Login(context.Context, *LoginRequest) (*LoginReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
-}
-func (UnimplementedServiceServer) Register(context.Context, *RegisterRequest) (*RegisterReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
+func (c *serviceClient) SearchAuditLog(ctx context.Context, in *SearchAuditLogRequest, opts ...grpc.CallOption) (*SearchAuditLogReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchAuditLogReply)
+	err := c.cc.Invoke(ctx, Service_SearchAuditLog_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedServiceServer) GetTaskListAll(context.Context, *GetTaskListAllRequest) (*GetTaskListAllReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetTaskListAll not implemented")
+
+func (c *serviceClient) ListTrash(ctx context.Context, in *ListTrashRequest, opts ...grpc.CallOption) (*ListTrashReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTrashReply)
+	err := c.cc.Invoke(ctx, Service_ListTrash_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedServiceServer) GetTaskListOne(context.Context, *GetTaskListOneRequest) (*GetTaskListOneReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetTaskListOne not implemented")
+
+func (c *serviceClient) RestoreTask(ctx context.Context, in *RestoreTaskRequest, opts ...grpc.CallOption) (*RestoreTaskReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RestoreTaskReply)
+	err := c.cc.Invoke(ctx, Service_RestoreTask_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedServiceServer) ImportXLSToTaskTable(context.Context, *ImportToTaskListRequest) (*ImportToTaskListReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method ImportXLSToTaskTable not implemented")
+
+func (c *serviceClient) RestorePatch(ctx context.Context, in *RestorePatchRequest, opts ...grpc.CallOption) (*RestorePatchReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RestorePatchReply)
+	err := c.cc.Invoke(ctx, Service_RestorePatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedServiceServer) DelTask(context.Context, *DelTaskRequest) (*DelTaskReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method DelTask not implemented")
+
+func (c *serviceClient) AddComment(ctx context.Context, in *AddCommentRequest, opts ...grpc.CallOption) (*AddCommentReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddCommentReply)
+	err := c.cc.Invoke(ctx, Service_AddComment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedServiceServer) ModTask(context.Context, *ModTaskRequest) (*ModTaskReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method ModTask not implemented")
+
+func (c *serviceClient) EditComment(ctx context.Context, in *EditCommentRequest, opts ...grpc.CallOption) (*EditCommentReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EditCommentReply)
+	err := c.cc.Invoke(ctx, Service_EditComment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedServiceServer) AddTask(context.Context, *AddTaskRequest) (*AddTaskReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method AddTask not implemented")
+
+func (c *serviceClient) DeleteComment(ctx context.Context, in *DeleteCommentRequest, opts ...grpc.CallOption) (*DeleteCommentReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteCommentReply)
+	err := c.cc.Invoke(ctx, Service_DeleteComment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedServiceServer) QueryTaskWithSQL(context.Context, *QueryTaskWithSQLRequest) (*QueryTaskWithSQLReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method QueryTaskWithSQL not implemented")
+
+func (c *serviceClient) ListComments(ctx context.Context, in *ListCommentsRequest, opts ...grpc.CallOption) (*ListCommentsReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCommentsReply)
+	err := c.cc.Invoke(ctx, Service_ListComments_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedServiceServer) QueryTaskWithField(context.Context, *QueryTaskWithFieldRequest) (*QueryTaskWithFieldReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method QueryTaskWithField not implemented")
+
+func (c *serviceClient) LogWork(ctx context.Context, in *LogWorkRequest, opts ...grpc.CallOption) (*LogWorkReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LogWorkReply)
+	err := c.cc.Invoke(ctx, Service_LogWork_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedServiceServer) GetPatchsAll(context.Context, *GetPatchsAllRequest) (*GetPatchsAllReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetPatchsAll not implemented")
+
+func (c *serviceClient) ListWorkLogs(ctx context.Context, in *ListWorkLogsRequest, opts ...grpc.CallOption) (*ListWorkLogsReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListWorkLogsReply)
+	err := c.cc.Invoke(ctx, Service_ListWorkLogs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedServiceServer) GetOnePatchs(context.Context, *GetOnePatchsRequest) (*GetOnePatchsReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetOnePatchs not implemented")
+
+func (c *serviceClient) GetWorkReport(ctx context.Context, in *GetWorkReportRequest, opts ...grpc.CallOption) (*GetWorkReportReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetWorkReportReply)
+	err := c.cc.Invoke(ctx, Service_GetWorkReport_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedServiceServer) DelPatch(context.Context, *DelPatchRequest) (*DelPatchReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method DelPatch not implemented")
+
+func (c *serviceClient) AddTaskDependency(ctx context.Context, in *AddTaskDependencyRequest, opts ...grpc.CallOption) (*AddTaskDependencyReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddTaskDependencyReply)
+	err := c.cc.Invoke(ctx, Service_AddTaskDependency_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedServiceServer) ImportXLSToPatchTable(context.Context, *ImportXLSToPatchRequest) (*ImportXLSToPatchReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method ImportXLSToPatchTable not implemented")
+
+func (c *serviceClient) RemoveTaskDependency(ctx context.Context, in *RemoveTaskDependencyRequest, opts ...grpc.CallOption) (*RemoveTaskDependencyReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RemoveTaskDependencyReply)
+	err := c.cc.Invoke(ctx, Service_RemoveTaskDependency_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedServiceServer) ModPatch(context.Context, *ModPatchRequest) (*ModPatchReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method ModPatch not implemented")
+
+func (c *serviceClient) ListTaskDependencies(ctx context.Context, in *ListTaskDependenciesRequest, opts ...grpc.CallOption) (*ListTaskDependenciesReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTaskDependenciesReply)
+	err := c.cc.Invoke(ctx, Service_ListTaskDependencies_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
-func (UnimplementedServiceServer) mustEmbedUnimplementedServiceServer() {}
 
-// UnsafeServiceServer may be embedded to opt out of forward compatibility for this service.
-// Use of this interface is not recommended, as added methods to ServiceServer will
-// result in compilation errors.
-type UnsafeServiceServer interface {
-	mustEmbedUnimplementedServiceServer()
+func (c *serviceClient) MoveTask(ctx context.Context, in *MoveTaskRequest, opts ...grpc.CallOption) (*MoveTaskReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MoveTaskReply)
+	err := c.cc.Invoke(ctx, Service_MoveTask_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-func RegisterServiceServer(s grpc.ServiceRegistrar, srv ServiceServer) {
-	s.RegisterService(&Service_ServiceDesc, srv)
+func (c *serviceClient) CreateTaskType(ctx context.Context, in *CreateTaskTypeRequest, opts ...grpc.CallOption) (*CreateTaskTypeReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateTaskTypeReply)
+	err := c.cc.Invoke(ctx, Service_CreateTaskType_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) UpdateTaskType(ctx context.Context, in *UpdateTaskTypeRequest, opts ...grpc.CallOption) (*UpdateTaskTypeReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateTaskTypeReply)
+	err := c.cc.Invoke(ctx, Service_UpdateTaskType_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) DeleteTaskType(ctx context.Context, in *DeleteTaskTypeRequest, opts ...grpc.CallOption) (*DeleteTaskTypeReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteTaskTypeReply)
+	err := c.cc.Invoke(ctx, Service_DeleteTaskType_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) ListTaskTypes(ctx context.Context, in *ListTaskTypesRequest, opts ...grpc.CallOption) (*ListTaskTypesReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTaskTypesReply)
+	err := c.cc.Invoke(ctx, Service_ListTaskTypes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) CreateLabel(ctx context.Context, in *CreateLabelRequest, opts ...grpc.CallOption) (*CreateLabelReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateLabelReply)
+	err := c.cc.Invoke(ctx, Service_CreateLabel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) UpdateLabel(ctx context.Context, in *UpdateLabelRequest, opts ...grpc.CallOption) (*UpdateLabelReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateLabelReply)
+	err := c.cc.Invoke(ctx, Service_UpdateLabel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) DeleteLabel(ctx context.Context, in *DeleteLabelRequest, opts ...grpc.CallOption) (*DeleteLabelReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteLabelReply)
+	err := c.cc.Invoke(ctx, Service_DeleteLabel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) ListLabels(ctx context.Context, in *ListLabelsRequest, opts ...grpc.CallOption) (*ListLabelsReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListLabelsReply)
+	err := c.cc.Invoke(ctx, Service_ListLabels_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) AddLabels(ctx context.Context, in *AddLabelsRequest, opts ...grpc.CallOption) (*AddLabelsReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddLabelsReply)
+	err := c.cc.Invoke(ctx, Service_AddLabels_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) RemoveLabels(ctx context.Context, in *RemoveLabelsRequest, opts ...grpc.CallOption) (*RemoveLabelsReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RemoveLabelsReply)
+	err := c.cc.Invoke(ctx, Service_RemoveLabels_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) ImportXLSX(ctx context.Context, in *ImportXLSXRequest, opts ...grpc.CallOption) (*ImportXLSXReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImportXLSXReply)
+	err := c.cc.Invoke(ctx, Service_ImportXLSX_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) ImportExternalIssues(ctx context.Context, in *ImportExternalIssuesRequest, opts ...grpc.CallOption) (*ImportXLSXReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImportXLSXReply)
+	err := c.cc.Invoke(ctx, Service_ImportExternalIssues_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) ListImportBatches(ctx context.Context, in *ListImportBatchesRequest, opts ...grpc.CallOption) (*ListImportBatchesReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListImportBatchesReply)
+	err := c.cc.Invoke(ctx, Service_ListImportBatches_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) GetImportBatch(ctx context.Context, in *GetImportBatchRequest, opts ...grpc.CallOption) (*GetImportBatchReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetImportBatchReply)
+	err := c.cc.Invoke(ctx, Service_GetImportBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) RollbackImportBatch(ctx context.Context, in *RollbackImportBatchRequest, opts ...grpc.CallOption) (*RollbackImportBatchReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RollbackImportBatchReply)
+	err := c.cc.Invoke(ctx, Service_RollbackImportBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) ExportTasks(ctx context.Context, in *ExportTasksRequest, opts ...grpc.CallOption) (Service_ExportTasksClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Service_ServiceDesc.Streams[0], Service_ExportTasks_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &serviceExportTasksClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Service_ExportTasksClient interface {
+	Recv() (*ExportChunk, error)
+	grpc.ClientStream
+}
+
+type serviceExportTasksClient struct {
+	grpc.ClientStream
+}
+
+func (x *serviceExportTasksClient) Recv() (*ExportChunk, error) {
+	m := new(ExportChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *serviceClient) ExportPatchs(ctx context.Context, in *ExportPatchsRequest, opts ...grpc.CallOption) (Service_ExportPatchsClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Service_ServiceDesc.Streams[1], Service_ExportPatchs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &serviceExportPatchsClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Service_ExportPatchsClient interface {
+	Recv() (*ExportChunk, error)
+	grpc.ClientStream
+}
+
+type serviceExportPatchsClient struct {
+	grpc.ClientStream
+}
+
+func (x *serviceExportPatchsClient) Recv() (*ExportChunk, error) {
+	m := new(ExportChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *serviceClient) ExportWorkReport(ctx context.Context, in *ExportWorkReportRequest, opts ...grpc.CallOption) (Service_ExportWorkReportClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Service_ServiceDesc.Streams[2], Service_ExportWorkReport_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &serviceExportWorkReportClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Service_ExportWorkReportClient interface {
+	Recv() (*ExportChunk, error)
+	grpc.ClientStream
+}
+
+type serviceExportWorkReportClient struct {
+	grpc.ClientStream
+}
+
+func (x *serviceExportWorkReportClient) Recv() (*ExportChunk, error) {
+	m := new(ExportChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *serviceClient) ResetCalendarToken(ctx context.Context, in *ResetCalendarTokenRequest, opts ...grpc.CallOption) (*ResetCalendarTokenReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResetCalendarTokenReply)
+	err := c.cc.Invoke(ctx, Service_ResetCalendarToken_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) RevokeCalendarToken(ctx context.Context, in *RevokeCalendarTokenRequest, opts ...grpc.CallOption) (*RevokeCalendarTokenReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RevokeCalendarTokenReply)
+	err := c.cc.Invoke(ctx, Service_RevokeCalendarToken_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) LinkCommits(ctx context.Context, in *LinkCommitsRequest, opts ...grpc.CallOption) (*LinkCommitsReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LinkCommitsReply)
+	err := c.cc.Invoke(ctx, Service_LinkCommits_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) ListTaskCommits(ctx context.Context, in *ListTaskCommitsRequest, opts ...grpc.CallOption) (*ListTaskCommitsReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTaskCommitsReply)
+	err := c.cc.Invoke(ctx, Service_ListTaskCommits_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) SetWorkSchedule(ctx context.Context, in *SetWorkScheduleRequest, opts ...grpc.CallOption) (*SetWorkScheduleReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetWorkScheduleReply)
+	err := c.cc.Invoke(ctx, Service_SetWorkSchedule_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) AddLeave(ctx context.Context, in *AddLeaveRequest, opts ...grpc.CallOption) (*AddLeaveReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddLeaveReply)
+	err := c.cc.Invoke(ctx, Service_AddLeave_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) DeleteLeave(ctx context.Context, in *DeleteLeaveRequest, opts ...grpc.CallOption) (*DeleteLeaveReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteLeaveReply)
+	err := c.cc.Invoke(ctx, Service_DeleteLeave_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) ListLeaves(ctx context.Context, in *ListLeavesRequest, opts ...grpc.CallOption) (*ListLeavesReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListLeavesReply)
+	err := c.cc.Invoke(ctx, Service_ListLeaves_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) GetCapacity(ctx context.Context, in *GetCapacityRequest, opts ...grpc.CallOption) (*GetCapacityReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetCapacityReply)
+	err := c.cc.Invoke(ctx, Service_GetCapacity_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) SetBusinessDays(ctx context.Context, in *SetBusinessDaysRequest, opts ...grpc.CallOption) (*SetBusinessDaysReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetBusinessDaysReply)
+	err := c.cc.Invoke(ctx, Service_SetBusinessDays_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) DeleteBusinessDays(ctx context.Context, in *DeleteBusinessDaysRequest, opts ...grpc.CallOption) (*DeleteBusinessDaysReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteBusinessDaysReply)
+	err := c.cc.Invoke(ctx, Service_DeleteBusinessDays_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) ListBusinessDays(ctx context.Context, in *ListBusinessDaysRequest, opts ...grpc.CallOption) (*ListBusinessDaysReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListBusinessDaysReply)
+	err := c.cc.Invoke(ctx, Service_ListBusinessDays_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) ImportBusinessCalendar(ctx context.Context, in *ImportBusinessCalendarRequest, opts ...grpc.CallOption) (*ImportBusinessCalendarReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImportBusinessCalendarReply)
+	err := c.cc.Invoke(ctx, Service_ImportBusinessCalendar_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) CreateEscalationRule(ctx context.Context, in *CreateEscalationRuleRequest, opts ...grpc.CallOption) (*CreateEscalationRuleReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateEscalationRuleReply)
+	err := c.cc.Invoke(ctx, Service_CreateEscalationRule_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) UpdateEscalationRule(ctx context.Context, in *UpdateEscalationRuleRequest, opts ...grpc.CallOption) (*UpdateEscalationRuleReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateEscalationRuleReply)
+	err := c.cc.Invoke(ctx, Service_UpdateEscalationRule_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) DeleteEscalationRule(ctx context.Context, in *DeleteEscalationRuleRequest, opts ...grpc.CallOption) (*DeleteEscalationRuleReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteEscalationRuleReply)
+	err := c.cc.Invoke(ctx, Service_DeleteEscalationRule_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) ListEscalationRules(ctx context.Context, in *ListEscalationRulesRequest, opts ...grpc.CallOption) (*ListEscalationRulesReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListEscalationRulesReply)
+	err := c.cc.Invoke(ctx, Service_ListEscalationRules_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) RunEscalation(ctx context.Context, in *RunEscalationRequest, opts ...grpc.CallOption) (*RunEscalationReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RunEscalationReply)
+	err := c.cc.Invoke(ctx, Service_RunEscalation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) ListEscalations(ctx context.Context, in *ListEscalationsRequest, opts ...grpc.CallOption) (*ListEscalationsReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListEscalationsReply)
+	err := c.cc.Invoke(ctx, Service_ListEscalations_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serviceClient) Backup(ctx context.Context, in *BackupRequest, opts ...grpc.CallOption) (Service_BackupClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Service_ServiceDesc.Streams[3], Service_Backup_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &serviceBackupClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Service_BackupClient interface {
+	Recv() (*ExportChunk, error)
+	grpc.ClientStream
+}
+
+type serviceBackupClient struct {
+	grpc.ClientStream
+}
+
+func (x *serviceBackupClient) Recv() (*ExportChunk, error) {
+	m := new(ExportChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *serviceClient) Restore(ctx context.Context, opts ...grpc.CallOption) (Service_RestoreClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Service_ServiceDesc.Streams[4], Service_Restore_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &serviceRestoreClient{ClientStream: stream}
+	return x, nil
+}
+
+type Service_RestoreClient interface {
+	Send(*RestoreRequest) error
+	CloseAndRecv() (*RestoreReply, error)
+	grpc.ClientStream
+}
+
+type serviceRestoreClient struct {
+	grpc.ClientStream
+}
+
+func (x *serviceRestoreClient) Send(m *RestoreRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *serviceRestoreClient) CloseAndRecv() (*RestoreReply, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(RestoreReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ServiceServer is the server API for Service service.
+// All implementations must embed UnimplementedServiceServer
+// for forward compatibility
+//
+// 定义服务
+type ServiceServer interface {
+	Login(context.Context, *LoginRequest) (*LoginReply, error)
+	Register(context.Context, *RegisterRequest) (*RegisterReply, error)
+	// 账号
+	GetProfile(context.Context, *GetProfileRequest) (*GetProfileReply, error)
+	UpdateProfile(context.Context, *UpdateProfileRequest) (*UpdateProfileReply, error)
+	ChangePassword(context.Context, *ChangePasswordRequest) (*ChangePasswordReply, error)
+	DeactivateUser(context.Context, *DeactivateUserRequest) (*DeactivateUserReply, error)
+	ListUsers(context.Context, *ListUsersRequest) (*ListUsersReply, error)
+	Logout(context.Context, *LogoutRequest) (*LogoutReply, error)
+	RequestPasswordReset(context.Context, *RequestPasswordResetRequest) (*RequestPasswordResetReply, error)
+	ConfirmPasswordReset(context.Context, *ConfirmPasswordResetRequest) (*ConfirmPasswordResetReply, error)
+	ListAuthEvents(context.Context, *ListAuthEventsRequest) (*ListAuthEventsReply, error)
+	// 服务账号与 API Key
+	CreateServiceAccount(context.Context, *CreateServiceAccountRequest) (*CreateServiceAccountReply, error)
+	CreateAPIKey(context.Context, *CreateAPIKeyRequest) (*CreateAPIKeyReply, error)
+	ListAPIKeys(context.Context, *ListAPIKeysRequest) (*ListAPIKeysReply, error)
+	RevokeAPIKey(context.Context, *RevokeAPIKeyRequest) (*RevokeAPIKeyReply, error)
+	// 修改单/任务
+	GetTaskListAll(context.Context, *GetTaskListAllRequest) (*GetTaskListAllReply, error)
+	GetTaskListOne(context.Context, *GetTaskListOneRequest) (*GetTaskListOneReply, error)
+	ImportXLSToTaskTable(context.Context, *ImportToTaskListRequest) (*ImportToTaskListReply, error)
+	// CURD
+	DelTask(context.Context, *DelTaskRequest) (*DelTaskReply, error)
+	ModTask(context.Context, *ModTaskRequest) (*ModTaskReply, error)
+	AddTask(context.Context, *AddTaskRequest) (*AddTaskReply, error)
+	QueryTaskWithSQL(context.Context, *QueryTaskWithSQLRequest) (*QueryTaskWithSQLReply, error)
+	QueryTaskWithField(context.Context, *QueryTaskWithFieldRequest) (*QueryTaskWithFieldReply, error)
+	// 补丁
+	GetPatchsAll(context.Context, *GetPatchsAllRequest) (*GetPatchsAllReply, error)
+	GetOnePatchs(context.Context, *GetOnePatchsRequest) (*GetOnePatchsReply, error)
+	DelPatch(context.Context, *DelPatchRequest) (*DelPatchReply, error)
+	ImportXLSToPatchTable(context.Context, *ImportXLSToPatchRequest) (*ImportXLSToPatchReply, error)
+	ModPatch(context.Context, *ModPatchRequest) (*ModPatchReply, error)
+	// 变更历史
+	GetTaskHistory(context.Context, *GetTaskHistoryRequest) (*GetTaskHistoryReply, error)
+	GetPatchHistory(context.Context, *GetPatchHistoryRequest) (*GetPatchHistoryReply, error)
+	SearchAuditLog(context.Context, *SearchAuditLogRequest) (*SearchAuditLogReply, error)
+	// 回收站
+	ListTrash(context.Context, *ListTrashRequest) (*ListTrashReply, error)
+	RestoreTask(context.Context, *RestoreTaskRequest) (*RestoreTaskReply, error)
+	RestorePatch(context.Context, *RestorePatchRequest) (*RestorePatchReply, error)
+	// 评论
+	AddComment(context.Context, *AddCommentRequest) (*AddCommentReply, error)
+	EditComment(context.Context, *EditCommentRequest) (*EditCommentReply, error)
+	DeleteComment(context.Context, *DeleteCommentRequest) (*DeleteCommentReply, error)
+	ListComments(context.Context, *ListCommentsRequest) (*ListCommentsReply, error)
+	// 工时
+	LogWork(context.Context, *LogWorkRequest) (*LogWorkReply, error)
+	ListWorkLogs(context.Context, *ListWorkLogsRequest) (*ListWorkLogsReply, error)
+	GetWorkReport(context.Context, *GetWorkReportRequest) (*GetWorkReportReply, error)
+	// 修改单依赖
+	AddTaskDependency(context.Context, *AddTaskDependencyRequest) (*AddTaskDependencyReply, error)
+	RemoveTaskDependency(context.Context, *RemoveTaskDependencyRequest) (*RemoveTaskDependencyReply, error)
+	ListTaskDependencies(context.Context, *ListTaskDependenciesRequest) (*ListTaskDependenciesReply, error)
+	// 子任务
+	MoveTask(context.Context, *MoveTaskRequest) (*MoveTaskReply, error)
+	// 任务类型
+	CreateTaskType(context.Context, *CreateTaskTypeRequest) (*CreateTaskTypeReply, error)
+	UpdateTaskType(context.Context, *UpdateTaskTypeRequest) (*UpdateTaskTypeReply, error)
+	DeleteTaskType(context.Context, *DeleteTaskTypeRequest) (*DeleteTaskTypeReply, error)
+	ListTaskTypes(context.Context, *ListTaskTypesRequest) (*ListTaskTypesReply, error)
+	// 标签
+	CreateLabel(context.Context, *CreateLabelRequest) (*CreateLabelReply, error)
+	UpdateLabel(context.Context, *UpdateLabelRequest) (*UpdateLabelReply, error)
+	DeleteLabel(context.Context, *DeleteLabelRequest) (*DeleteLabelReply, error)
+	ListLabels(context.Context, *ListLabelsRequest) (*ListLabelsReply, error)
+	AddLabels(context.Context, *AddLabelsRequest) (*AddLabelsReply, error)
+	RemoveLabels(context.Context, *RemoveLabelsRequest) (*RemoveLabelsReply, error)
+	// 服务端解析 Excel 导入
+	ImportXLSX(context.Context, *ImportXLSXRequest) (*ImportXLSXReply, error)
+	ImportExternalIssues(context.Context, *ImportExternalIssuesRequest) (*ImportXLSXReply, error)
+	ListImportBatches(context.Context, *ListImportBatchesRequest) (*ListImportBatchesReply, error)
+	GetImportBatch(context.Context, *GetImportBatchRequest) (*GetImportBatchReply, error)
+	RollbackImportBatch(context.Context, *RollbackImportBatchRequest) (*RollbackImportBatchReply, error)
+	// 导出为 .xlsx 或 CSV，文件内容分片返回
+	ExportTasks(*ExportTasksRequest, Service_ExportTasksServer) error
+	ExportPatchs(*ExportPatchsRequest, Service_ExportPatchsServer) error
+	ExportWorkReport(*ExportWorkReportRequest, Service_ExportWorkReportServer) error
+	// 日历订阅（iCalendar）的密钥
+	ResetCalendarToken(context.Context, *ResetCalendarTokenRequest) (*ResetCalendarTokenReply, error)
+	RevokeCalendarToken(context.Context, *RevokeCalendarTokenRequest) (*RevokeCalendarTokenReply, error)
+	// 提交记录和合并请求关联修改单
+	LinkCommits(context.Context, *LinkCommitsRequest) (*LinkCommitsReply, error)
+	ListTaskCommits(context.Context, *ListTaskCommitsRequest) (*ListTaskCommitsReply, error)
+	// 工作时间、请假与负载
+	SetWorkSchedule(context.Context, *SetWorkScheduleRequest) (*SetWorkScheduleReply, error)
+	AddLeave(context.Context, *AddLeaveRequest) (*AddLeaveReply, error)
+	DeleteLeave(context.Context, *DeleteLeaveRequest) (*DeleteLeaveReply, error)
+	ListLeaves(context.Context, *ListLeavesRequest) (*ListLeavesReply, error)
+	GetCapacity(context.Context, *GetCapacityRequest) (*GetCapacityReply, error)
+	// 工作日历：节假日与调休
+	SetBusinessDays(context.Context, *SetBusinessDaysRequest) (*SetBusinessDaysReply, error)
+	DeleteBusinessDays(context.Context, *DeleteBusinessDaysRequest) (*DeleteBusinessDaysReply, error)
+	ListBusinessDays(context.Context, *ListBusinessDaysRequest) (*ListBusinessDaysReply, error)
+	ImportBusinessCalendar(context.Context, *ImportBusinessCalendarRequest) (*ImportBusinessCalendarReply, error)
+	// 紧急程度自动升级
+	CreateEscalationRule(context.Context, *CreateEscalationRuleRequest) (*CreateEscalationRuleReply, error)
+	UpdateEscalationRule(context.Context, *UpdateEscalationRuleRequest) (*UpdateEscalationRuleReply, error)
+	DeleteEscalationRule(context.Context, *DeleteEscalationRuleRequest) (*DeleteEscalationRuleReply, error)
+	ListEscalationRules(context.Context, *ListEscalationRulesRequest) (*ListEscalationRulesReply, error)
+	RunEscalation(context.Context, *RunEscalationRequest) (*RunEscalationReply, error)
+	ListEscalations(context.Context, *ListEscalationsRequest) (*ListEscalationsReply, error)
+	// 备份与恢复，仅管理员
+	Backup(*BackupRequest, Service_BackupServer) error
+	Restore(Service_RestoreServer) error
+	mustEmbedUnimplementedServiceServer()
+}
+
+// UnimplementedServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedServiceServer struct {
+}
+
+func (UnimplementedServiceServer) Login(context.Context, *LoginRequest) (*LoginReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
+}
+func (UnimplementedServiceServer) Register(context.Context, *RegisterRequest) (*RegisterReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
+}
+func (UnimplementedServiceServer) GetProfile(context.Context, *GetProfileRequest) (*GetProfileReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetProfile not implemented")
+}
+func (UnimplementedServiceServer) UpdateProfile(context.Context, *UpdateProfileRequest) (*UpdateProfileReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateProfile not implemented")
+}
+func (UnimplementedServiceServer) ChangePassword(context.Context, *ChangePasswordRequest) (*ChangePasswordReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChangePassword not implemented")
+}
+func (UnimplementedServiceServer) DeactivateUser(context.Context, *DeactivateUserRequest) (*DeactivateUserReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeactivateUser not implemented")
+}
+func (UnimplementedServiceServer) ListUsers(context.Context, *ListUsersRequest) (*ListUsersReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListUsers not implemented")
+}
+func (UnimplementedServiceServer) Logout(context.Context, *LogoutRequest) (*LogoutReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Logout not implemented")
+}
+func (UnimplementedServiceServer) RequestPasswordReset(context.Context, *RequestPasswordResetRequest) (*RequestPasswordResetReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestPasswordReset not implemented")
+}
+func (UnimplementedServiceServer) ConfirmPasswordReset(context.Context, *ConfirmPasswordResetRequest) (*ConfirmPasswordResetReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmPasswordReset not implemented")
+}
+func (UnimplementedServiceServer) ListAuthEvents(context.Context, *ListAuthEventsRequest) (*ListAuthEventsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAuthEvents not implemented")
+}
+func (UnimplementedServiceServer) CreateServiceAccount(context.Context, *CreateServiceAccountRequest) (*CreateServiceAccountReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateServiceAccount not implemented")
+}
+func (UnimplementedServiceServer) CreateAPIKey(context.Context, *CreateAPIKeyRequest) (*CreateAPIKeyReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateAPIKey not implemented")
+}
+func (UnimplementedServiceServer) ListAPIKeys(context.Context, *ListAPIKeysRequest) (*ListAPIKeysReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAPIKeys not implemented")
+}
+func (UnimplementedServiceServer) RevokeAPIKey(context.Context, *RevokeAPIKeyRequest) (*RevokeAPIKeyReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeAPIKey not implemented")
+}
+func (UnimplementedServiceServer) GetTaskListAll(context.Context, *GetTaskListAllRequest) (*GetTaskListAllReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTaskListAll not implemented")
+}
+func (UnimplementedServiceServer) GetTaskListOne(context.Context, *GetTaskListOneRequest) (*GetTaskListOneReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTaskListOne not implemented")
+}
+func (UnimplementedServiceServer) ImportXLSToTaskTable(context.Context, *ImportToTaskListRequest) (*ImportToTaskListReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ImportXLSToTaskTable not implemented")
+}
+func (UnimplementedServiceServer) DelTask(context.Context, *DelTaskRequest) (*DelTaskReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DelTask not implemented")
+}
+func (UnimplementedServiceServer) ModTask(context.Context, *ModTaskRequest) (*ModTaskReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ModTask not implemented")
+}
+func (UnimplementedServiceServer) AddTask(context.Context, *AddTaskRequest) (*AddTaskReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddTask not implemented")
+}
+func (UnimplementedServiceServer) QueryTaskWithSQL(context.Context, *QueryTaskWithSQLRequest) (*QueryTaskWithSQLReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryTaskWithSQL not implemented")
+}
+func (UnimplementedServiceServer) QueryTaskWithField(context.Context, *QueryTaskWithFieldRequest) (*QueryTaskWithFieldReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryTaskWithField not implemented")
+}
+func (UnimplementedServiceServer) GetPatchsAll(context.Context, *GetPatchsAllRequest) (*GetPatchsAllReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPatchsAll not implemented")
+}
+func (UnimplementedServiceServer) GetOnePatchs(context.Context, *GetOnePatchsRequest) (*GetOnePatchsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOnePatchs not implemented")
+}
+func (UnimplementedServiceServer) DelPatch(context.Context, *DelPatchRequest) (*DelPatchReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DelPatch not implemented")
+}
+func (UnimplementedServiceServer) ImportXLSToPatchTable(context.Context, *ImportXLSToPatchRequest) (*ImportXLSToPatchReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ImportXLSToPatchTable not implemented")
+}
+func (UnimplementedServiceServer) ModPatch(context.Context, *ModPatchRequest) (*ModPatchReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ModPatch not implemented")
+}
+func (UnimplementedServiceServer) GetTaskHistory(context.Context, *GetTaskHistoryRequest) (*GetTaskHistoryReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTaskHistory not implemented")
+}
+func (UnimplementedServiceServer) GetPatchHistory(context.Context, *GetPatchHistoryRequest) (*GetPatchHistoryReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPatchHistory not implemented")
+}
+func (UnimplementedServiceServer) SearchAuditLog(context.Context, *SearchAuditLogRequest) (*SearchAuditLogReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchAuditLog not implemented")
+}
+func (UnimplementedServiceServer) ListTrash(context.Context, *ListTrashRequest) (*ListTrashReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTrash not implemented")
+}
+func (UnimplementedServiceServer) RestoreTask(context.Context, *RestoreTaskRequest) (*RestoreTaskReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreTask not implemented")
+}
+func (UnimplementedServiceServer) RestorePatch(context.Context, *RestorePatchRequest) (*RestorePatchReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestorePatch not implemented")
+}
+func (UnimplementedServiceServer) AddComment(context.Context, *AddCommentRequest) (*AddCommentReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddComment not implemented")
+}
+func (UnimplementedServiceServer) EditComment(context.Context, *EditCommentRequest) (*EditCommentReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EditComment not implemented")
+}
+func (UnimplementedServiceServer) DeleteComment(context.Context, *DeleteCommentRequest) (*DeleteCommentReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteComment not implemented")
+}
+func (UnimplementedServiceServer) ListComments(context.Context, *ListCommentsRequest) (*ListCommentsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListComments not implemented")
+}
+func (UnimplementedServiceServer) LogWork(context.Context, *LogWorkRequest) (*LogWorkReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LogWork not implemented")
+}
+func (UnimplementedServiceServer) ListWorkLogs(context.Context, *ListWorkLogsRequest) (*ListWorkLogsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWorkLogs not implemented")
+}
+func (UnimplementedServiceServer) GetWorkReport(context.Context, *GetWorkReportRequest) (*GetWorkReportReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetWorkReport not implemented")
+}
+func (UnimplementedServiceServer) AddTaskDependency(context.Context, *AddTaskDependencyRequest) (*AddTaskDependencyReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddTaskDependency not implemented")
+}
+func (UnimplementedServiceServer) RemoveTaskDependency(context.Context, *RemoveTaskDependencyRequest) (*RemoveTaskDependencyReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveTaskDependency not implemented")
+}
+func (UnimplementedServiceServer) ListTaskDependencies(context.Context, *ListTaskDependenciesRequest) (*ListTaskDependenciesReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTaskDependencies not implemented")
+}
+func (UnimplementedServiceServer) MoveTask(context.Context, *MoveTaskRequest) (*MoveTaskReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MoveTask not implemented")
+}
+func (UnimplementedServiceServer) CreateTaskType(context.Context, *CreateTaskTypeRequest) (*CreateTaskTypeReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateTaskType not implemented")
+}
+func (UnimplementedServiceServer) UpdateTaskType(context.Context, *UpdateTaskTypeRequest) (*UpdateTaskTypeReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateTaskType not implemented")
+}
+func (UnimplementedServiceServer) DeleteTaskType(context.Context, *DeleteTaskTypeRequest) (*DeleteTaskTypeReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteTaskType not implemented")
+}
+func (UnimplementedServiceServer) ListTaskTypes(context.Context, *ListTaskTypesRequest) (*ListTaskTypesReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTaskTypes not implemented")
+}
+func (UnimplementedServiceServer) CreateLabel(context.Context, *CreateLabelRequest) (*CreateLabelReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateLabel not implemented")
+}
+func (UnimplementedServiceServer) UpdateLabel(context.Context, *UpdateLabelRequest) (*UpdateLabelReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateLabel not implemented")
+}
+func (UnimplementedServiceServer) DeleteLabel(context.Context, *DeleteLabelRequest) (*DeleteLabelReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteLabel not implemented")
+}
+func (UnimplementedServiceServer) ListLabels(context.Context, *ListLabelsRequest) (*ListLabelsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListLabels not implemented")
+}
+func (UnimplementedServiceServer) AddLabels(context.Context, *AddLabelsRequest) (*AddLabelsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddLabels not implemented")
+}
+func (UnimplementedServiceServer) RemoveLabels(context.Context, *RemoveLabelsRequest) (*RemoveLabelsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveLabels not implemented")
+}
+func (UnimplementedServiceServer) ImportXLSX(context.Context, *ImportXLSXRequest) (*ImportXLSXReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ImportXLSX not implemented")
+}
+func (UnimplementedServiceServer) ImportExternalIssues(context.Context, *ImportExternalIssuesRequest) (*ImportXLSXReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ImportExternalIssues not implemented")
+}
+func (UnimplementedServiceServer) ListImportBatches(context.Context, *ListImportBatchesRequest) (*ListImportBatchesReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListImportBatches not implemented")
+}
+func (UnimplementedServiceServer) GetImportBatch(context.Context, *GetImportBatchRequest) (*GetImportBatchReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetImportBatch not implemented")
+}
+func (UnimplementedServiceServer) RollbackImportBatch(context.Context, *RollbackImportBatchRequest) (*RollbackImportBatchReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RollbackImportBatch not implemented")
+}
+func (UnimplementedServiceServer) ExportTasks(*ExportTasksRequest, Service_ExportTasksServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportTasks not implemented")
+}
+func (UnimplementedServiceServer) ExportPatchs(*ExportPatchsRequest, Service_ExportPatchsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportPatchs not implemented")
+}
+func (UnimplementedServiceServer) ExportWorkReport(*ExportWorkReportRequest, Service_ExportWorkReportServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportWorkReport not implemented")
+}
+func (UnimplementedServiceServer) ResetCalendarToken(context.Context, *ResetCalendarTokenRequest) (*ResetCalendarTokenReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetCalendarToken not implemented")
+}
+func (UnimplementedServiceServer) RevokeCalendarToken(context.Context, *RevokeCalendarTokenRequest) (*RevokeCalendarTokenReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeCalendarToken not implemented")
+}
+func (UnimplementedServiceServer) LinkCommits(context.Context, *LinkCommitsRequest) (*LinkCommitsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LinkCommits not implemented")
+}
+func (UnimplementedServiceServer) ListTaskCommits(context.Context, *ListTaskCommitsRequest) (*ListTaskCommitsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTaskCommits not implemented")
+}
+func (UnimplementedServiceServer) SetWorkSchedule(context.Context, *SetWorkScheduleRequest) (*SetWorkScheduleReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetWorkSchedule not implemented")
+}
+func (UnimplementedServiceServer) AddLeave(context.Context, *AddLeaveRequest) (*AddLeaveReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddLeave not implemented")
+}
+func (UnimplementedServiceServer) DeleteLeave(context.Context, *DeleteLeaveRequest) (*DeleteLeaveReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteLeave not implemented")
+}
+func (UnimplementedServiceServer) ListLeaves(context.Context, *ListLeavesRequest) (*ListLeavesReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListLeaves not implemented")
+}
+func (UnimplementedServiceServer) GetCapacity(context.Context, *GetCapacityRequest) (*GetCapacityReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCapacity not implemented")
+}
+func (UnimplementedServiceServer) SetBusinessDays(context.Context, *SetBusinessDaysRequest) (*SetBusinessDaysReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetBusinessDays not implemented")
+}
+func (UnimplementedServiceServer) DeleteBusinessDays(context.Context, *DeleteBusinessDaysRequest) (*DeleteBusinessDaysReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteBusinessDays not implemented")
+}
+func (UnimplementedServiceServer) ListBusinessDays(context.Context, *ListBusinessDaysRequest) (*ListBusinessDaysReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBusinessDays not implemented")
+}
+func (UnimplementedServiceServer) ImportBusinessCalendar(context.Context, *ImportBusinessCalendarRequest) (*ImportBusinessCalendarReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ImportBusinessCalendar not implemented")
+}
+func (UnimplementedServiceServer) CreateEscalationRule(context.Context, *CreateEscalationRuleRequest) (*CreateEscalationRuleReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateEscalationRule not implemented")
+}
+func (UnimplementedServiceServer) UpdateEscalationRule(context.Context, *UpdateEscalationRuleRequest) (*UpdateEscalationRuleReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateEscalationRule not implemented")
+}
+func (UnimplementedServiceServer) DeleteEscalationRule(context.Context, *DeleteEscalationRuleRequest) (*DeleteEscalationRuleReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteEscalationRule not implemented")
+}
+func (UnimplementedServiceServer) ListEscalationRules(context.Context, *ListEscalationRulesRequest) (*ListEscalationRulesReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEscalationRules not implemented")
+}
+func (UnimplementedServiceServer) RunEscalation(context.Context, *RunEscalationRequest) (*RunEscalationReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RunEscalation not implemented")
+}
+func (UnimplementedServiceServer) ListEscalations(context.Context, *ListEscalationsRequest) (*ListEscalationsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEscalations not implemented")
+}
+func (UnimplementedServiceServer) Backup(*BackupRequest, Service_BackupServer) error {
+	return status.Errorf(codes.Unimplemented, "method Backup not implemented")
+}
+func (UnimplementedServiceServer) Restore(Service_RestoreServer) error {
+	return status.Errorf(codes.Unimplemented, "method Restore not implemented")
+}
+func (UnimplementedServiceServer) mustEmbedUnimplementedServiceServer() {}
+
+// UnsafeServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ServiceServer will
+// result in compilation errors.
+type UnsafeServiceServer interface {
+	mustEmbedUnimplementedServiceServer()
+}
+
+func RegisterServiceServer(s grpc.ServiceRegistrar, srv ServiceServer) {
+	s.RegisterService(&Service_ServiceDesc, srv)
+}
+
+func _Service_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_Login_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).Login(ctx, req.(*LoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_Register_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).Register(ctx, req.(*RegisterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_GetProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetProfileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).GetProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_GetProfile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).GetProfile(ctx, req.(*GetProfileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_UpdateProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateProfileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).UpdateProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_UpdateProfile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).UpdateProfile(ctx, req.(*UpdateProfileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_ChangePassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChangePasswordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).ChangePassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_ChangePassword_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).ChangePassword(ctx, req.(*ChangePasswordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_DeactivateUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeactivateUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).DeactivateUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_DeactivateUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).DeactivateUser(ctx, req.(*DeactivateUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_ListUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListUsersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).ListUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_ListUsers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).ListUsers(ctx, req.(*ListUsersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_Logout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogoutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).Logout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_Logout_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).Logout(ctx, req.(*LogoutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_RequestPasswordReset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestPasswordResetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).RequestPasswordReset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_RequestPasswordReset_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).RequestPasswordReset(ctx, req.(*RequestPasswordResetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_ConfirmPasswordReset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfirmPasswordResetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).ConfirmPasswordReset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_ConfirmPasswordReset_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).ConfirmPasswordReset(ctx, req.(*ConfirmPasswordResetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_ListAuthEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAuthEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).ListAuthEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_ListAuthEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).ListAuthEvents(ctx, req.(*ListAuthEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_CreateServiceAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateServiceAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).CreateServiceAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_CreateServiceAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).CreateServiceAccount(ctx, req.(*CreateServiceAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_CreateAPIKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateAPIKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).CreateAPIKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_CreateAPIKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).CreateAPIKey(ctx, req.(*CreateAPIKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_ListAPIKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAPIKeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).ListAPIKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_ListAPIKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).ListAPIKeys(ctx, req.(*ListAPIKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_RevokeAPIKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeAPIKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).RevokeAPIKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_RevokeAPIKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).RevokeAPIKey(ctx, req.(*RevokeAPIKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_GetTaskListAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTaskListAllRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).GetTaskListAll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_GetTaskListAll_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).GetTaskListAll(ctx, req.(*GetTaskListAllRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_GetTaskListOne_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTaskListOneRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).GetTaskListOne(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_GetTaskListOne_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).GetTaskListOne(ctx, req.(*GetTaskListOneRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_ImportXLSToTaskTable_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportToTaskListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).ImportXLSToTaskTable(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_ImportXLSToTaskTable_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).ImportXLSToTaskTable(ctx, req.(*ImportToTaskListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_DelTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DelTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).DelTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_DelTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).DelTask(ctx, req.(*DelTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_ModTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ModTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).ModTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_ModTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).ModTask(ctx, req.(*ModTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_AddTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).AddTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_AddTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).AddTask(ctx, req.(*AddTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_QueryTaskWithSQL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryTaskWithSQLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).QueryTaskWithSQL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_QueryTaskWithSQL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).QueryTaskWithSQL(ctx, req.(*QueryTaskWithSQLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_QueryTaskWithField_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryTaskWithFieldRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).QueryTaskWithField(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_QueryTaskWithField_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).QueryTaskWithField(ctx, req.(*QueryTaskWithFieldRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_GetPatchsAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPatchsAllRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).GetPatchsAll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_GetPatchsAll_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).GetPatchsAll(ctx, req.(*GetPatchsAllRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_GetOnePatchs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOnePatchsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).GetOnePatchs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_GetOnePatchs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).GetOnePatchs(ctx, req.(*GetOnePatchsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_DelPatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DelPatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).DelPatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_DelPatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).DelPatch(ctx, req.(*DelPatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_ImportXLSToPatchTable_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportXLSToPatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).ImportXLSToPatchTable(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_ImportXLSToPatchTable_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).ImportXLSToPatchTable(ctx, req.(*ImportXLSToPatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_ModPatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ModPatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).ModPatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_ModPatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).ModPatch(ctx, req.(*ModPatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_GetTaskHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTaskHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).GetTaskHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_GetTaskHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).GetTaskHistory(ctx, req.(*GetTaskHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_GetPatchHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPatchHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).GetPatchHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_GetPatchHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).GetPatchHistory(ctx, req.(*GetPatchHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_SearchAuditLog_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchAuditLogRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).SearchAuditLog(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_SearchAuditLog_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).SearchAuditLog(ctx, req.(*SearchAuditLogRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_ListTrash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTrashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).ListTrash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_ListTrash_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).ListTrash(ctx, req.(*ListTrashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_RestoreTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).RestoreTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_RestoreTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).RestoreTask(ctx, req.(*RestoreTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_RestorePatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestorePatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).RestorePatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_RestorePatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).RestorePatch(ctx, req.(*RestorePatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_AddComment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddCommentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).AddComment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_AddComment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).AddComment(ctx, req.(*AddCommentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_EditComment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EditCommentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).EditComment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_EditComment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).EditComment(ctx, req.(*EditCommentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_DeleteComment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCommentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).DeleteComment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_DeleteComment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).DeleteComment(ctx, req.(*DeleteCommentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_ListComments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCommentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).ListComments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_ListComments_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).ListComments(ctx, req.(*ListCommentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_LogWork_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogWorkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).LogWork(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_LogWork_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).LogWork(ctx, req.(*LogWorkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_ListWorkLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWorkLogsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).ListWorkLogs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_ListWorkLogs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).ListWorkLogs(ctx, req.(*ListWorkLogsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_GetWorkReport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetWorkReportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).GetWorkReport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_GetWorkReport_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).GetWorkReport(ctx, req.(*GetWorkReportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_AddTaskDependency_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddTaskDependencyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).AddTaskDependency(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_AddTaskDependency_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).AddTaskDependency(ctx, req.(*AddTaskDependencyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_RemoveTaskDependency_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveTaskDependencyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).RemoveTaskDependency(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_RemoveTaskDependency_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).RemoveTaskDependency(ctx, req.(*RemoveTaskDependencyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_ListTaskDependencies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTaskDependenciesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).ListTaskDependencies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_ListTaskDependencies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).ListTaskDependencies(ctx, req.(*ListTaskDependenciesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_MoveTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MoveTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).MoveTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_MoveTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).MoveTask(ctx, req.(*MoveTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_CreateTaskType_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateTaskTypeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).CreateTaskType(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_CreateTaskType_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).CreateTaskType(ctx, req.(*CreateTaskTypeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_UpdateTaskType_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateTaskTypeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).UpdateTaskType(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_UpdateTaskType_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).UpdateTaskType(ctx, req.(*UpdateTaskTypeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_DeleteTaskType_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteTaskTypeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).DeleteTaskType(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_DeleteTaskType_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).DeleteTaskType(ctx, req.(*DeleteTaskTypeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_ListTaskTypes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTaskTypesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).ListTaskTypes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_ListTaskTypes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).ListTaskTypes(ctx, req.(*ListTaskTypesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_CreateLabel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateLabelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).CreateLabel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_CreateLabel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).CreateLabel(ctx, req.(*CreateLabelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_UpdateLabel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateLabelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).UpdateLabel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_UpdateLabel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).UpdateLabel(ctx, req.(*UpdateLabelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_DeleteLabel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteLabelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).DeleteLabel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_DeleteLabel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).DeleteLabel(ctx, req.(*DeleteLabelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_ListLabels_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListLabelsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).ListLabels(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_ListLabels_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).ListLabels(ctx, req.(*ListLabelsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_AddLabels_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddLabelsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).AddLabels(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_AddLabels_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).AddLabels(ctx, req.(*AddLabelsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_RemoveLabels_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveLabelsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).RemoveLabels(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_RemoveLabels_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).RemoveLabels(ctx, req.(*RemoveLabelsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_ImportXLSX_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportXLSXRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).ImportXLSX(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_ImportXLSX_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).ImportXLSX(ctx, req.(*ImportXLSXRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_ImportExternalIssues_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportExternalIssuesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).ImportExternalIssues(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_ImportExternalIssues_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).ImportExternalIssues(ctx, req.(*ImportExternalIssuesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_ListImportBatches_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListImportBatchesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).ListImportBatches(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_ListImportBatches_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).ListImportBatches(ctx, req.(*ListImportBatchesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_GetImportBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetImportBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).GetImportBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_GetImportBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).GetImportBatch(ctx, req.(*GetImportBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_RollbackImportBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RollbackImportBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).RollbackImportBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_RollbackImportBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).RollbackImportBatch(ctx, req.(*RollbackImportBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_ExportTasks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportTasksRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ServiceServer).ExportTasks(m, &serviceExportTasksServer{ServerStream: stream})
+}
+
+type Service_ExportTasksServer interface {
+	Send(*ExportChunk) error
+	grpc.ServerStream
+}
+
+type serviceExportTasksServer struct {
+	grpc.ServerStream
+}
+
+func (x *serviceExportTasksServer) Send(m *ExportChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Service_ExportPatchs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportPatchsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ServiceServer).ExportPatchs(m, &serviceExportPatchsServer{ServerStream: stream})
+}
+
+type Service_ExportPatchsServer interface {
+	Send(*ExportChunk) error
+	grpc.ServerStream
+}
+
+type serviceExportPatchsServer struct {
+	grpc.ServerStream
+}
+
+func (x *serviceExportPatchsServer) Send(m *ExportChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Service_ExportWorkReport_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportWorkReportRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ServiceServer).ExportWorkReport(m, &serviceExportWorkReportServer{ServerStream: stream})
+}
+
+type Service_ExportWorkReportServer interface {
+	Send(*ExportChunk) error
+	grpc.ServerStream
+}
+
+type serviceExportWorkReportServer struct {
+	grpc.ServerStream
+}
+
+func (x *serviceExportWorkReportServer) Send(m *ExportChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Service_ResetCalendarToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResetCalendarTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).ResetCalendarToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_ResetCalendarToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).ResetCalendarToken(ctx, req.(*ResetCalendarTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_RevokeCalendarToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeCalendarTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).RevokeCalendarToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_RevokeCalendarToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).RevokeCalendarToken(ctx, req.(*RevokeCalendarTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_LinkCommits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LinkCommitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).LinkCommits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_LinkCommits_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).LinkCommits(ctx, req.(*LinkCommitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Service_ListTaskCommits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTaskCommitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceServer).ListTaskCommits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Service_ListTaskCommits_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceServer).ListTaskCommits(ctx, req.(*ListTaskCommitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func _Service_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(LoginRequest)
+func _Service_SetWorkSchedule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetWorkScheduleRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ServiceServer).Login(ctx, in)
+		return srv.(ServiceServer).SetWorkSchedule(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Service_Login_FullMethodName,
+		FullMethod: Service_SetWorkSchedule_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ServiceServer).Login(ctx, req.(*LoginRequest))
+		return srv.(ServiceServer).SetWorkSchedule(ctx, req.(*SetWorkScheduleRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Service_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(RegisterRequest)
+func _Service_AddLeave_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddLeaveRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ServiceServer).Register(ctx, in)
+		return srv.(ServiceServer).AddLeave(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Service_Register_FullMethodName,
+		FullMethod: Service_AddLeave_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ServiceServer).Register(ctx, req.(*RegisterRequest))
+		return srv.(ServiceServer).AddLeave(ctx, req.(*AddLeaveRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Service_GetTaskListAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetTaskListAllRequest)
+func _Service_DeleteLeave_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteLeaveRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ServiceServer).GetTaskListAll(ctx, in)
+		return srv.(ServiceServer).DeleteLeave(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Service_GetTaskListAll_FullMethodName,
+		FullMethod: Service_DeleteLeave_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ServiceServer).GetTaskListAll(ctx, req.(*GetTaskListAllRequest))
+		return srv.(ServiceServer).DeleteLeave(ctx, req.(*DeleteLeaveRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Service_GetTaskListOne_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetTaskListOneRequest)
+func _Service_ListLeaves_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListLeavesRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ServiceServer).GetTaskListOne(ctx, in)
+		return srv.(ServiceServer).ListLeaves(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Service_GetTaskListOne_FullMethodName,
+		FullMethod: Service_ListLeaves_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ServiceServer).GetTaskListOne(ctx, req.(*GetTaskListOneRequest))
+		return srv.(ServiceServer).ListLeaves(ctx, req.(*ListLeavesRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Service_ImportXLSToTaskTable_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ImportToTaskListRequest)
+func _Service_GetCapacity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCapacityRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ServiceServer).ImportXLSToTaskTable(ctx, in)
+		return srv.(ServiceServer).GetCapacity(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Service_ImportXLSToTaskTable_FullMethodName,
+		FullMethod: Service_GetCapacity_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ServiceServer).ImportXLSToTaskTable(ctx, req.(*ImportToTaskListRequest))
+		return srv.(ServiceServer).GetCapacity(ctx, req.(*GetCapacityRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Service_DelTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(DelTaskRequest)
+func _Service_SetBusinessDays_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetBusinessDaysRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ServiceServer).DelTask(ctx, in)
+		return srv.(ServiceServer).SetBusinessDays(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Service_DelTask_FullMethodName,
+		FullMethod: Service_SetBusinessDays_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ServiceServer).DelTask(ctx, req.(*DelTaskRequest))
+		return srv.(ServiceServer).SetBusinessDays(ctx, req.(*SetBusinessDaysRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Service_ModTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ModTaskRequest)
+func _Service_DeleteBusinessDays_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteBusinessDaysRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ServiceServer).ModTask(ctx, in)
+		return srv.(ServiceServer).DeleteBusinessDays(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Service_ModTask_FullMethodName,
+		FullMethod: Service_DeleteBusinessDays_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ServiceServer).ModTask(ctx, req.(*ModTaskRequest))
+		return srv.(ServiceServer).DeleteBusinessDays(ctx, req.(*DeleteBusinessDaysRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Service_AddTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(AddTaskRequest)
+func _Service_ListBusinessDays_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBusinessDaysRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ServiceServer).AddTask(ctx, in)
+		return srv.(ServiceServer).ListBusinessDays(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Service_AddTask_FullMethodName,
+		FullMethod: Service_ListBusinessDays_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ServiceServer).AddTask(ctx, req.(*AddTaskRequest))
+		return srv.(ServiceServer).ListBusinessDays(ctx, req.(*ListBusinessDaysRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Service_QueryTaskWithSQL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(QueryTaskWithSQLRequest)
+func _Service_ImportBusinessCalendar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportBusinessCalendarRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ServiceServer).QueryTaskWithSQL(ctx, in)
+		return srv.(ServiceServer).ImportBusinessCalendar(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Service_QueryTaskWithSQL_FullMethodName,
+		FullMethod: Service_ImportBusinessCalendar_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ServiceServer).QueryTaskWithSQL(ctx, req.(*QueryTaskWithSQLRequest))
+		return srv.(ServiceServer).ImportBusinessCalendar(ctx, req.(*ImportBusinessCalendarRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Service_QueryTaskWithField_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(QueryTaskWithFieldRequest)
+func _Service_CreateEscalationRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateEscalationRuleRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ServiceServer).QueryTaskWithField(ctx, in)
+		return srv.(ServiceServer).CreateEscalationRule(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Service_QueryTaskWithField_FullMethodName,
+		FullMethod: Service_CreateEscalationRule_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ServiceServer).QueryTaskWithField(ctx, req.(*QueryTaskWithFieldRequest))
+		return srv.(ServiceServer).CreateEscalationRule(ctx, req.(*CreateEscalationRuleRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Service_GetPatchsAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetPatchsAllRequest)
+func _Service_UpdateEscalationRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateEscalationRuleRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ServiceServer).GetPatchsAll(ctx, in)
+		return srv.(ServiceServer).UpdateEscalationRule(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Service_GetPatchsAll_FullMethodName,
+		FullMethod: Service_UpdateEscalationRule_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ServiceServer).GetPatchsAll(ctx, req.(*GetPatchsAllRequest))
+		return srv.(ServiceServer).UpdateEscalationRule(ctx, req.(*UpdateEscalationRuleRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Service_GetOnePatchs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetOnePatchsRequest)
+func _Service_DeleteEscalationRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteEscalationRuleRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ServiceServer).GetOnePatchs(ctx, in)
+		return srv.(ServiceServer).DeleteEscalationRule(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Service_GetOnePatchs_FullMethodName,
+		FullMethod: Service_DeleteEscalationRule_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ServiceServer).GetOnePatchs(ctx, req.(*GetOnePatchsRequest))
+		return srv.(ServiceServer).DeleteEscalationRule(ctx, req.(*DeleteEscalationRuleRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Service_DelPatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(DelPatchRequest)
+func _Service_ListEscalationRules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListEscalationRulesRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ServiceServer).DelPatch(ctx, in)
+		return srv.(ServiceServer).ListEscalationRules(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Service_DelPatch_FullMethodName,
+		FullMethod: Service_ListEscalationRules_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ServiceServer).DelPatch(ctx, req.(*DelPatchRequest))
+		return srv.(ServiceServer).ListEscalationRules(ctx, req.(*ListEscalationRulesRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Service_ImportXLSToPatchTable_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ImportXLSToPatchRequest)
+func _Service_RunEscalation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RunEscalationRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ServiceServer).ImportXLSToPatchTable(ctx, in)
+		return srv.(ServiceServer).RunEscalation(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Service_ImportXLSToPatchTable_FullMethodName,
+		FullMethod: Service_RunEscalation_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ServiceServer).ImportXLSToPatchTable(ctx, req.(*ImportXLSToPatchRequest))
+		return srv.(ServiceServer).RunEscalation(ctx, req.(*RunEscalationRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Service_ModPatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ModPatchRequest)
+func _Service_ListEscalations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListEscalationsRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ServiceServer).ModPatch(ctx, in)
+		return srv.(ServiceServer).ListEscalations(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: Service_ModPatch_FullMethodName,
+		FullMethod: Service_ListEscalations_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ServiceServer).ModPatch(ctx, req.(*ModPatchRequest))
+		return srv.(ServiceServer).ListEscalations(ctx, req.(*ListEscalationsRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Service_Backup_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BackupRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ServiceServer).Backup(m, &serviceBackupServer{ServerStream: stream})
+}
+
+type Service_BackupServer interface {
+	Send(*ExportChunk) error
+	grpc.ServerStream
+}
+
+type serviceBackupServer struct {
+	grpc.ServerStream
+}
+
+func (x *serviceBackupServer) Send(m *ExportChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Service_Restore_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ServiceServer).Restore(&serviceRestoreServer{ServerStream: stream})
+}
+
+type Service_RestoreServer interface {
+	SendAndClose(*RestoreReply) error
+	Recv() (*RestoreRequest, error)
+	grpc.ServerStream
+}
+
+type serviceRestoreServer struct {
+	grpc.ServerStream
+}
+
+func (x *serviceRestoreServer) SendAndClose(m *RestoreReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *serviceRestoreServer) Recv() (*RestoreRequest, error) {
+	m := new(RestoreRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // Service_ServiceDesc is the grpc.ServiceDesc for Service service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -720,6 +3239,58 @@ var Service_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Register",
 			Handler:    _Service_Register_Handler,
 		},
+		{
+			MethodName: "GetProfile",
+			Handler:    _Service_GetProfile_Handler,
+		},
+		{
+			MethodName: "UpdateProfile",
+			Handler:    _Service_UpdateProfile_Handler,
+		},
+		{
+			MethodName: "ChangePassword",
+			Handler:    _Service_ChangePassword_Handler,
+		},
+		{
+			MethodName: "DeactivateUser",
+			Handler:    _Service_DeactivateUser_Handler,
+		},
+		{
+			MethodName: "ListUsers",
+			Handler:    _Service_ListUsers_Handler,
+		},
+		{
+			MethodName: "Logout",
+			Handler:    _Service_Logout_Handler,
+		},
+		{
+			MethodName: "RequestPasswordReset",
+			Handler:    _Service_RequestPasswordReset_Handler,
+		},
+		{
+			MethodName: "ConfirmPasswordReset",
+			Handler:    _Service_ConfirmPasswordReset_Handler,
+		},
+		{
+			MethodName: "ListAuthEvents",
+			Handler:    _Service_ListAuthEvents_Handler,
+		},
+		{
+			MethodName: "CreateServiceAccount",
+			Handler:    _Service_CreateServiceAccount_Handler,
+		},
+		{
+			MethodName: "CreateAPIKey",
+			Handler:    _Service_CreateAPIKey_Handler,
+		},
+		{
+			MethodName: "ListAPIKeys",
+			Handler:    _Service_ListAPIKeys_Handler,
+		},
+		{
+			MethodName: "RevokeAPIKey",
+			Handler:    _Service_RevokeAPIKey_Handler,
+		},
 		{
 			MethodName: "GetTaskListAll",
 			Handler:    _Service_GetTaskListAll_Handler,
@@ -772,7 +3343,237 @@ var Service_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ModPatch",
 			Handler:    _Service_ModPatch_Handler,
 		},
+		{
+			MethodName: "GetTaskHistory",
+			Handler:    _Service_GetTaskHistory_Handler,
+		},
+		{
+			MethodName: "GetPatchHistory",
+			Handler:    _Service_GetPatchHistory_Handler,
+		},
+		{
+			MethodName: "SearchAuditLog",
+			Handler:    _Service_SearchAuditLog_Handler,
+		},
+		{
+			MethodName: "ListTrash",
+			Handler:    _Service_ListTrash_Handler,
+		},
+		{
+			MethodName: "RestoreTask",
+			Handler:    _Service_RestoreTask_Handler,
+		},
+		{
+			MethodName: "RestorePatch",
+			Handler:    _Service_RestorePatch_Handler,
+		},
+		{
+			MethodName: "AddComment",
+			Handler:    _Service_AddComment_Handler,
+		},
+		{
+			MethodName: "EditComment",
+			Handler:    _Service_EditComment_Handler,
+		},
+		{
+			MethodName: "DeleteComment",
+			Handler:    _Service_DeleteComment_Handler,
+		},
+		{
+			MethodName: "ListComments",
+			Handler:    _Service_ListComments_Handler,
+		},
+		{
+			MethodName: "LogWork",
+			Handler:    _Service_LogWork_Handler,
+		},
+		{
+			MethodName: "ListWorkLogs",
+			Handler:    _Service_ListWorkLogs_Handler,
+		},
+		{
+			MethodName: "GetWorkReport",
+			Handler:    _Service_GetWorkReport_Handler,
+		},
+		{
+			MethodName: "AddTaskDependency",
+			Handler:    _Service_AddTaskDependency_Handler,
+		},
+		{
+			MethodName: "RemoveTaskDependency",
+			Handler:    _Service_RemoveTaskDependency_Handler,
+		},
+		{
+			MethodName: "ListTaskDependencies",
+			Handler:    _Service_ListTaskDependencies_Handler,
+		},
+		{
+			MethodName: "MoveTask",
+			Handler:    _Service_MoveTask_Handler,
+		},
+		{
+			MethodName: "CreateTaskType",
+			Handler:    _Service_CreateTaskType_Handler,
+		},
+		{
+			MethodName: "UpdateTaskType",
+			Handler:    _Service_UpdateTaskType_Handler,
+		},
+		{
+			MethodName: "DeleteTaskType",
+			Handler:    _Service_DeleteTaskType_Handler,
+		},
+		{
+			MethodName: "ListTaskTypes",
+			Handler:    _Service_ListTaskTypes_Handler,
+		},
+		{
+			MethodName: "CreateLabel",
+			Handler:    _Service_CreateLabel_Handler,
+		},
+		{
+			MethodName: "UpdateLabel",
+			Handler:    _Service_UpdateLabel_Handler,
+		},
+		{
+			MethodName: "DeleteLabel",
+			Handler:    _Service_DeleteLabel_Handler,
+		},
+		{
+			MethodName: "ListLabels",
+			Handler:    _Service_ListLabels_Handler,
+		},
+		{
+			MethodName: "AddLabels",
+			Handler:    _Service_AddLabels_Handler,
+		},
+		{
+			MethodName: "RemoveLabels",
+			Handler:    _Service_RemoveLabels_Handler,
+		},
+		{
+			MethodName: "ImportXLSX",
+			Handler:    _Service_ImportXLSX_Handler,
+		},
+		{
+			MethodName: "ImportExternalIssues",
+			Handler:    _Service_ImportExternalIssues_Handler,
+		},
+		{
+			MethodName: "ListImportBatches",
+			Handler:    _Service_ListImportBatches_Handler,
+		},
+		{
+			MethodName: "GetImportBatch",
+			Handler:    _Service_GetImportBatch_Handler,
+		},
+		{
+			MethodName: "RollbackImportBatch",
+			Handler:    _Service_RollbackImportBatch_Handler,
+		},
+		{
+			MethodName: "ResetCalendarToken",
+			Handler:    _Service_ResetCalendarToken_Handler,
+		},
+		{
+			MethodName: "RevokeCalendarToken",
+			Handler:    _Service_RevokeCalendarToken_Handler,
+		},
+		{
+			MethodName: "LinkCommits",
+			Handler:    _Service_LinkCommits_Handler,
+		},
+		{
+			MethodName: "ListTaskCommits",
+			Handler:    _Service_ListTaskCommits_Handler,
+		},
+		{
+			MethodName: "SetWorkSchedule",
+			Handler:    _Service_SetWorkSchedule_Handler,
+		},
+		{
+			MethodName: "AddLeave",
+			Handler:    _Service_AddLeave_Handler,
+		},
+		{
+			MethodName: "DeleteLeave",
+			Handler:    _Service_DeleteLeave_Handler,
+		},
+		{
+			MethodName: "ListLeaves",
+			Handler:    _Service_ListLeaves_Handler,
+		},
+		{
+			MethodName: "GetCapacity",
+			Handler:    _Service_GetCapacity_Handler,
+		},
+		{
+			MethodName: "SetBusinessDays",
+			Handler:    _Service_SetBusinessDays_Handler,
+		},
+		{
+			MethodName: "DeleteBusinessDays",
+			Handler:    _Service_DeleteBusinessDays_Handler,
+		},
+		{
+			MethodName: "ListBusinessDays",
+			Handler:    _Service_ListBusinessDays_Handler,
+		},
+		{
+			MethodName: "ImportBusinessCalendar",
+			Handler:    _Service_ImportBusinessCalendar_Handler,
+		},
+		{
+			MethodName: "CreateEscalationRule",
+			Handler:    _Service_CreateEscalationRule_Handler,
+		},
+		{
+			MethodName: "UpdateEscalationRule",
+			Handler:    _Service_UpdateEscalationRule_Handler,
+		},
+		{
+			MethodName: "DeleteEscalationRule",
+			Handler:    _Service_DeleteEscalationRule_Handler,
+		},
+		{
+			MethodName: "ListEscalationRules",
+			Handler:    _Service_ListEscalationRules_Handler,
+		},
+		{
+			MethodName: "RunEscalation",
+			Handler:    _Service_RunEscalation_Handler,
+		},
+		{
+			MethodName: "ListEscalations",
+			Handler:    _Service_ListEscalations_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportTasks",
+			Handler:       _Service_ExportTasks_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ExportPatchs",
+			Handler:       _Service_ExportPatchs_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ExportWorkReport",
+			Handler:       _Service_ExportWorkReport_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Backup",
+			Handler:       _Service_Backup_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Restore",
+			Handler:       _Service_Restore_Handler,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "server.proto",
 }
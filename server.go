@@ -8,7 +8,10 @@ import (
 	"fmt"
 	"github.com/go-redis/redis/v8"
 	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"log"
 	"regexp"
 	"strings"
@@ -35,6 +38,7 @@ type modDeadlineInfo struct {
 	patchNo     string
 	newDeadline string
 	user        string
+	batchID     string // 导入补丁时被提前的修改单也记入导入批次，回滚时一并恢复
 }
 
 //
@@ -111,30 +115,64 @@ type server struct {
 
 var Server = &server{}
 
+// 用户不存在、已停用与密码错误统一返回 errLoginFailed，避免泄露用户名是否存在
+var errLoginFailed = status.Error(codes.Unauthenticated, "invalid name or password")
+
 func (s *server) Login(ctx context.Context, in *pb.LoginRequest) (*pb.LoginReply, error) {
-	var user UserInfo
-	res := db.Where("name = ?", in.Name).First(&user)
-	if res.Error != nil {
-		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
-			return nil, errors.New("this user does not exist")
-		}
+	now := time.Now()
+	ip := peerIP(ctx)
+	if wait := loginGuard.lockedFor(in.Name, ip, now); wait > 0 {
+		recordAuthEvent(ctx, db, AUTH_EVENT_LOGIN_LOCKED, in.Name, "")
+		return nil, status.Errorf(codes.ResourceExhausted, "too many failed login attempts, retry after %d seconds", int(wait.Seconds())+1)
+	}
+
+	var existing *UserInfo
+	var found UserInfo
+	res := db.Where("name = ?", in.Name).First(&found)
+	if res.Error == nil {
+		existing = &found
+	} else if !errors.Is(res.Error, gorm.ErrRecordNotFound) {
 		return nil, res.Error
 	}
 
-	//算法标识符 + 成本因子 + 盐值
-	err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(in.Password))
+	user, err := providerFor(existing).authenticate(ctx, in.Name, in.Password, existing)
+	var failure *authFailure
+	if errors.As(err, &failure) {
+		recordAuthEvent(ctx, db, AUTH_EVENT_LOGIN_FAILURE, in.Name, failure.reason)
+		if loginGuard.fail(in.Name, ip, now) {
+			recordAuthEvent(ctx, db, AUTH_EVENT_LOCKOUT, in.Name, "ip "+ip)
+		}
+		return nil, errLoginFailed
+	} else if err != nil {
+		return nil, err
+	}
+
+	loginGuard.succeed(in.Name)
+	token, err := createSession(user)
 	if err != nil {
-		return nil, errors.New("wrong password")
-	} else {
-		return &pb.LoginReply{}, nil
+		return nil, err
+	}
+	recordAuthEvent(ctx, db, AUTH_EVENT_LOGIN, user.Name, "")
+	return &pb.LoginReply{Token: token}, nil
+}
+
+func (s *server) Logout(ctx context.Context, in *pb.LogoutRequest) (*pb.LogoutReply, error) {
+	token := tokenFromContext(ctx)
+	if token == "" {
+		return &pb.LogoutReply{}, nil
+	}
+	if err := db.Where("token_hash = ?", hashToken(token)).Delete(&SessionInfo{}).Error; err != nil {
+		return nil, err
+	}
+	if user := currentUser(ctx); user != nil {
+		recordAuthEvent(ctx, db, AUTH_EVENT_LOGOUT, user.Name, "")
 	}
+	return &pb.LogoutReply{}, nil
 }
 
 func (s *server) Register(ctx context.Context, in *pb.RegisterRequest) (*pb.RegisterReply, error) {
-	var existingUser UserInfo
-	res := db.Where("name = ?", in.User.Name).First(&existingUser)
-	if res.RowsAffected > 0 {
-		return &pb.RegisterReply{}, errors.New("user already exists")
+	if in.User == nil || in.User.Name == "" || in.User.Password == "" {
+		return nil, errors.New("name and password are required")
 	}
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(in.User.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -142,11 +180,22 @@ func (s *server) Register(ctx context.Context, in *pb.RegisterRequest) (*pb.Regi
 	}
 	userinfo := UserInfo{
 		Name:     in.User.Name,
-		JobNo:    int(in.User.JobNum),
+		JobNo:    optionalJobNo(in.User.JobNum),
 		Password: string(hashedPassword),
-		Email:    in.User.Email,
+		Email:    optionalEmail(in.User.Email),
+		Active:   true,
+		Source:   AUTH_SOURCE_LOCAL,
 	}
-	if err := db.Create(&userinfo).Error; err != nil {
+	// 唯一性检查与插入放在同一事务中，并发注册时由唯一索引兜底
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := checkUserUnique(tx, 0, userinfo.Name, userinfo.JobNo, userinfo.Email); err != nil {
+			return err
+		}
+		return tx.Create(&userinfo).Error
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil, errors.New("user already exists")
+	} else if err != nil {
 		return nil, err
 	}
 	return &pb.RegisterReply{}, nil
@@ -154,75 +203,350 @@ func (s *server) Register(ctx context.Context, in *pb.RegisterRequest) (*pb.Regi
 
 func (s *server) GetTaskListAll(ctx context.Context, in *pb.GetTaskListAllRequest) (*pb.GetTaskListAllReply, error) {
 	var tasks []TaskInfo
-	res := db.Find(&tasks)
+	res := whereLabels(db, AUDIT_ENTITY_TASK, "task_id", in.Labels).Find(&tasks)
 	if res.Error != nil {
 		return nil, res.Error
 	}
 	reply := &pb.GetTaskListAllReply{}
 	reply.Tasks = common.AllTaskInfoToPbTask(tasks)
+	if in.Tree {
+		reply.Roots = buildTaskTree(tasks)
+	}
+	if err := attachTaskLabels(append(reply.Tasks, treeTasks(reply.Roots)...)); err != nil {
+		return nil, err
+	}
 	return reply, nil
 }
 func (s *server) GetTaskListOne(ctx context.Context, in *pb.GetTaskListOneRequest) (*pb.GetTaskListOneReply, error) {
 	var tasks []TaskInfo
-	res := db.Where("principal = ?", in.Name).Find(&tasks)
+	res := whereLabels(db, AUDIT_ENTITY_TASK, "task_id", in.Labels).Where("principal = ?", in.Name).Find(&tasks)
 	if res.Error != nil {
 		return nil, res.Error
 	}
 	reply := &pb.GetTaskListOneReply{}
 	reply.Tasks = common.AllTaskInfoToPbTask(tasks)
+	if in.Tree {
+		// 子任务可能属于其他负责人，汇总时需要完整的子树
+		all := tasks
+		seen := make(map[string]bool, len(tasks))
+		for _, t := range tasks {
+			seen[t.TaskID] = true
+		}
+		for _, t := range tasks {
+			ids, err := subtreeIDs(db, t.TaskID)
+			if err != nil {
+				return nil, err
+			}
+			var missing []string
+			for _, id := range ids {
+				if !seen[id] {
+					seen[id] = true
+					missing = append(missing, id)
+				}
+			}
+			if len(missing) > 0 {
+				var more []TaskInfo
+				if err := db.Where("task_id IN ?", missing).Find(&more).Error; err != nil {
+					return nil, err
+				}
+				all = append(all, more...)
+			}
+		}
+		reply.Roots = buildTaskTree(all)
+	}
+	if err := attachTaskLabels(append(reply.Tasks, treeTasks(reply.Roots)...)); err != nil {
+		return nil, err
+	}
 	return reply, nil
 }
 
+// 按主键查找，不存在时返回 nil, nil
+func findTask(tx *gorm.DB, taskID string) (*TaskInfo, error) {
+	var task TaskInfo
+	if err := tx.Where("task_id = ?", taskID).First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &task, nil
+}
+
+func findPatch(tx *gorm.DB, patchNo string) (*PatchsInfo, error) {
+	var patch PatchsInfo
+	if err := tx.Where("patch_no = ?", patchNo).First(&patch).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &patch, nil
+}
+
+// 版本号不一致时返回 ABORTED，并在 details 中带上服务端当前的数据，便于客户端合并
+func staleTaskError(current *TaskInfo) error {
+	st := status.New(codes.Aborted, "task has been modified by someone else")
+	if withDetails, err := st.WithDetails(common.OneTaskInfoToPbTask(*current)); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+func stalePatchError(current *PatchsInfo) error {
+	st := status.New(codes.Aborted, "patch has been modified by someone else")
+	if withDetails, err := st.WithDetails(common.OnePatchsInfoToPbPatchs(*current)); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
 // TODO: 考虑导入任务时，是否查询联系到的补丁的时间，并修改
 func (s *server) ImportXLSToTaskTable(ctx context.Context, in *pb.ImportToTaskListRequest) (*pb.ImportToTaskListReply, error) {
-	taskInfos := common.AllPbTaskToTaskInfo(in.Tasks)
-	tx := db.Begin()
-	if err := tx.Save(taskInfos).Error; err != nil {
-		tx.Rollback()
+	reply := &pb.ImportToTaskListReply{}
+	var written []TaskInfo
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var batch *ImportBatchInfo
+		if !in.DryRun {
+			var err error
+			if batch, err = beginImportBatch(ctx, tx, in.User, AUDIT_ENTITY_TASK, in.IdempotencyKey); err != nil {
+				return err
+			}
+		}
+		rows, toWrite, err := planTaskImport(tx, in.Tasks)
+		if err != nil {
+			return err
+		}
+		c := countImportRows(rows)
+		reply.Rows = rows
+		reply.InsertCnt, reply.UpdateCnt, reply.UnchangedCnt, reply.ConflictCnt = c.created, c.updated, c.unchanged, c.conflict
+		if in.DryRun {
+			return errImportRollback
+		}
+		if err := c.check(AUDIT_ENTITY_TASK, rows); err != nil {
+			return err
+		}
+		if err := importTasks(ctx, tx, in.User, toWrite, batch.ID); err != nil {
+			return err
+		}
+		reply.BatchId, written = batch.ID, toWrite
+		return finishImportBatch(tx, batch, c)
+	})
+	if errors.Is(err, errImportReplayed) {
+		batch, err := findReplayedBatch(ctx, in.User, in.IdempotencyKey)
+		if err != nil {
+			return nil, err
+		}
+		return &pb.ImportToTaskListReply{
+			InsertCnt:    int32(batch.Created),
+			UpdateCnt:    int32(batch.Updated),
+			UnchangedCnt: int32(batch.Unchanged),
+			BatchId:      batch.ID,
+			Replayed:     true,
+		}, nil
+	}
+	if err != nil && !errors.Is(err, errImportRollback) {
 		return nil, err
 	}
-	tx.Commit()
-	for _, taskInfo := range taskInfos {
-		msg := fmt.Sprintf("<%s> -> import tasks counts: %d -> <%s>", in.User, len(in.Tasks), taskInfo.Principal)
-		NotificationServer.updateDatabaseAndNotify(msg)
+	notified := make(map[string]bool)
+	for _, taskInfo := range written {
+		if !notified[taskInfo.Principal] {
+			notified[taskInfo.Principal] = true
+			msg := fmt.Sprintf("<%s> -> import tasks counts: %d -> <%s>", in.User, len(written), taskInfo.Principal)
+			NotificationServer.updateDatabaseAndNotify(msg)
+		}
 	}
+	return reply, nil
+}
 
-	return &pb.ImportToTaskListReply{}, nil
+// 导入修改单，已存在的整条覆盖，在调用方的事务中执行；每条记录登记到导入批次。
+// 任务类型的默认值已在校验时补好，这里按原样写入
+func importTasks(ctx context.Context, tx *gorm.DB, user string, taskInfos []TaskInfo, batchID string) error {
+	for i := range taskInfos {
+		before, err := findTask(tx, taskInfos[i].TaskID)
+		if err != nil {
+			return err
+		}
+		taskInfos[i].Version = 1
+		if before != nil {
+			taskInfos[i].Version = before.Version + 1
+			if taskInfos[i].ParentID == "" {
+				taskInfos[i].ParentID = before.ParentID
+			}
+		}
+		if err := checkParent(tx, &taskInfos[i]); err != nil {
+			return fmt.Errorf("task %s: %v", taskInfos[i].TaskID, err)
+		}
+		if err := tx.Save(&taskInfos[i]).Error; err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, tx, user, AUDIT_ENTITY_TASK, before, &taskInfos[i]); err != nil {
+			return err
+		}
+		if err := recordImportRow(tx, batchID, AUDIT_ENTITY_TASK, taskInfos[i].TaskID, before, taskInfos[i].Version); err != nil {
+			return err
+		}
+	}
+	ids := make([]string, len(taskInfos))
+	for i := range taskInfos {
+		ids[i] = taskInfos[i].TaskID
+	}
+	dependents, err := dependentsOf(tx, ids)
+	if err != nil {
+		return err
+	}
+	if err := refreshBlocked(ctx, tx, user, append(ids, dependents...)); err != nil {
+		return err
+	}
+	// 更新阻塞状态会增加版本号，批次中记录的版本以最终写入的为准，否则无法回滚
+	for _, id := range ids {
+		after, err := findTask(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := recordImportRow(tx, batchID, AUDIT_ENTITY_TASK, id, nil, after.Version); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 func (s *server) DelTask(ctx context.Context, in *pb.DelTaskRequest) (*pb.DelTaskReply, error) {
-	task := TaskInfo{}
-	if err := db.Where("task_id = ?", in.TaskNo).Delete(&task).Error; err != nil {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		task, err := findTask(tx, in.TaskNo)
+		if err != nil || task == nil {
+			return err
+		}
+		if has, err := hasSubtasks(tx, task.TaskID); err != nil {
+			return err
+		} else if has {
+			return errors.New("task has subtasks, move or delete them first")
+		}
+		return softDeleteTask(ctx, tx, in.User, task, in.Reason, "")
+	})
+	if err != nil {
 		return nil, err
-	} else {
-		msg := fmt.Sprintf("<%s> -> delete task: %s -> <%s>", in.User, in.TaskNo, in.Principal)
-		NotificationServer.updateDatabaseAndNotify(msg)
-		return &pb.DelTaskReply{}, nil
 	}
+	msg := fmt.Sprintf("<%s> -> delete task: %s -> <%s>", in.User, in.TaskNo, in.Principal)
+	NotificationServer.updateDatabaseAndNotify(msg)
+	return &pb.DelTaskReply{}, nil
 }
 func (s *server) ModTask(ctx context.Context, in *pb.ModTaskRequest) (*pb.ModTaskReply, error) {
-	if err := db.Save(common.OnePbTaskToTaskInfo(in.T)).Error; err != nil {
-		return nil, err
-	} else {
-		msg := fmt.Sprintf("<%s> -> modifiy task: %s -> <%s>", in.User, in.T.TaskId, in.T.Principal)
-		NotificationServer.updateDatabaseAndNotify(msg)
-		return &pb.ModTaskReply{}, nil
+	task := common.OnePbTaskToTaskInfo(in.T)
+	var updates map[string]interface{}
+	if paths := in.UpdateMask.GetPaths(); len(paths) > 0 {
+		var err error
+		if updates, err = maskUpdates(taskMaskFields, in.T, paths); err != nil {
+			return nil, err
+		}
+	}
+	var after *TaskInfo
+	var conflicts []deadlineConflict
+	err := db.Transaction(func(tx *gorm.DB) error {
+		before, err := findTask(tx.Clauses(clause.Locking{Strength: "UPDATE"}), task.TaskID)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return errors.New("task does not exist")
+		}
+		if before.Version != in.T.Version {
+			return staleTaskError(before)
+		}
+		// 子树内的需求号必须一致，修改需求号请使用 MoveTask
+		reqNo := task.ReqNo
+		if updates != nil {
+			reqNo = before.ReqNo
+			if v, ok := updates["req_no"]; ok {
+				reqNo = v.(string)
+			}
+		}
+		if reqNo != before.ReqNo {
+			if has, err := hasSubtasks(tx, task.TaskID); err != nil {
+				return err
+			} else if has || before.ParentID != "" {
+				return errors.New("reqNo of a task in a hierarchy can only be changed with MoveTask")
+			}
+		}
+		query := tx.Model(&TaskInfo{}).Where("task_id = ? AND version = ?", task.TaskID, before.Version)
+		var res *gorm.DB
+		if updates != nil {
+			updates["version"] = before.Version + 1
+			res = query.Updates(updates)
+		} else {
+			task.Version = before.Version + 1
+			res = query.Select("*").Omit("task_id", "parent_id", "state_before_blocked").Updates(task)
+		}
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return staleTaskError(before)
+		}
+		if after, err = findTask(tx, task.TaskID); err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, tx, in.User, AUDIT_ENTITY_TASK, before, after); err != nil {
+			return err
+		}
 
+		// 状态变化后重新计算自身及依赖方的阻塞状态
+		dependents, err := dependentsOf(tx, []string{task.TaskID})
+		if err != nil {
+			return err
+		}
+		if err := refreshBlocked(ctx, tx, in.User, append([]string{task.TaskID}, dependents...)); err != nil {
+			return err
+		}
+		if after, err = findTask(tx, task.TaskID); err != nil {
+			return err
+		}
+		if !after.Deadline.Equal(before.Deadline) {
+			conflicts, err = deadlineConflicts(tx, []string{task.TaskID})
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
+	msg := fmt.Sprintf("<%s> -> modifiy task: %s -> <%s>", in.User, in.T.TaskId, in.T.Principal)
+	NotificationServer.updateDatabaseAndNotify(msg)
+	notifyDeadlineConflicts(in.User, conflicts)
+	return &pb.ModTaskReply{T: common.OneTaskInfoToPbTask(*after), Warnings: conflictsToWarnings(conflicts)}, nil
 }
 func (s *server) AddTask(ctx context.Context, in *pb.AddTaskRequest) (*pb.AddTaskReply, error) {
-	var task TaskInfo
-	if err := db.Where("task_id = ?", in.T.TaskId).First(&task).Error; err == nil { //有重复task_id
-		return nil, errors.New("task already exists")
-	} else if !errors.Is(err, gorm.ErrRecordNotFound) { //查找出错，且不是没有找到的错误
-		return nil, err
-	}
-	if err := db.Create(common.OnePbTaskToTaskInfo(in.T)).Error; err != nil {
+	task := common.OnePbTaskToTaskInfo(in.T)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if existing, err := findTask(tx.Unscoped(), task.TaskID); err != nil { //查找出错，且不是没有找到的错误
+			return err
+		} else if existing != nil && existing.DeletedAt.Valid {
+			return errors.New("task is in the trash, restore it instead")
+		} else if existing != nil { //有重复task_id
+			return errors.New("task already exists")
+		}
+		if err := checkParent(tx, task); err != nil {
+			return err
+		}
+		types, err := loadTaskTypes(tx)
+		if err != nil {
+			return err
+		}
+		if err := applyTaskType(types, task, in.T.EmergencyLevel != nil); err != nil {
+			return err
+		}
+		if task.Deadline.IsZero() {
+			task.Deadline = defaultTaskDeadline()
+		}
+		task.Version = 1
+		if err := tx.Create(task).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, in.User, AUDIT_ENTITY_TASK, nil, task)
+	})
+	if err != nil {
 		return nil, err
-	} else {
-		msg := fmt.Sprintf("<%s> -> add task: %s -> <%s>", in.User, in.T.TaskId, in.T.Principal)
-		NotificationServer.updateDatabaseAndNotify(msg)
-		return &pb.AddTaskReply{}, nil
 	}
+	msg := fmt.Sprintf("<%s> -> add task: %s -> <%s>", in.User, in.T.TaskId, in.T.Principal)
+	NotificationServer.updateDatabaseAndNotify(msg)
+	return &pb.AddTaskReply{}, nil
 }
 func (s *server) QueryTaskWithSQL(ctx context.Context, in *pb.QueryTaskWithSQLRequest) (*pb.QueryTaskWithSQLReply, error) {
 	var tasks []TaskInfo
@@ -233,121 +557,223 @@ func (s *server) QueryTaskWithSQL(ctx context.Context, in *pb.QueryTaskWithSQLRe
 }
 func (s *server) QueryTaskWithField(ctx context.Context, in *pb.QueryTaskWithFieldRequest) (*pb.QueryTaskWithFieldReply, error) {
 	var tasks []TaskInfo
-	whereCond := fmt.Sprintf(" %s = ?", in.Field)
-	if err := db.Where(whereCond, in.FieldValue).Find(&tasks).Error; err != nil {
+	query := whereLabels(db, AUDIT_ENTITY_TASK, "task_id", in.Labels)
+	if in.Field != "" || len(in.Labels) == 0 {
+		whereCond := fmt.Sprintf(" %s = ?", in.Field)
+		query = query.Where(whereCond, in.FieldValue)
+	}
+	if err := query.Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	reply := &pb.QueryTaskWithFieldReply{Tasks: common.AllTaskInfoToPbTask(tasks)}
+	if err := attachTaskLabels(reply.Tasks); err != nil {
 		return nil, err
 	}
-	return &pb.QueryTaskWithFieldReply{Tasks: common.AllTaskInfoToPbTask(tasks)}, nil
+	return reply, nil
 }
 
 func (s *server) ImportXLSToPatchTable(ctx context.Context, in *pb.ImportXLSToPatchRequest) (*pb.ImportXLSToPatchReply, error) {
-	tx := db.Begin()
-	if tx.Error != nil {
-		return nil, tx.Error
-	}
-
-	for _, patch := range in.Patchs {
-		go func() {
-			newDeadline := patch.Deadline
-			err := s.ModDeadLineInPatchsAndTasks(context.Background(), &modDeadlineInfo{patchNo: patch.PatchNo, newDeadline: newDeadline, user: in.User}, false)
-			if err != nil {
-				log.Println(err)
+	reply := &pb.ImportXLSToPatchReply{}
+	var conflicts []deadlineConflict
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var batch *ImportBatchInfo
+		if !in.DryRun {
+			var err error
+			if batch, err = beginImportBatch(ctx, tx, in.User, AUDIT_ENTITY_PATCH, in.IdempotencyKey); err != nil {
+				return err
 			}
-		}()
-	}
-
-	if err := tx.Save(common.AllPbPatchsToPatchsInfo(in.Patchs)).Error; err != nil {
-		tx.Rollback()
+		}
+		rows, toWrite, err := planPatchImport(tx, in.Patchs)
+		if err != nil {
+			return err
+		}
+		c := countImportRows(rows)
+		reply.Rows = rows
+		reply.InsertCnt, reply.UpdateCnt, reply.UnchangedCnt, reply.ConflictCnt = c.created, c.updated, c.unchanged, c.conflict
+		if in.DryRun {
+			return errImportRollback
+		}
+		if err := c.check(AUDIT_ENTITY_PATCH, rows); err != nil {
+			return err
+		}
+		if conflicts, err = s.importPatches(ctx, tx, in.User, toWrite, batch.ID); err != nil {
+			return err
+		}
+		reply.BatchId = batch.ID
+		return finishImportBatch(tx, batch, c)
+	})
+	if errors.Is(err, errImportReplayed) {
+		batch, err := findReplayedBatch(ctx, in.User, in.IdempotencyKey)
+		if err != nil {
+			return nil, err
+		}
+		return &pb.ImportXLSToPatchReply{
+			InsertCnt:    int32(batch.Created),
+			UpdateCnt:    int32(batch.Updated),
+			UnchangedCnt: int32(batch.Unchanged),
+			BatchId:      batch.ID,
+			Replayed:     true,
+		}, nil
+	}
+	if err != nil && !errors.Is(err, errImportRollback) {
 		return nil, err
 	}
-	if err := tx.Commit().Error; err != nil {
-		return nil, err
+	if in.DryRun {
+		return reply, nil
 	}
 
-	msg := fmt.Sprintf("<%s> -> import patchs counts: %d -> <ALL>", in.User, len(in.Patchs))
+	msg := fmt.Sprintf("<%s> -> import patchs counts: %d -> <ALL>", in.User, reply.InsertCnt+reply.UpdateCnt)
 	NotificationServer.updateDatabaseAndNotify(msg)
-	return &pb.ImportXLSToPatchReply{}, nil
+	notifyDeadlineConflicts(in.User, conflicts)
+	return reply, nil
 }
 
-// 修改补丁时间，其下的修改单日期也一同修改
-
-func (s *server) ModDeadLineInPatchsAndTasks(ctx context.Context, in *modDeadlineInfo, modPatchs bool) error {
-	tx := db.Begin()
-	if tx.Error != nil {
-		return tx.Error
+// 导入补丁，已存在的整条覆盖，其下修改单的截止日期随补丁提前，在调用方的事务中执行；每条补丁登记到导入批次
+func (s *server) importPatches(ctx context.Context, tx *gorm.DB, user string, patchInfos []PatchsInfo, batchID string) ([]deadlineConflict, error) {
+	var conflicts []deadlineConflict
+	for i := range patchInfos {
+		if patchInfos[i].Deadline.IsZero() {
+			return nil, fmt.Errorf("patch %s: invalid deadline", patchInfos[i].PatchNo)
+		}
+		before, err := findPatch(tx, patchInfos[i].PatchNo)
+		if err != nil {
+			return nil, err
+		}
+		patchInfos[i].Version = 1
+		if before != nil {
+			patchInfos[i].Version = before.Version + 1
+		}
+		if err := tx.Save(&patchInfos[i]).Error; err != nil {
+			return nil, err
+		}
+		if err := recordAudit(ctx, tx, user, AUDIT_ENTITY_PATCH, before, &patchInfos[i]); err != nil {
+			return nil, err
+		}
+		if err := recordImportRow(tx, batchID, AUDIT_ENTITY_PATCH, patchInfos[i].PatchNo, before, patchInfos[i].Version); err != nil {
+			return nil, err
+		}
+		info := &modDeadlineInfo{patchNo: patchInfos[i].PatchNo, newDeadline: patchInfos[i].Deadline.Format("2006-01-02"), user: user, batchID: batchID}
+		found, err := s.ModDeadLineInPatchsAndTasks(ctx, tx, info, false)
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, found...)
 	}
+	return conflicts, nil
+}
 
+// 修改补丁时间，其下的修改单日期也一同修改（只会提前，不会推后），在调用方的事务中执行
+// 补丁的版本号由调用方维护，修改单的版本号在这里递增
+// 返回被提前的修改单与其前置修改单之间的截止日期冲突
+
+func (s *server) ModDeadLineInPatchsAndTasks(ctx context.Context, tx *gorm.DB, in *modDeadlineInfo, modPatchs bool) ([]deadlineConflict, error) {
 	newDeadline, patchNo := in.newDeadline, in.patchNo
+	deadline, err := time.Parse("2006-01-02", newDeadline)
+	if err != nil {
+		return nil, fmt.Errorf("invalid deadline %q: %v", newDeadline, err)
+	}
+
+	patch, err := findPatch(tx, patchNo)
+	if err != nil {
+		return nil, err
+	}
+	if patch == nil {
+		return nil, errors.New("patch does not exist")
+	}
 
 	// 更新 patch_table 表中的 deadline
 	if modPatchs {
+		before := *patch
 		if err := tx.Model(&PatchsInfo{}).
 			Where("patch_no = ?", patchNo).
 			Update("deadline", newDeadline).Error; err != nil {
-			tx.Rollback()
-			return err
+			return nil, err
+		}
+		patch.Deadline = deadline
+		if err := recordAudit(ctx, tx, in.user, AUDIT_ENTITY_PATCH, &before, patch); err != nil {
+			return nil, err
 		}
 	}
 
 	// 更新 tasklist_table 表中的 deadline
-	var reqNo string
-	db.Model(&PatchsInfo{}).Select("req_no").Where("patch_no = ?", patchNo).Scan(&reqNo)
-	reqNos := strings.Split(reqNo, ",")
-	if err := db.Model(&TaskInfo{}).
-		Where("req_no IN ?", reqNos).
-		Update("deadline", gorm.Expr("LEAST(deadline, ?)", newDeadline)).Error; err != nil {
-		tx.Rollback()
-		return err
+	reqNos := strings.Split(patch.ReqNo, ",")
+	var tasks []TaskInfo
+	if err := tx.Where("req_no IN ? AND deadline > ?", reqNos, newDeadline).Find(&tasks).Error; err != nil {
+		return nil, err
 	}
-
-	if err := tx.Commit().Error; err != nil {
-		return err
+	moved := make([]string, len(tasks))
+	for i := range tasks {
+		before := tasks[i]
+		if err := tx.Model(&TaskInfo{}).
+			Where("task_id = ?", tasks[i].TaskID).
+			Updates(map[string]interface{}{"deadline": newDeadline, "version": gorm.Expr("version + 1")}).Error; err != nil {
+			return nil, err
+		}
+		tasks[i].Deadline = deadline
+		tasks[i].Version = before.Version + 1
+		if err := recordAudit(ctx, tx, in.user, AUDIT_ENTITY_TASK, &before, &tasks[i]); err != nil {
+			return nil, err
+		}
+		if err := recordImportRow(tx, in.batchID, AUDIT_ENTITY_TASK, tasks[i].TaskID, &before, tasks[i].Version); err != nil {
+			return nil, err
+		}
+		moved[i] = tasks[i].TaskID
 	}
 
 	//TODO: 导入补丁或修改补丁（时间被修改）时调用该函数，这里已经不需要在发布消息了（外层已经发布了）
 	//msg := fmt.Sprintf("<%s> -> modifiy patchs's:%s deadline to %s -> <ALL>", in.user, in.patchNo, in.newDeadline)
 	//NotificationServer.updateDatabaseAndNotify(msg)
 
-	return nil
+	return deadlineConflicts(tx, moved)
 }
 
 func (s *server) DelPatch(ctx context.Context, in *pb.DelPatchRequest) (*pb.DelPatchReply, error) {
 	patchNo := in.PatchNo
 
-	tx := db.Begin()
-	if tx.Error != nil {
-		log.Fatalf("failed to begin transaction: %v", tx.Error)
-		return nil, tx.Error
-	}
-
-	// 删除 tasklist_table 中相关的记录
-	if err := tx.Exec("DELETE FROM tasklist_table WHERE req_no IN (SELECT req_no FROM patch_table WHERE patch_no = ?)", patchNo).Error; err != nil {
-		tx.Rollback()
-		return nil, err
-	}
+	var affected []TaskInfo
+	err := db.Transaction(func(tx *gorm.DB) error {
+		patch, err := findPatch(tx, patchNo)
+		if err != nil || patch == nil {
+			return err
+		}
 
-	// 删除 patch_table 中的记录
-	if err := tx.Where("patch_no = ?", patchNo).Delete(&PatchsInfo{}).Error; err != nil {
-		tx.Rollback()
+		// tasklist_table 中相关的记录一同移入回收站
+		if err := tx.Where("req_no = ?", patch.ReqNo).Find(&affected).Error; err != nil {
+			return err
+		}
+		if in.DryRun {
+			return nil
+		}
+		for i := range affected {
+			if err := softDeleteTask(ctx, tx, in.User, &affected[i], in.Reason, patchNo); err != nil {
+				return err
+			}
+		}
+		return softDeletePatch(ctx, tx, in.User, patch, in.Reason)
+	})
+	if err != nil {
 		return nil, err
 	}
-
-	if err := tx.Commit().Error; err != nil {
-		log.Fatalf("failed to commit transaction: %v", err)
-		return nil, err
+	reply := &pb.DelPatchReply{AffectedTasks: common.AllTaskInfoToPbTask(affected)}
+	if in.DryRun {
+		return reply, nil
 	}
 
 	msg := fmt.Sprintf("<%s> -> delete patchs:%s -> <ALL>", in.User, in.PatchNo)
 	NotificationServer.updateDatabaseAndNotify(msg)
-	return &pb.DelPatchReply{}, nil
+	return reply, nil
 }
 
 func (s *server) GetPatchsAll(ctx context.Context, in *pb.GetPatchsAllRequest) (*pb.GetPatchsAllReply, error) {
 	var patchs []PatchsInfo
-	if er := db.Find(&patchs).Error; er != nil {
+	if er := whereLabels(db, AUDIT_ENTITY_PATCH, "patch_no", in.Labels).Find(&patchs).Error; er != nil {
 		return nil, er
 	}
-	return &pb.GetPatchsAllReply{Patchs: common.AllPatchsInfoToPbPatchs(patchs)}, nil
+	reply := &pb.GetPatchsAllReply{Patchs: common.AllPatchsInfoToPbPatchs(patchs)}
+	if err := attachPatchLabels(reply.Patchs); err != nil {
+		return nil, err
+	}
+	return reply, nil
 }
 
 // TODO:确认表的patch_no是否唯一
@@ -356,29 +782,79 @@ func (s *server) GetOnePatchs(ctx context.Context, in *pb.GetOnePatchsRequest) (
 	if err := db.Where("patch_no = ?", in.PatchNo).Find(&patchs).Error; err != nil {
 		return nil, err
 	}
-	return &pb.GetOnePatchsReply{P: common.OnePatchsInfoToPbPatchs(patchs)}, nil
-}
-
-func (s *server) ModPatch(ctx context.Context, in *pb.ModPatchRequest) (*pb.ModPatchReply, error) {
-	patchs := &PatchsInfo{PatchNo: in.P.PatchNo, ReqNo: in.P.ReqNo}
-	if err := db.Model(&patchs).Updates(&PatchsInfo{
-		ClientName: in.P.ClientName,
-		Reason:     in.P.Reason,
-		Describe:   in.P.Describe,
-		Sponsor:    in.P.Sponsor,
-		State:      in.P.State}).Error; err != nil {
+	reply := &pb.GetOnePatchsReply{P: common.OnePatchsInfoToPbPatchs(patchs)}
+	if err := attachPatchLabels([]*pb.Patch{reply.P}); err != nil {
 		return nil, err
 	}
+	return reply, nil
+}
 
-	var deadline time.Time
-	db.Table("patch_table").Where("patch_no = ?", patchs.PatchNo).First(&deadline)
+func (s *server) ModPatch(ctx context.Context, in *pb.ModPatchRequest) (*pb.ModPatchReply, error) {
+	var updates map[string]interface{}
+	modDeadline := true
+	if paths := in.UpdateMask.GetPaths(); len(paths) > 0 {
+		var err error
+		if updates, err = maskUpdates(patchMaskFields, in.P, paths); err != nil {
+			return nil, err
+		}
+		_, modDeadline = updates["deadline"]
+		delete(updates, "deadline")
+	}
+	var after *PatchsInfo
+	var conflicts []deadlineConflict
+	err := db.Transaction(func(tx *gorm.DB) error {
+		before, err := findPatch(tx.Clauses(clause.Locking{Strength: "UPDATE"}), in.P.PatchNo)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return errors.New("patch does not exist")
+		}
+		if before.Version != in.P.Version {
+			return stalePatchError(before)
+		}
+		query := tx.Model(&PatchsInfo{}).Where("patch_no = ? AND version = ?", in.P.PatchNo, before.Version)
+		var res *gorm.DB
+		if updates != nil {
+			updates["version"] = before.Version + 1
+			res = query.Updates(updates)
+		} else {
+			res = query.Updates(&PatchsInfo{
+				ClientName: in.P.ClientName,
+				Reason:     in.P.Reason,
+				Describe:   in.P.Describe,
+				Sponsor:    in.P.Sponsor,
+				State:      in.P.State,
+				Version:    before.Version + 1})
+		}
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return stalePatchError(before)
+		}
+		if after, err = findPatch(tx, in.P.PatchNo); err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, tx, in.User, AUDIT_ENTITY_PATCH, before, after); err != nil {
+			return err
+		}
 
-	if deadline.Format("2006-01-02") != in.P.Deadline {
-		err := s.ModDeadLineInPatchsAndTasks(context.Background(), &modDeadlineInfo{patchNo: in.P.PatchNo, newDeadline: in.P.Deadline, user: in.User}, true)
+		if modDeadline && after.Deadline.Format("2006-01-02") != in.P.Deadline {
+			info := &modDeadlineInfo{patchNo: in.P.PatchNo, newDeadline: in.P.Deadline, user: in.User}
+			if conflicts, err = s.ModDeadLineInPatchsAndTasks(ctx, tx, info, true); err != nil {
+				return err
+			}
+			after, err = findPatch(tx, in.P.PatchNo)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
-	} else {
-		msg := fmt.Sprintf("<%s> -> modifiy patchs:%s -> <ALL>", in.User, in.P.PatchNo)
-		NotificationServer.updateDatabaseAndNotify(msg)
-		return &pb.ModPatchReply{}, nil
 	}
+	msg := fmt.Sprintf("<%s> -> modifiy patchs:%s -> <ALL>", in.User, in.P.PatchNo)
+	NotificationServer.updateDatabaseAndNotify(msg)
+	notifyDeadlineConflicts(in.User, conflicts)
+	return &pb.ModPatchReply{P: common.OnePatchsInfoToPbPatchs(*after), Warnings: conflictsToWarnings(conflicts)}, nil
 }
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"OrderManager/pb"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+const sessionTTL = 7 * 24 * time.Hour
+
+type ctxKey int
+
+const (
+	ctxKeyUser ctxKey = iota
+	ctxKeyScopes
+)
+
+var errUnauthenticated = status.Error(codes.Unauthenticated, "invalid or expired token")
+
+var errTokenRequired = status.Error(codes.Unauthenticated, "token is required")
+
+// 不需要登录即可调用的 RPC
+var publicMethods = map[string]bool{
+	pb.Service_Login_FullMethodName:                true,
+	pb.Service_Register_FullMethodName:             true,
+	pb.Service_RequestPasswordReset_FullMethodName: true,
+	pb.Service_ConfirmPasswordReset_FullMethodName: true,
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func createSession(user *UserInfo) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	session := SessionInfo{
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(sessionTTL),
+	}
+	if err := db.Create(&session).Error; err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// 使该用户的所有会话失效
+func revokeSessions(tx *gorm.DB, userID uint) error {
+	return tx.Where("user_id = ?", userID).Delete(&SessionInfo{}).Error
+}
+
+func tokenFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(values[0], "Bearer ")
+}
+
+// 从 metadata 中解析 token 并把当前用户放入 ctx，除 publicMethods 外都必须携带 token
+func authenticate(ctx context.Context, fullMethod string) (context.Context, error) {
+	token := tokenFromContext(ctx)
+	if token == "" {
+		if publicMethods[fullMethod] {
+			return ctx, nil
+		}
+		return nil, errTokenRequired
+	}
+	if strings.HasPrefix(token, apiKeyPrefix) {
+		return authenticateAPIKey(ctx, token)
+	}
+	var session SessionInfo
+	if err := db.Where("token_hash = ? AND expires_at > ?", hashToken(token), time.Now()).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errUnauthenticated
+		}
+		return nil, err
+	}
+	var user UserInfo
+	if err := db.First(&user, session.UserID).Error; err != nil || !user.Active {
+		return nil, errUnauthenticated
+	}
+	return context.WithValue(ctx, ctxKeyUser, &user), nil
+}
+
+func currentUser(ctx context.Context) *UserInfo {
+	user, _ := ctx.Value(ctxKeyUser).(*UserInfo)
+	return user
+}
+
+// 以会话或 API Key 对应的用户为准，忽略请求中的 user 字段；
+// ctx 中没有用户的只有定时任务、webhook 等内部调用，此时使用 claimed
+func operatorName(ctx context.Context, claimed string) string {
+	if user := currentUser(ctx); user != nil {
+		return user.Name
+	}
+	return claimed
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"OrderManager/common"
+	"OrderManager/pb"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 任务及其所有后代的单号，包含自身
+func subtreeIDs(tx *gorm.DB, rootID string) ([]string, error) {
+	ids := []string{rootID}
+	seen := map[string]bool{rootID: true}
+	level := []string{rootID}
+	for len(level) > 0 {
+		var children []string
+		if err := tx.Model(&TaskInfo{}).Where("parent_id IN ?", level).Pluck("task_id", &children).Error; err != nil {
+			return nil, err
+		}
+		level = level[:0]
+		for _, id := range children {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+				level = append(level, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// 校验父任务：必须存在、不能是自身或自身的后代，且与父任务属于同一需求（需求号为空时沿用父任务的）
+func checkParent(tx *gorm.DB, task *TaskInfo) error {
+	if task.ParentID == "" {
+		return nil
+	}
+	parent, err := findTask(tx, task.ParentID)
+	if err != nil {
+		return err
+	}
+	if parent == nil {
+		return errors.New("parent task does not exist")
+	}
+	subtree, err := subtreeIDs(tx, task.TaskID)
+	if err != nil {
+		return err
+	}
+	for _, id := range subtree {
+		if id == parent.TaskID {
+			return errors.New("task cannot be placed under itself or its subtask")
+		}
+	}
+	if task.ReqNo == "" {
+		task.ReqNo = parent.ReqNo
+	} else if task.ReqNo != parent.ReqNo {
+		return fmt.Errorf("subtask must belong to requirement %s of its parent", parent.ReqNo)
+	}
+	return nil
+}
+
+func hasSubtasks(tx *gorm.DB, taskID string) (bool, error) {
+	var n int64
+	err := tx.Model(&TaskInfo{}).Where("parent_id = ?", taskID).Count(&n).Error
+	return n > 0, err
+}
+
+// 汇总子任务的状态：全部相同时取该状态，有任务已开始时为进行中，否则有阻塞时为阻塞
+func rollUpState(states []string) string {
+	same, started, blocked := true, false, false
+	for _, st := range states {
+		same = same && st == states[0]
+		started = started || st == TASK_STATE_TEXT_ING || st == TASK_STATE_TEXT_FINISH
+		blocked = blocked || st == TASK_STATE_TEXT_BLOCKED
+	}
+	switch {
+	case same:
+		return states[0]
+	case started:
+		return TASK_STATE_TEXT_ING
+	case blocked:
+		return TASK_STATE_TEXT_BLOCKED
+	}
+	return TASK_STATE_TEXT_WAIT
+}
+
+// 构建任务树，父任务不在 tasks 中的任务作为根节点
+func buildTaskTree(tasks []TaskInfo) []*pb.TaskNode {
+	nodes := make(map[string]*pb.TaskNode, len(tasks))
+	for _, t := range tasks {
+		nodes[t.TaskID] = &pb.TaskNode{T: common.OneTaskInfoToPbTask(t)}
+	}
+	var roots []*pb.TaskNode
+	for _, t := range tasks {
+		parent, ok := nodes[t.ParentID]
+		if ok && t.ParentID != t.TaskID {
+			parent.Children = append(parent.Children, nodes[t.TaskID])
+		} else {
+			roots = append(roots, nodes[t.TaskID])
+		}
+	}
+	visited := make(map[*pb.TaskNode]bool, len(nodes))
+	for _, root := range roots {
+		rollUp(root, visited)
+	}
+	return roots
+}
+
+// 叶子任务的工时取自身，父任务的工时、状态、进度由子任务汇总
+func rollUp(node *pb.TaskNode, visited map[*pb.TaskNode]bool) {
+	visited[node] = true
+	if len(node.Children) == 0 {
+		node.TotalEstimatedHours = node.T.EstimatedHours
+		node.RolledUpState = node.T.State
+		if node.T.State == TASK_STATE_TEXT_FINISH {
+			node.Progress = 1
+		}
+		return
+	}
+	var done float64
+	states := make([]string, 0, len(node.Children))
+	for _, child := range node.Children {
+		if !visited[child] {
+			rollUp(child, visited)
+		}
+		node.TotalEstimatedHours += child.TotalEstimatedHours
+		done += child.Progress * child.TotalEstimatedHours
+		states = append(states, child.RolledUpState)
+	}
+	node.RolledUpState = rollUpState(states)
+	if node.TotalEstimatedHours > 0 {
+		node.Progress = done / node.TotalEstimatedHours
+	} else {
+		var sum float64
+		for _, child := range node.Children {
+			sum += child.Progress
+		}
+		node.Progress = sum / float64(len(node.Children))
+	}
+}
+
+func (s *server) MoveTask(ctx context.Context, in *pb.MoveTaskRequest) (*pb.MoveTaskReply, error) {
+	if _, err := maxLen("newReqNo", in.NewReqNo, 20); err != nil {
+		return nil, err
+	}
+	var moved []TaskInfo
+	err := db.Transaction(func(tx *gorm.DB) error {
+		moved = nil
+		task, err := findTask(tx.Clauses(clause.Locking{Strength: "UPDATE"}), in.TaskId)
+		if err != nil {
+			return err
+		}
+		if task == nil {
+			return errors.New("task does not exist")
+		}
+		if task.Version != in.Version {
+			return staleTaskError(task)
+		}
+		subtree, err := subtreeIDs(tx, task.TaskID)
+		if err != nil {
+			return err
+		}
+
+		reqNo := task.ReqNo
+		if in.NewReqNo != "" {
+			reqNo = in.NewReqNo
+		}
+		if in.NewParentId != "" {
+			parent, err := findTask(tx, in.NewParentId)
+			if err != nil {
+				return err
+			}
+			if parent == nil {
+				return errors.New("parent task does not exist")
+			}
+			for _, id := range subtree {
+				if id == parent.TaskID {
+					return errors.New("task cannot be placed under itself or its subtask")
+				}
+			}
+			if in.NewReqNo != "" && in.NewReqNo != parent.ReqNo {
+				return fmt.Errorf("subtask must belong to requirement %s of its parent", parent.ReqNo)
+			}
+			reqNo = parent.ReqNo
+		}
+
+		var tasks []TaskInfo
+		if err := tx.Where("task_id IN ?", subtree).Find(&tasks).Error; err != nil {
+			return err
+		}
+		for i := range tasks {
+			updates := map[string]interface{}{"req_no": reqNo, "version": gorm.Expr("version + 1")}
+			if tasks[i].TaskID == task.TaskID {
+				updates["parent_id"] = in.NewParentId
+			} else if tasks[i].ReqNo == reqNo {
+				moved = append(moved, tasks[i])
+				continue
+			}
+			if err := tx.Model(&TaskInfo{}).Where("task_id = ?", tasks[i].TaskID).Updates(updates).Error; err != nil {
+				return err
+			}
+			after, err := findTask(tx, tasks[i].TaskID)
+			if err != nil {
+				return err
+			}
+			if err := recordAudit(ctx, tx, in.User, AUDIT_ENTITY_TASK, &tasks[i], after); err != nil {
+				return err
+			}
+			moved = append(moved, *after)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	notified := make(map[string]bool)
+	for _, t := range moved {
+		if !notified[t.Principal] {
+			notified[t.Principal] = true
+			msg := fmt.Sprintf("<%s> -> move task: %s -> <%s>", in.User, in.TaskId, t.Principal)
+			NotificationServer.updateDatabaseAndNotify(msg)
+		}
+	}
+	return &pb.MoveTaskReply{Tasks: common.AllTaskInfoToPbTask(moved)}, nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"OrderManager/common"
+	"OrderManager/pb"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"regexp"
+	"strings"
+)
+
+var colorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// 任务类型可以要求的必填字段
+var taskRequiredFields = map[string]func(t *TaskInfo) bool{
+	"comment":        func(t *TaskInfo) bool { return t.Comment != "" },
+	"deadline":       func(t *TaskInfo) bool { return !t.Deadline.IsZero() },
+	"principal":      func(t *TaskInfo) bool { return t.Principal != "" },
+	"reqNo":          func(t *TaskInfo) bool { return t.ReqNo != "" },
+	"estimatedHours": func(t *TaskInfo) bool { return t.EstimatedWorkHours > 0 },
+	"parentId":       func(t *TaskInfo) bool { return t.ParentID != "" },
+}
+
+func validateTaskType(t *pb.TaskType) error {
+	if t == nil {
+		return errors.New("task type is required")
+	}
+	if t.Id < 0 {
+		return fmt.Errorf("invalid id %d", t.Id)
+	}
+	if _, err := required("name", t.Name, 20); err != nil {
+		return err
+	}
+	if t.Color != "" && !colorPattern.MatchString(t.Color) {
+		return fmt.Errorf("invalid color %q", t.Color)
+	}
+	if t.DefaultEstimatedHours < 0 {
+		return errors.New("defaultEstimatedHours cannot be negative")
+	}
+	if t.DefaultEmergencyLevel < EMERGENCY_LEVEL_0 || t.DefaultEmergencyLevel > EMERGENCY_LEVEL_2 {
+		return fmt.Errorf("invalid defaultEmergencyLevel %d", t.DefaultEmergencyLevel)
+	}
+	seen := make(map[string]bool, len(t.RequiredFields))
+	for _, f := range t.RequiredFields {
+		if _, ok := taskRequiredFields[f]; !ok {
+			return fmt.Errorf("unknown required field %q", f)
+		}
+		if seen[f] {
+			return fmt.Errorf("duplicate required field %q", f)
+		}
+		seen[f] = true
+	}
+	return nil
+}
+
+// 所有已登记的任务类型，为空表示尚未配置，此时不做校验
+func loadTaskTypes(tx *gorm.DB) (map[int]TaskTypeInfo, error) {
+	var types []TaskTypeInfo
+	if err := tx.Find(&types).Error; err != nil {
+		return nil, err
+	}
+	res := make(map[int]TaskTypeInfo, len(types))
+	for _, t := range types {
+		res[t.ID] = t
+	}
+	return res, nil
+}
+
+// 先校验任务类型的必填字段，再填充默认值：工时为 0 或未填写紧急程度（levelSet 为 false）时取类型的默认值
+func applyTaskType(types map[int]TaskTypeInfo, task *TaskInfo, levelSet bool) error {
+	if len(types) == 0 {
+		return nil
+	}
+	tt, ok := types[task.Type]
+	if !ok {
+		return fmt.Errorf("unknown task type %d", task.Type)
+	}
+	for _, f := range strings.Split(tt.RequiredFields, ",") {
+		if check, ok := taskRequiredFields[f]; ok && !check(task) {
+			return fmt.Errorf("%s is required for task type %s", f, tt.Name)
+		}
+	}
+	if task.EstimatedWorkHours == 0 {
+		task.EstimatedWorkHours = tt.DefaultEstimatedHours
+	}
+	if !levelSet {
+		task.EmergencyLevel = tt.DefaultEmergencyLevel
+	}
+	return nil
+}
+
+func (s *server) CreateTaskType(ctx context.Context, in *pb.CreateTaskTypeRequest) (*pb.CreateTaskTypeReply, error) {
+	if err := validateTaskType(in.T); err != nil {
+		return nil, err
+	}
+	tt := common.PbTaskTypeToTaskTypeInfo(in.T)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := requireAdmin(tx, operatorName(ctx, in.User)); err != nil {
+			return err
+		}
+		if err := tx.Create(tt).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return errors.New("task type id or name already exists")
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreateTaskTypeReply{T: common.TaskTypeInfoToPbTaskType(*tt)}, nil
+}
+
+func (s *server) UpdateTaskType(ctx context.Context, in *pb.UpdateTaskTypeRequest) (*pb.UpdateTaskTypeReply, error) {
+	if err := validateTaskType(in.T); err != nil {
+		return nil, err
+	}
+	tt := common.PbTaskTypeToTaskTypeInfo(in.T)
+	var after TaskTypeInfo
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := requireAdmin(tx, operatorName(ctx, in.User)); err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", tt.ID).First(&after).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("task type does not exist")
+			}
+			return err
+		}
+		if err := tx.Model(&after).
+			Select("name", "color", "default_estimated_hours", "default_emergency_level", "required_fields").Updates(tt).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return errors.New("task type name already exists")
+			}
+			return err
+		}
+		return tx.Where("id = ?", tt.ID).First(&after).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.UpdateTaskTypeReply{T: common.TaskTypeInfoToPbTaskType(after)}, nil
+}
+
+func (s *server) DeleteTaskType(ctx context.Context, in *pb.DeleteTaskTypeRequest) (*pb.DeleteTaskTypeReply, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := requireAdmin(tx, operatorName(ctx, in.User)); err != nil {
+			return err
+		}
+		var n int64
+		if err := tx.Unscoped().Model(&TaskInfo{}).Where("type = ?", in.Id).Count(&n).Error; err != nil {
+			return err
+		}
+		if n > 0 {
+			return fmt.Errorf("task type is used by %d tasks", n)
+		}
+		res := tx.Where("id = ?", in.Id).Delete(&TaskTypeInfo{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errors.New("task type does not exist")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DeleteTaskTypeReply{}, nil
+}
+
+func (s *server) ListTaskTypes(ctx context.Context, in *pb.ListTaskTypesRequest) (*pb.ListTaskTypesReply, error) {
+	var types []TaskTypeInfo
+	if err := db.Order("id").Find(&types).Error; err != nil {
+		return nil, err
+	}
+	return &pb.ListTaskTypesReply{Types: common.AllTaskTypeInfoToPbTaskType(types)}, nil
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"OrderManager/common"
+	"OrderManager/pb"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"time"
+)
+
+// 回收站保留时间，超过后由 trashPurgeClock 彻底删除
+const trashRetention = 30 * 24 * time.Hour
+
+func softDeleteTask(ctx context.Context, tx *gorm.DB, user string, task *TaskInfo, reason, withPatch string) error {
+	before := *task
+	now := time.Now()
+	task.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+	task.DeletedBy = operatorName(ctx, user)
+	task.DeleteReason = reason
+	task.DeletedWithPatch = withPatch
+	if err := tx.Model(&TaskInfo{}).Where("task_id = ?", task.TaskID).Updates(map[string]interface{}{
+		"deleted_at":         now,
+		"deleted_by":         task.DeletedBy,
+		"delete_reason":      reason,
+		"deleted_with_patch": withPatch,
+	}).Error; err != nil {
+		return err
+	}
+	if err := recordAuditAction(ctx, tx, user, AUDIT_ENTITY_TASK, AUDIT_ACTION_DELETE, &before, task); err != nil {
+		return err
+	}
+	// 回收站中的前置修改单不再阻塞依赖方
+	dependents, err := dependentsOf(tx, []string{task.TaskID})
+	if err != nil {
+		return err
+	}
+	return refreshBlocked(ctx, tx, user, dependents)
+}
+
+func softDeletePatch(ctx context.Context, tx *gorm.DB, user string, patch *PatchsInfo, reason string) error {
+	before := *patch
+	now := time.Now()
+	patch.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+	patch.DeletedBy = operatorName(ctx, user)
+	patch.DeleteReason = reason
+	if err := tx.Model(&PatchsInfo{}).Where("patch_no = ?", patch.PatchNo).Updates(map[string]interface{}{
+		"deleted_at":    now,
+		"deleted_by":    patch.DeletedBy,
+		"delete_reason": reason,
+	}).Error; err != nil {
+		return err
+	}
+	return recordAuditAction(ctx, tx, user, AUDIT_ENTITY_PATCH, AUDIT_ACTION_DELETE, &before, patch)
+}
+
+func restoreTask(ctx context.Context, tx *gorm.DB, user string, task *TaskInfo) (*TaskInfo, error) {
+	if err := tx.Unscoped().Model(&TaskInfo{}).Where("task_id = ?", task.TaskID).Updates(map[string]interface{}{
+		"deleted_at":         nil,
+		"deleted_by":         "",
+		"delete_reason":      "",
+		"deleted_with_patch": "",
+		"version":            gorm.Expr("version + 1"),
+	}).Error; err != nil {
+		return nil, err
+	}
+	after, err := findTask(tx, task.TaskID)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordAuditAction(ctx, tx, user, AUDIT_ENTITY_TASK, AUDIT_ACTION_RESTORE, task, after); err != nil {
+		return nil, err
+	}
+	dependents, err := dependentsOf(tx, []string{task.TaskID})
+	if err != nil {
+		return nil, err
+	}
+	if err := refreshBlocked(ctx, tx, user, append([]string{task.TaskID}, dependents...)); err != nil {
+		return nil, err
+	}
+	return findTask(tx, task.TaskID)
+}
+
+func restorePatch(ctx context.Context, tx *gorm.DB, user string, patch *PatchsInfo) (*PatchsInfo, error) {
+	if err := tx.Unscoped().Model(&PatchsInfo{}).Where("patch_no = ?", patch.PatchNo).Updates(map[string]interface{}{
+		"deleted_at":    nil,
+		"deleted_by":    "",
+		"delete_reason": "",
+		"version":       gorm.Expr("version + 1"),
+	}).Error; err != nil {
+		return nil, err
+	}
+	after, err := findPatch(tx, patch.PatchNo)
+	if err != nil {
+		return nil, err
+	}
+	return after, recordAuditAction(ctx, tx, user, AUDIT_ENTITY_PATCH, AUDIT_ACTION_RESTORE, patch, after)
+}
+
+func trashInfoOf(deletedAt gorm.DeletedAt, deletedBy, reason, withPatch string) *pb.TrashInfo {
+	return &pb.TrashInfo{
+		DeletedAt:        deletedAt.Time.Format("2006-01-02 15:04:05"),
+		DeletedBy:        deletedBy,
+		Reason:           reason,
+		DeletedWithPatch: withPatch,
+	}
+}
+
+func (s *server) ListTrash(ctx context.Context, in *pb.ListTrashRequest) (*pb.ListTrashReply, error) {
+	reply := &pb.ListTrashReply{}
+	if in.EntityType == "" || in.EntityType == AUDIT_ENTITY_TASK {
+		var tasks []TaskInfo
+		if err := db.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at desc").Find(&tasks).Error; err != nil {
+			return nil, err
+		}
+		for _, t := range tasks {
+			reply.Tasks = append(reply.Tasks, &pb.TrashedTask{
+				T:     common.OneTaskInfoToPbTask(t),
+				Trash: trashInfoOf(t.DeletedAt, t.DeletedBy, t.DeleteReason, t.DeletedWithPatch),
+			})
+		}
+	}
+	if in.EntityType == "" || in.EntityType == AUDIT_ENTITY_PATCH {
+		var patchs []PatchsInfo
+		if err := db.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at desc").Find(&patchs).Error; err != nil {
+			return nil, err
+		}
+		for _, p := range patchs {
+			reply.Patchs = append(reply.Patchs, &pb.TrashedPatch{
+				P:     common.OnePatchsInfoToPbPatchs(p),
+				Trash: trashInfoOf(p.DeletedAt, p.DeletedBy, p.DeleteReason, ""),
+			})
+		}
+	}
+	return reply, nil
+}
+
+func (s *server) RestoreTask(ctx context.Context, in *pb.RestoreTaskRequest) (*pb.RestoreTaskReply, error) {
+	var after *TaskInfo
+	err := db.Transaction(func(tx *gorm.DB) error {
+		task, err := findTask(tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}), in.TaskId)
+		if err != nil {
+			return err
+		}
+		if task == nil {
+			return errors.New("task does not exist")
+		}
+		if !task.DeletedAt.Valid {
+			return errors.New("task is not in the trash")
+		}
+		after, err = restoreTask(ctx, tx, in.User, task)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	msg := fmt.Sprintf("<%s> -> restore task: %s -> <%s>", in.User, after.TaskID, after.Principal)
+	NotificationServer.updateDatabaseAndNotify(msg)
+	return &pb.RestoreTaskReply{T: common.OneTaskInfoToPbTask(*after)}, nil
+}
+
+func (s *server) RestorePatch(ctx context.Context, in *pb.RestorePatchRequest) (*pb.RestorePatchReply, error) {
+	var after *PatchsInfo
+	var restored []TaskInfo
+	err := db.Transaction(func(tx *gorm.DB) error {
+		patch, err := findPatch(tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}), in.PatchNo)
+		if err != nil {
+			return err
+		}
+		if patch == nil {
+			return errors.New("patch does not exist")
+		}
+		if !patch.DeletedAt.Valid {
+			return errors.New("patch is not in the trash")
+		}
+		if after, err = restorePatch(ctx, tx, in.User, patch); err != nil {
+			return err
+		}
+
+		// 随补丁一同删除的修改单一起恢复
+		var tasks []TaskInfo
+		if err := tx.Unscoped().Where("deleted_with_patch = ? AND deleted_at IS NOT NULL", in.PatchNo).Find(&tasks).Error; err != nil {
+			return err
+		}
+		for i := range tasks {
+			task, err := restoreTask(ctx, tx, in.User, &tasks[i])
+			if err != nil {
+				return err
+			}
+			restored = append(restored, *task)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	msg := fmt.Sprintf("<%s> -> restore patchs:%s -> <ALL>", in.User, in.PatchNo)
+	NotificationServer.updateDatabaseAndNotify(msg)
+	return &pb.RestorePatchReply{P: common.OnePatchsInfoToPbPatchs(*after), Tasks: common.AllTaskInfoToPbTask(restored)}, nil
+}
+
+// 彻底删除在回收站中超过保留时间的修改单和补丁
+func purgeTrash(cutoff time.Time) {
+	ctx := context.Background()
+	var purged int
+	err := db.Transaction(func(tx *gorm.DB) error {
+		purged = 0
+		var tasks []TaskInfo
+		if err := tx.Unscoped().Where("deleted_at < ?", cutoff).Find(&tasks).Error; err != nil {
+			return err
+		}
+		for i := range tasks {
+			if err := tx.Unscoped().Delete(&tasks[i]).Error; err != nil {
+				return err
+			}
+			if err := recordAuditAction(ctx, tx, "system", AUDIT_ENTITY_TASK, AUDIT_ACTION_PURGE, &tasks[i], nil); err != nil {
+				return err
+			}
+			if err := tx.Where("task_id = ? OR depends_on = ?", tasks[i].TaskID, tasks[i].TaskID).Delete(&TaskDependencyInfo{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("entity_type = ? AND entity_id = ?", AUDIT_ENTITY_TASK, tasks[i].TaskID).Delete(&EntityLabelInfo{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("task_id = ?", tasks[i].TaskID).Delete(&ExternalIssueInfo{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("task_id = ?", tasks[i].TaskID).Delete(&TaskCommitInfo{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("task_id = ?", tasks[i].TaskID).Delete(&EscalationInfo{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Where("entity_type = ? AND entity_id = ?", AUDIT_ENTITY_TASK, tasks[i].TaskID).Delete(&CommentInfo{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("task_id = ?", tasks[i].TaskID).Delete(&WorkLogInfo{}).Error; err != nil {
+				return err
+			}
+		}
+		var patchs []PatchsInfo
+		if err := tx.Unscoped().Where("deleted_at < ?", cutoff).Find(&patchs).Error; err != nil {
+			return err
+		}
+		for i := range patchs {
+			if err := tx.Unscoped().Delete(&patchs[i]).Error; err != nil {
+				return err
+			}
+			if err := recordAuditAction(ctx, tx, "system", AUDIT_ENTITY_PATCH, AUDIT_ACTION_PURGE, &patchs[i], nil); err != nil {
+				return err
+			}
+			if err := tx.Where("entity_type = ? AND entity_id = ?", AUDIT_ENTITY_PATCH, patchs[i].PatchNo).Delete(&EntityLabelInfo{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Where("entity_type = ? AND entity_id = ?", AUDIT_ENTITY_PATCH, patchs[i].PatchNo).Delete(&CommentInfo{}).Error; err != nil {
+				return err
+			}
+		}
+		purged = len(tasks) + len(patchs)
+		return nil
+	})
+	if err != nil {
+		log.Println("清理回收站失败：", err)
+	} else if purged > 0 {
+		log.Printf("清理回收站：%d 条记录", purged)
+	}
+}
+
+// 每天凌晨 3 点清理回收站
+func trashPurgeClock() {
+	for {
+		now := time.Now()
+		next := time.Date(now.Year(), now.Month(), now.Day(), 3, 0, 0, 0, now.Location())
+		if now.After(next) {
+			next = next.Add(24 * time.Hour)
+		}
+		time.Sleep(next.Sub(now))
+		purgeTrash(time.Now().Add(-trashRetention))
+	}
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"OrderManager/common"
+	"OrderManager/pb"
+	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+	"time"
+)
+
+var errPermissionDenied = errors.New("permission denied")
+
+// 工号为 0 表示未填写
+func optionalJobNo(jobNo int64) *int {
+	if jobNo == 0 {
+		return nil
+	}
+	n := int(jobNo)
+	return &n
+}
+
+// 邮箱为空表示未填写
+func optionalEmail(email string) *string {
+	if email == "" {
+		return nil
+	}
+	return &email
+}
+
+func sameJobNo(a, b *int) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+func sameEmail(a, b *string) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+// 检查姓名、工号、邮箱是否已被其他用户占用（excludeID 为当前用户，新注册时为 0），未填写的工号和邮箱不检查
+func checkUserUnique(tx *gorm.DB, excludeID uint, name string, jobNo *int, email *string) error {
+	cond := tx.Where("name = ?", name)
+	if jobNo != nil {
+		cond = cond.Or("job_no = ?", *jobNo)
+	}
+	if email != nil {
+		cond = cond.Or("email = ?", *email)
+	}
+	var existing []UserInfo
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where(cond).Where("id <> ?", excludeID).
+		Find(&existing).Error; err != nil {
+		return err
+	}
+	for _, u := range existing {
+		switch {
+		case u.Name == name:
+			return errors.New("user already exists")
+		case jobNo != nil && sameJobNo(u.JobNo, jobNo):
+			return errors.New("job number already in use")
+		case email != nil && sameEmail(u.Email, email):
+			return errors.New("email already in use")
+		}
+	}
+	return nil
+}
+
+// 建立唯一索引前检查已有数据中重复的值，有重复时报告出来由管理员处理，否则 AutoMigrate 会直接失败
+func checkUserDuplicates(tx *gorm.DB) error {
+	indexes := []struct{ column, index string }{
+		{"name", "user_table_name_uindex"},
+		{"job_no", "user_table_job_no_uindex"},
+		{"email", "user_table_email_uindex"},
+	}
+	var problems []string
+	for _, idx := range indexes {
+		if tx.Migrator().HasIndex(&UserInfo{}, idx.index) {
+			continue
+		}
+		var values []string
+		err := tx.Model(&UserInfo{}).Where(idx.column+" IS NOT NULL").
+			Group(idx.column).Having("COUNT(*) > 1").Pluck(idx.column, &values).Error
+		if err != nil {
+			return err
+		}
+		if len(values) > 0 {
+			problems = append(problems, fmt.Sprintf("%s: %s", idx.column, strings.Join(values, ", ")))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("user_table has duplicate values, fix them before starting the server (%s)", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// 旧版本的 user_table 中工号和邮箱不能为空，未填写时存的是 0 和空字符串，
+// 在建立唯一索引之前改为允许 NULL 并把这些值改为 NULL，再检查重复的值
+func migrateUserKeys(tx *gorm.DB) error {
+	m := tx.Migrator()
+	if !m.HasTable(&UserInfo{}) {
+		return nil
+	}
+	columns, err := m.ColumnTypes(&UserInfo{})
+	if err != nil {
+		return err
+	}
+	for _, c := range columns {
+		field := map[string]string{"job_no": "JobNo", "email": "Email"}[c.Name()]
+		if nullable, ok := c.Nullable(); field == "" || !ok || nullable {
+			continue
+		}
+		if err := m.AlterColumn(&UserInfo{}, field); err != nil {
+			return err
+		}
+	}
+	if err := tx.Model(&UserInfo{}).Where("job_no = 0").Update("job_no", nil).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&UserInfo{}).Where("email = ''").Update("email", nil).Error; err != nil {
+		return err
+	}
+	return checkUserDuplicates(tx)
+}
+
+func findUserByName(tx *gorm.DB, name string) (*UserInfo, error) {
+	var user UserInfo
+	if err := tx.Where("name = ?", name).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("this user does not exist")
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func requireAdmin(tx *gorm.DB, name string) error {
+	user, err := findUserByName(tx, name)
+	if err != nil {
+		return errPermissionDenied
+	}
+	if !user.Active || user.RoleNo != ROLE_ADMIN {
+		return errPermissionDenied
+	}
+	return nil
+}
+
+func (s *server) GetProfile(ctx context.Context, in *pb.GetProfileRequest) (*pb.GetProfileReply, error) {
+	user, err := findUserByName(db, in.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetProfileReply{User: common.UserInfoToPbUser(*user)}, nil
+}
+
+func (s *server) UpdateProfile(ctx context.Context, in *pb.UpdateProfileRequest) (*pb.UpdateProfileReply, error) {
+	if in.Profile == nil {
+		return nil, errors.New("profile is required")
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var target UserInfo
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("name = ?", in.Profile.Name).First(&target).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("this user does not exist")
+			}
+			return err
+		}
+		// 修改他人资料、分组或角色需要管理员权限
+		operator := operatorName(ctx, in.User)
+		if operator != target.Name || int(in.Profile.Group) != target.Group || int(in.Profile.RoleNo) != target.RoleNo {
+			if err := requireAdmin(tx, operator); err != nil {
+				return err
+			}
+		}
+		// 工号为 0、邮箱为空表示清空
+		jobNo, email := optionalJobNo(in.Profile.JobNum), optionalEmail(in.Profile.Email)
+		if err := checkUserUnique(tx, target.ID, target.Name, jobNo, email); err != nil {
+			return err
+		}
+		return tx.Model(&target).Updates(map[string]interface{}{
+			"job_no":  jobNo,
+			"email":   email,
+			"group":   in.Profile.Group,
+			"role_no": in.Profile.RoleNo,
+		}).Error
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil, errors.New("job number or email already in use")
+	} else if err != nil {
+		return nil, err
+	}
+	return &pb.UpdateProfileReply{}, nil
+}
+
+func (s *server) ChangePassword(ctx context.Context, in *pb.ChangePasswordRequest) (*pb.ChangePasswordReply, error) {
+	if in.NewPassword == "" {
+		return nil, errors.New("new password is required")
+	}
+	now := time.Now()
+	ip := peerIP(ctx)
+	if loginGuard.lockedFor(in.Name, ip, now) > 0 {
+		return nil, status.Error(codes.ResourceExhausted, "too many failed attempts, try again later")
+	}
+	var existing *UserInfo
+	var found UserInfo
+	res := db.Where("name = ?", in.Name).First(&found)
+	if res.Error == nil {
+		existing = &found
+	} else if !errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return nil, res.Error
+	}
+	// 先按用户来源校验旧密码，通过后才提示目录用户不能在这里改密码，避免泄露账号是否存在；
+	// 用户不存在时不交给 LDAP，以免自动创建用户
+	provider := authProviders[AUTH_SOURCE_LOCAL]
+	if existing != nil {
+		provider = providerFor(existing)
+	}
+	user, err := provider.authenticate(ctx, in.Name, in.OldPassword, existing)
+	var failure *authFailure
+	if errors.As(err, &failure) {
+		recordAuthEvent(ctx, db, AUTH_EVENT_LOGIN_FAILURE, in.Name, "change password: "+failure.reason)
+		if loginGuard.fail(in.Name, ip, now) {
+			recordAuthEvent(ctx, db, AUTH_EVENT_LOCKOUT, in.Name, "ip "+ip)
+		}
+		return nil, errLoginFailed
+	} else if err != nil {
+		return nil, err
+	}
+	if user.Source != AUTH_SOURCE_LOCAL {
+		return nil, errors.New("password is managed by the directory")
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(in.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	// 修改密码后所有会话失效，需要重新登录
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("password", string(hashedPassword)).Error; err != nil {
+			return err
+		}
+		if err := revokeSessions(tx, user.ID); err != nil {
+			return err
+		}
+		recordAuthEvent(ctx, tx, AUTH_EVENT_PASSWORD_CHANGE, user.Name, "")
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ChangePasswordReply{}, nil
+}
+
+func (s *server) DeactivateUser(ctx context.Context, in *pb.DeactivateUserRequest) (*pb.DeactivateUserReply, error) {
+	operator := operatorName(ctx, in.User)
+	if in.Name == operator {
+		return nil, errors.New("cannot deactivate yourself")
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := requireAdmin(tx, operator); err != nil {
+			return err
+		}
+		target, err := findUserByName(tx, in.Name)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(target).Update("active", false).Error; err != nil {
+			return err
+		}
+		if err := revokeSessions(tx, target.ID); err != nil {
+			return err
+		}
+		recordAuthEvent(ctx, tx, AUTH_EVENT_USER_DEACTIVATED, target.Name, "by "+operator)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DeactivateUserReply{}, nil
+}
+
+func (s *server) ListUsers(ctx context.Context, in *pb.ListUsersRequest) (*pb.ListUsersReply, error) {
+	if err := requireAdmin(db, operatorName(ctx, in.User)); err != nil {
+		return nil, err
+	}
+	query := db.Model(&UserInfo{})
+	if in.Group != nil {
+		query = query.Where("`group` = ?", in.GetGroup())
+	}
+	if in.RoleNo != nil {
+		query = query.Where("role_no = ?", in.GetRoleNo())
+	}
+	if !in.IncludeInactive {
+		query = query.Where("active = ?", true)
+	}
+	var users []UserInfo
+	if err := query.Order("id").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return &pb.ListUsersReply{Users: common.AllUserInfoToPbUser(users)}, nil
+}
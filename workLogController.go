@@ -0,0 +1,214 @@
+package main
+
+import (
+	"OrderManager/pb"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	WORK_REPORT_BY_PRINCIPAL = "principal"
+	WORK_REPORT_BY_REQ_NO    = "reqNo"
+	WORK_REPORT_BY_PATCH     = "patch"
+)
+
+// 每人每天最多可填报的工时
+const maxWorkHoursPerDay = 24
+
+func workLogInfoToPbWorkLog(w *WorkLogInfo) *pb.WorkLog {
+	return &pb.WorkLog{
+		Id:        uint64(w.ID),
+		TaskId:    w.TaskID,
+		Name:      w.Name,
+		Date:      w.WorkDate.Format("2006-01-02"),
+		Hours:     w.Hours,
+		Note:      w.Note,
+		CreatedAt: w.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// 按日期区间（含两端）过滤 work_date
+func whereWorkDate(query *gorm.DB, since, until string) (*gorm.DB, error) {
+	if since != "" {
+		if _, err := time.Parse("2006-01-02", since); err != nil {
+			return nil, fmt.Errorf("invalid since %q", since)
+		}
+		query = query.Where("work_date >= ?", since)
+	}
+	if until != "" {
+		if _, err := time.Parse("2006-01-02", until); err != nil {
+			return nil, fmt.Errorf("invalid until %q", until)
+		}
+		query = query.Where("work_date <= ?", until)
+	}
+	return query, nil
+}
+
+func (s *server) LogWork(ctx context.Context, in *pb.LogWorkRequest) (*pb.LogWorkReply, error) {
+	name := operatorName(ctx, in.User)
+	if name == "" {
+		return nil, errors.New("user is required")
+	}
+	if in.Hours <= 0 || in.Hours > maxWorkHoursPerDay {
+		return nil, fmt.Errorf("hours must be between 0 and %d", maxWorkHoursPerDay)
+	}
+	date := time.Now().Format("2006-01-02")
+	if in.Date != "" {
+		date = in.Date
+	}
+	workDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q", in.Date)
+	}
+
+	w := &WorkLogInfo{TaskID: in.TaskId, Name: name, WorkDate: workDate, Hours: in.Hours, Note: in.Note}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		task, err := findTask(tx, in.TaskId)
+		if err != nil {
+			return err
+		}
+		if task == nil {
+			return errors.New("task does not exist")
+		}
+		// 锁住填报人，同一人的并发填报依次检查当天的工时合计
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("name = ?", name).First(&UserInfo{}).Error; err != nil {
+			return err
+		}
+		var logged float64
+		if err := tx.Model(&WorkLogInfo{}).Select("COALESCE(SUM(hours), 0)").
+			Where("name = ? AND work_date = ?", name, date).Scan(&logged).Error; err != nil {
+			return err
+		}
+		if logged+in.Hours > maxWorkHoursPerDay {
+			return fmt.Errorf("%s already logged %.1f hours on %s", name, logged, date)
+		}
+		return tx.Create(w).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.LogWorkReply{Log: workLogInfoToPbWorkLog(w)}, nil
+}
+
+func (s *server) ListWorkLogs(ctx context.Context, in *pb.ListWorkLogsRequest) (*pb.ListWorkLogsReply, error) {
+	query := db.Model(&WorkLogInfo{})
+	if in.TaskId != "" {
+		query = query.Where("task_id = ?", in.TaskId)
+	}
+	if in.Name != "" {
+		query = query.Where("name = ?", in.Name)
+	}
+	query, err := whereWorkDate(query, in.Since, in.Until)
+	if err != nil {
+		return nil, err
+	}
+	var logs []WorkLogInfo
+	if err := query.Order("work_date, id").Find(&logs).Error; err != nil {
+		return nil, err
+	}
+
+	reply := &pb.ListWorkLogsReply{Logs: make([]*pb.WorkLog, len(logs))}
+	actual := make(map[string]float64)
+	var taskIDs []string
+	for i := range logs {
+		reply.Logs[i] = workLogInfoToPbWorkLog(&logs[i])
+		if _, ok := actual[logs[i].TaskID]; !ok {
+			taskIDs = append(taskIDs, logs[i].TaskID)
+		}
+		actual[logs[i].TaskID] += logs[i].Hours
+	}
+	estimated := make(map[string]float64)
+	if len(taskIDs) > 0 {
+		var tasks []TaskInfo
+		if err := db.Unscoped().Select("task_id", "estimated_work_hours").Where("task_id IN ?", taskIDs).Find(&tasks).Error; err != nil {
+			return nil, err
+		}
+		for _, t := range tasks {
+			estimated[t.TaskID] = t.EstimatedWorkHours
+		}
+	}
+	for _, id := range taskIDs {
+		reply.Totals = append(reply.Totals, &pb.TaskWorkTotal{TaskId: id, EstimatedHours: estimated[id], ActualHours: actual[id]})
+	}
+	return reply, nil
+}
+
+func (s *server) GetWorkReport(ctx context.Context, in *pb.GetWorkReportRequest) (*pb.GetWorkReportReply, error) {
+	var tasks []TaskInfo
+	if err := db.Select("task_id", "principal", "req_no", "estimated_work_hours").Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+
+	// 各修改单在时间范围内的实际工时
+	type taskHours struct {
+		TaskID string
+		Hours  float64
+	}
+	query, err := whereWorkDate(db.Model(&WorkLogInfo{}), in.Since, in.Until)
+	if err != nil {
+		return nil, err
+	}
+	var sums []taskHours
+	if err := query.Select("task_id, SUM(hours) AS hours").Group("task_id").Scan(&sums).Error; err != nil {
+		return nil, err
+	}
+	actual := make(map[string]float64, len(sums))
+	for _, h := range sums {
+		actual[h.TaskID] = h.Hours
+	}
+
+	// 每个修改单归属的分组
+	var keysOf func(t *TaskInfo) []string
+	switch in.GroupBy {
+	case WORK_REPORT_BY_PRINCIPAL, "":
+		keysOf = func(t *TaskInfo) []string { return []string{t.Principal} }
+	case WORK_REPORT_BY_REQ_NO:
+		keysOf = func(t *TaskInfo) []string { return []string{t.ReqNo} }
+	case WORK_REPORT_BY_PATCH:
+		var patchs []PatchsInfo
+		if err := db.Select("patch_no", "req_no").Find(&patchs).Error; err != nil {
+			return nil, err
+		}
+		patchsOfReq := make(map[string][]string)
+		for _, p := range patchs {
+			for _, reqNo := range strings.Split(p.ReqNo, ",") {
+				patchsOfReq[reqNo] = append(patchsOfReq[reqNo], p.PatchNo)
+			}
+		}
+		keysOf = func(t *TaskInfo) []string { return patchsOfReq[t.ReqNo] }
+	default:
+		return nil, fmt.Errorf("unknown groupBy: %s", in.GroupBy)
+	}
+
+	// 指定了时间范围时只统计范围内有工时的修改单，否则预估工时与实际工时不可比
+	dateRanged := in.Since != "" || in.Until != ""
+	rows := make(map[string]*pb.WorkReportRow)
+	for i := range tasks {
+		if _, logged := actual[tasks[i].TaskID]; dateRanged && !logged {
+			continue
+		}
+		for _, key := range keysOf(&tasks[i]) {
+			row, ok := rows[key]
+			if !ok {
+				row = &pb.WorkReportRow{Key: key}
+				rows[key] = row
+			}
+			row.TaskCount++
+			row.EstimatedHours += tasks[i].EstimatedWorkHours
+			row.ActualHours += actual[tasks[i].TaskID]
+		}
+	}
+	reply := &pb.GetWorkReportReply{}
+	for _, row := range rows {
+		row.Variance = row.ActualHours - row.EstimatedHours
+		reply.Rows = append(reply.Rows, row)
+	}
+	sort.Slice(reply.Rows, func(i, j int) bool { return reply.Rows[i].Key < reply.Rows[j].Key })
+	return reply, nil
+}
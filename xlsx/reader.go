@@ -0,0 +1,379 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 单个压缩文件解压后的大小上限，防止压缩炸弹
+const maxPartSize = 64 << 20
+
+// Excel 工作表的行数、列数上限
+const (
+	maxRows    = 1048576
+	maxColumns = 16384
+)
+
+// 单个工作表累计的单元格数上限（含为对齐列号补上的空单元格），
+// 防止很小的文件在很远的列写入单元格时占用大量内存
+const maxCells = 1 << 21
+
+type workbookXML struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+	Pr struct {
+		Date1904 bool `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+}
+
+type relationshipsXML struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// 富文本的 <si> 由多个 <r><t> 组成
+type stringItemXML struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (si stringItemXML) text() string {
+	if len(si.R) == 0 {
+		return si.T
+	}
+	var b strings.Builder
+	for _, r := range si.R {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+type sharedStringsXML struct {
+	Items []stringItemXML `xml:"si"`
+}
+
+type stylesXML struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+// 工作表 sheetData 中的一行
+type rowXML struct {
+	R     int `xml:"r,attr"`
+	Cells []struct {
+		R  string        `xml:"r,attr"`
+		T  string        `xml:"t,attr"`
+		S  int           `xml:"s,attr"`
+		V  string        `xml:"v"`
+		Is stringItemXML `xml:"is"`
+	} `xml:"c"`
+}
+
+type workbook struct {
+	files      map[string]*zip.File
+	info       workbookXML
+	rels       relationshipsXML
+	shared     sharedStringsXML
+	dateStyles map[int]bool
+}
+
+func (w *workbook) read(name string) ([]byte, error) {
+	f, ok := w.files[name]
+	if !ok {
+		return nil, fmt.Errorf("xlsx: missing %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxPartSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxPartSize {
+		return nil, fmt.Errorf("xlsx: %s is too large", name)
+	}
+	return data, nil
+}
+
+func (w *workbook) decode(name string, v interface{}) error {
+	data, err := w.read(name)
+	if err != nil {
+		return err
+	}
+	return xml.Unmarshal(data, v)
+}
+
+// 读取工作簿目录、共享字符串和样式
+func openWorkbook(data []byte) (*workbook, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errors.New("xlsx: not a valid .xlsx file")
+	}
+	w := &workbook{files: make(map[string]*zip.File, len(zr.File))}
+	for _, f := range zr.File {
+		w.files[f.Name] = f
+	}
+	if err := w.decode("xl/workbook.xml", &w.info); err != nil {
+		return nil, err
+	}
+	if len(w.info.Sheets) == 0 {
+		return nil, errors.New("xlsx: workbook has no sheets")
+	}
+	if err := w.decode("xl/_rels/workbook.xml.rels", &w.rels); err != nil {
+		return nil, err
+	}
+	if _, ok := w.files["xl/sharedStrings.xml"]; ok {
+		if err := w.decode("xl/sharedStrings.xml", &w.shared); err != nil {
+			return nil, err
+		}
+	}
+	var styles stylesXML
+	if _, ok := w.files["xl/styles.xml"]; ok {
+		if err := w.decode("xl/styles.xml", &styles); err != nil {
+			return nil, err
+		}
+	}
+	w.dateStyles = dateStyleSet(&styles)
+	return w, nil
+}
+
+// ReadSheet 读取工作表的所有单元格，sheet 为空时取第一个工作表。
+// 返回的行号与 Excel 一致（rows[0] 为第 1 行），空行为 nil；日期格式的单元格转为 2006-01-02 或 2006-01-02 15:04:05
+func ReadSheet(data []byte, sheet string) ([][]string, error) {
+	w, err := openWorkbook(data)
+	if err != nil {
+		return nil, err
+	}
+	rid := w.info.Sheets[0].RID
+	if sheet != "" {
+		rid = ""
+		for _, s := range w.info.Sheets {
+			if s.Name == sheet {
+				rid = s.RID
+			}
+		}
+		if rid == "" {
+			return nil, fmt.Errorf("xlsx: sheet %q not found", sheet)
+		}
+	}
+	return w.readRows(rid)
+}
+
+// Sheet 工作表名及其单元格，行号规则同 ReadSheet
+type Sheet struct {
+	Name string
+	Rows [][]string
+}
+
+// ReadSheets 按顺序读取所有工作表
+func ReadSheets(data []byte) ([]Sheet, error) {
+	w, err := openWorkbook(data)
+	if err != nil {
+		return nil, err
+	}
+	sheets := make([]Sheet, len(w.info.Sheets))
+	for i, s := range w.info.Sheets {
+		sheets[i].Name = s.Name
+		if sheets[i].Rows, err = w.readRows(s.RID); err != nil {
+			return nil, err
+		}
+	}
+	return sheets, nil
+}
+
+func (w *workbook) readRows(rid string) ([][]string, error) {
+	target := ""
+	for _, r := range w.rels.Relationships {
+		if r.ID == rid {
+			target = r.Target
+		}
+	}
+	if target == "" {
+		return nil, errors.New("xlsx: sheet relationship not found")
+	}
+	if strings.HasPrefix(target, "/") {
+		target = strings.TrimPrefix(target, "/")
+	} else {
+		target = path.Join("xl", target)
+	}
+
+	data, err := w.read(target)
+	if err != nil {
+		return nil, err
+	}
+	// 逐行解码，不把整个工作表的 XML 结构留在内存中
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var rows [][]string
+	next := 1
+	total := 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+		var row rowXML
+		if err := dec.DecodeElement(&row, &start); err != nil {
+			return nil, err
+		}
+		r := row.R
+		if r == 0 {
+			r = next
+		}
+		if r < 1 || r > maxRows {
+			return nil, fmt.Errorf("xlsx: row %d out of range", row.R)
+		}
+		next = r + 1
+		for len(rows) < r {
+			rows = append(rows, nil)
+		}
+		var cells []string
+		col := 0
+		for _, c := range row.Cells {
+			if c.R != "" {
+				if idx, ok := columnIndex(c.R); ok {
+					col = idx
+				}
+			}
+			if col < 0 || col >= maxColumns {
+				return nil, fmt.Errorf("xlsx: column out of range in %q", c.R)
+			}
+			var value string
+			switch c.T {
+			case "s":
+				idx, err := strconv.Atoi(c.V)
+				if err != nil || idx < 0 || idx >= len(w.shared.Items) {
+					return nil, fmt.Errorf("xlsx: bad shared string in %s", c.R)
+				}
+				value = w.shared.Items[idx].text()
+			case "inlineStr":
+				value = c.Is.text()
+			case "b":
+				value = map[string]string{"1": "TRUE", "0": "FALSE"}[c.V]
+			case "", "n":
+				value = c.V
+				if w.dateStyles[c.S] && c.V != "" {
+					if f, err := strconv.ParseFloat(c.V, 64); err == nil {
+						value = formatSerial(f, w.info.Pr.Date1904)
+					}
+				}
+			default:
+				value = c.V
+			}
+			// 空单元格（例如只设置了格式的）不补齐，行的长度到最后一个有值的单元格为止
+			if value = strings.TrimSpace(value); value != "" {
+				if col >= len(cells) {
+					if total += col + 1 - len(cells); total > maxCells {
+						return nil, fmt.Errorf("xlsx: sheet has more than %d cells", maxCells)
+					}
+				}
+				for len(cells) <= col {
+					cells = append(cells, "")
+				}
+				cells[col] = value
+			}
+			col++
+		}
+		rows[r-1] = cells
+	}
+	return rows, nil
+}
+
+// 列引用（如 AB12）中的列号，从 0 开始；超过 maxColumns 时返回 maxColumns
+func columnIndex(ref string) (int, bool) {
+	n := 0
+	i := 0
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		if n <= maxColumns {
+			n = n*26 + int(ref[i]-'A'+1)
+		}
+	}
+	if n > maxColumns {
+		return maxColumns, true
+	}
+	if i == 0 {
+		return 0, false
+	}
+	return n - 1, true
+}
+
+// 内置的日期格式编号
+var builtinDateFormats = map[int]bool{14: true, 15: true, 16: true, 17: true, 18: true, 19: true, 20: true, 21: true, 22: true, 45: true, 46: true, 47: true}
+
+func dateStyleSet(styles *stylesXML) map[int]bool {
+	custom := make(map[int]bool)
+	for _, f := range styles.NumFmts {
+		custom[f.ID] = isDateFormat(f.Code)
+	}
+	res := make(map[int]bool)
+	for i, xf := range styles.CellXfs {
+		if isDate, ok := custom[xf.NumFmtID]; ok {
+			res[i] = isDate
+		} else {
+			res[i] = builtinDateFormats[xf.NumFmtID]
+		}
+	}
+	return res
+}
+
+// 去掉引号和方括号中的内容后，包含 y、m、d 的格式视为日期
+func isDateFormat(code string) bool {
+	var b strings.Builder
+	quoted, bracket := false, false
+	for _, r := range code {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+		case r == '[':
+			bracket = true
+		case r == ']':
+			bracket = false
+		case !bracket:
+			b.WriteRune(r)
+		}
+	}
+	lower := strings.ToLower(b.String())
+	return strings.ContainsAny(lower, "yd") || strings.Contains(lower, "mm")
+}
+
+func formatSerial(f float64, date1904 bool) string {
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	days := math.Floor(f)
+	secs := math.Round((f - days) * 86400)
+	t := epoch.AddDate(0, 0, int(days)).Add(time.Duration(secs) * time.Second)
+	if secs == 0 {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
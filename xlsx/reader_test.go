@@ -0,0 +1,115 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 用给定的 sheetData 内容拼出一个最小的 .xlsx
+func buildWorkbook(t *testing.T, sheetData string) []byte {
+	t.Helper()
+	parts := map[string]string{
+		"xl/workbook.xml":            `<workbook><sheets><sheet name="S" r:id="rId1" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships><Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/worksheets/sheet1.xml":   `<worksheet><sheetData>` + sheetData + `</sheetData></worksheet>`,
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range parts {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadSheetRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	if _, err := w.AddSheet("tasks"); err != nil {
+		t.Fatal(err)
+	}
+	w.WriteRow([]interface{}{"id", "name", "hours"})
+	w.WriteRow([]interface{}{"T-1", "login <page>", 3})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := ReadSheet(buf.Bytes(), "tasks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"id", "name", "hours"}, {"T-1", "login <page>", "3"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+}
+
+func TestReadSheetSparse(t *testing.T) {
+	tests := []struct {
+		name      string
+		sheetData string
+		want      [][]string
+		wantErr   string
+	}{
+		{
+			name:      "gaps are padded up to the last value",
+			sheetData: `<row r="1"><c r="A1" t="inlineStr"><is><t>a</t></is></c><c r="C1"><v>1</v></c></row><row r="3"><c r="B3"><v>2</v></c></row>`,
+			want:      [][]string{{"a", "", "1"}, nil, {"", "2"}},
+		},
+		{
+			name:      "styled empty cells far to the right are not padded",
+			sheetData: `<row r="1"><c r="A1"><v>1</v></c><c r="XFD1" s="1"/></row>`,
+			want:      [][]string{{"1"}},
+		},
+		{
+			name:      "column past XFD",
+			sheetData: `<row r="1"><c r="XFE1"><v>1</v></c></row>`,
+			wantErr:   "column out of range",
+		},
+		{
+			name:      "row past the last Excel row",
+			sheetData: `<row r="1048577"><c r="A1048577"><v>1</v></c></row>`,
+			wantErr:   "out of range",
+		},
+		{
+			name:      "values far to the right on many rows",
+			sheetData: farRightRows(200),
+			wantErr:   "more than",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ReadSheet(buildWorkbook(t, tt.sheetData), "")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ReadSheet error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(rows, tt.want) {
+				t.Errorf("rows = %q, want %q", rows, tt.want)
+			}
+		})
+	}
+}
+
+// n 行，每行只在 XFD 列有一个值
+func farRightRows(n int) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, `<row r="%d"><c r="XFD%d"><v>1</v></c></row>`, i, i)
+	}
+	return b.String()
+}
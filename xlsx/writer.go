@@ -0,0 +1,195 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// 工作表名的长度上限以及不允许出现的字符
+const maxSheetNameLen = 31
+
+var sheetNameReplacer = strings.NewReplacer(
+	"[", "_", "]", "_", ":", "_", "*", "_", "?", "_", "/", "_", "\\", "_",
+)
+
+// Writer 依次写出各工作表的行，写完一个工作表后不能再回头修改，适合边查询边输出。
+// 字符串写为内联字符串，整数和浮点数写为数值
+type Writer struct {
+	zw     *zip.Writer
+	sheets []string
+	used   map[string]bool
+	cur    io.Writer
+	row    int
+	closed bool
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{zw: zip.NewWriter(w), used: make(map[string]bool)}
+}
+
+// 替换非法字符、截断过长的名称，重名时追加序号
+func (w *Writer) sheetName(name string) string {
+	name = strings.TrimSpace(sheetNameReplacer.Replace(name))
+	if name == "" {
+		name = fmt.Sprintf("Sheet%d", len(w.sheets)+1)
+	}
+	truncate := func(s string, n int) string {
+		for utf8.RuneCountInString(s) > n {
+			_, size := utf8.DecodeLastRuneInString(s)
+			s = s[:len(s)-size]
+		}
+		return s
+	}
+	res := truncate(name, maxSheetNameLen)
+	for i := 2; w.used[strings.ToLower(res)]; i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		res = truncate(name, maxSheetNameLen-len(suffix)) + suffix
+	}
+	w.used[strings.ToLower(res)] = true
+	return res
+}
+
+func (w *Writer) endSheet() error {
+	if w.cur == nil {
+		return nil
+	}
+	_, err := io.WriteString(w.cur, `</sheetData></worksheet>`)
+	w.cur = nil
+	return err
+}
+
+// AddSheet 结束当前工作表并开始一个新的工作表，返回实际使用的工作表名
+func (w *Writer) AddSheet(name string) (string, error) {
+	if w.closed {
+		return "", errors.New("xlsx: writer is closed")
+	}
+	if err := w.endSheet(); err != nil {
+		return "", err
+	}
+	name = w.sheetName(name)
+	w.sheets = append(w.sheets, name)
+	f, err := w.zw.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(w.sheets)))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.WriteString(f, xml.Header+
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`); err != nil {
+		return "", err
+	}
+	w.cur, w.row = f, 0
+	return name, nil
+}
+
+// WriteRow 在当前工作表末尾写入一行，尚未添加工作表时自动添加 Sheet1。
+// 支持 string、int、int32、int64、float64，nil 为空单元格
+func (w *Writer) WriteRow(values []interface{}) error {
+	if w.cur == nil {
+		if _, err := w.AddSheet(""); err != nil {
+			return err
+		}
+	}
+	w.row++
+	var b strings.Builder
+	fmt.Fprintf(&b, `<row r="%d">`, w.row)
+	for i, v := range values {
+		ref := columnName(i) + strconv.Itoa(w.row)
+		var num string
+		switch t := v.(type) {
+		case nil:
+			continue
+		case string:
+			if t == "" {
+				continue
+			}
+			fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			if err := xml.EscapeText(&b, []byte(t)); err != nil {
+				return err
+			}
+			b.WriteString(`</t></is></c>`)
+			continue
+		case int:
+			num = strconv.Itoa(t)
+		case int32:
+			num = strconv.FormatInt(int64(t), 10)
+		case int64:
+			num = strconv.FormatInt(t, 10)
+		case float64:
+			num = strconv.FormatFloat(t, 'f', -1, 64)
+		default:
+			return fmt.Errorf("xlsx: unsupported cell type %T", v)
+		}
+		fmt.Fprintf(&b, `<c r="%s"><v>%s</v></c>`, ref, num)
+	}
+	b.WriteString(`</row>`)
+	_, err := io.WriteString(w.cur, b.String())
+	return err
+}
+
+// Close 写出工作簿的目录信息，不关闭底层的 io.Writer
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	if len(w.sheets) == 0 {
+		if _, err := w.AddSheet(""); err != nil {
+			return err
+		}
+	}
+	if err := w.endSheet(); err != nil {
+		return err
+	}
+	w.closed = true
+
+	var types, sheets, rels strings.Builder
+	for i, name := range w.sheets {
+		fmt.Fprintf(&types, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+		fmt.Fprintf(&sheets, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escapeAttr(name), i+1, i+1)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			types.String() + `</Types>`},
+		{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets>` + sheets.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			rels.String() + `</Relationships>`},
+	}
+	for _, p := range parts {
+		f, err := w.zw.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, xml.Header+p.content); err != nil {
+			return err
+		}
+	}
+	return w.zw.Close()
+}
+
+// 列号（从 0 开始）对应的列名，如 0 -> A、27 -> AB
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// xml.EscapeText 同时转义了引号，可用于属性值
+func escapeAttr(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}